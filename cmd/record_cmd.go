@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/migalabs/goteth/pkg/fakenode"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+)
+
+var RecordCommand = &cli.Command{
+	Name:   "record",
+	Usage:  "proxy the beacon and execution nodes, saving every response as a fixture to replay in tests",
+	Action: LaunchRecord,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:        "bn-endpoint",
+			Usage:       "Beacon node endpoint to record",
+			EnvVars:     []string{"ANALYZER_BN_ENDPOINT"},
+			DefaultText: "http://localhost:5052",
+		},
+		&cli.StringFlag{
+			Name:        "el-endpoint",
+			Usage:       "Execution node endpoint to record, not recorded if empty",
+			EnvVars:     []string{"ANALYZER_EL_ENDPOINT"},
+			DefaultText: "http://localhost:8545",
+		},
+		&cli.StringFlag{
+			Name:     "fixtures-dir",
+			Usage:    "Directory where to save the fixtures, in a beacon and an execution subdirectory",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "bn-listen",
+			Usage: "Address where the beacon node proxy listens",
+			Value: "localhost:15052",
+		},
+		&cli.StringFlag{
+			Name:  "el-listen",
+			Usage: "Address where the execution node proxy listens",
+			Value: "localhost:18545",
+		},
+		&cli.StringFlag{
+			Name:        "log-level",
+			Usage:       "Log level: debug, warn, info, error",
+			EnvVars:     []string{"ANALYZER_LOG_LEVEL"},
+			DefaultText: "info",
+		}},
+}
+
+func LaunchRecord(c *cli.Context) error {
+	if c.IsSet("log-level") {
+		logrus.SetLevel(utils.ParseLogLevel(c.String("log-level")))
+	}
+	bnEndpoint := c.String("bn-endpoint")
+	if bnEndpoint == "" {
+		return fmt.Errorf("bn-endpoint is needed")
+	}
+	dir := c.String("fixtures-dir")
+
+	servers := make([]*http.Server, 0)
+	errC := make(chan error, 2)
+	serve := func(upstream string, listen string, subdir string) error {
+		recorder, err := fakenode.NewRecorder(upstream, filepath.Join(dir, subdir))
+		if err != nil {
+			return err
+		}
+		server := &http.Server{Addr: listen, Handler: recorder}
+		servers = append(servers, server)
+		go func() {
+			errC <- server.ListenAndServe()
+		}()
+		logCmdChain.Infof("recording %s at %s into %s", upstream, listen, filepath.Join(dir, subdir))
+		return nil
+	}
+
+	err := serve(bnEndpoint, c.String("bn-listen"), fakenode.BeaconFixtures)
+	if err != nil {
+		return err
+	}
+	if elEndpoint := c.String("el-endpoint"); elEndpoint != "" {
+		err = serve(elEndpoint, c.String("el-listen"), fakenode.ExecutionFixtures)
+		if err != nil {
+			return err
+		}
+	}
+
+	sigtermC := make(chan os.Signal, 1)
+	signal.Notify(sigtermC, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigtermC:
+		logCmdChain.Info("Sudden shutdown detected, stopping the recording")
+	case err = <-errC:
+	}
+	for _, server := range servers {
+		server.Shutdown(context.Background())
+	}
+	close(sigtermC)
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -47,6 +47,9 @@ func main() {
 		Commands: []*cli.Command{
 			cmd.BlocksCommand,
 			cmd.ValidatorWindowCommand,
+			cmd.GapsCommand,
+			cmd.VerifyCommand,
+			cmd.RecordCommand,
 		},
 	}
 
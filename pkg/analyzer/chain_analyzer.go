@@ -2,7 +2,9 @@ package analyzer
 
 import (
 	"context"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/migalabs/goteth/pkg/events"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 )
 
 type ChainAnalyzer struct {
@@ -26,6 +29,8 @@ type ChainAnalyzer struct {
 	initSlot  phase0.Slot
 	finalSlot phase0.Slot
 
+	headStartSlot phase0.Slot // head modes: slot the fill to head starts from, fixed before any routine writes
+
 	// Channels
 	downloadTaskChan chan phase0.Slot // channel to send download tasks
 
@@ -34,17 +39,27 @@ type ChainAnalyzer struct {
 	relayCli  *relay.RelaysMonitor // client to monitor all relays in list
 	eventsObj events.Events        // object to receive signals from beacon node
 	dbClient  *db.DBService        // client to communicate with clickhouse
+	chainSpec *spec.ChainSpec      // parameters of the chain, read from the beacon node
 
 	// Control Variables
 	wgMainRoutine *sync.WaitGroup    // wait group for main routine (either historical or head)
 	wgDownload    *sync.WaitGroup    // wait group for download routine
-	stop          bool               // flag to notify all routine to finish
+	eg            *errgroup.Group    // every routine reports its error here, the first one cancels ctx
+	stop          atomic.Bool        // flag to notify all routines to finish gracefully
 	routineClosed chan struct{}      // signal that everything was closed succesfully
 	downloadMode  string             // whether to download historical blocks (defined by user) or follow chain head
 	metrics       db.DBMetrics       // waht metrics to be downloaded / processed
+	processors    []Processor        // derive metrics from every block and epoch, in order
 	processerBook *utils.RoutineBook // defines slot to process new metrics into the database, good for monitoring
+	activeReqs    atomic.Int64       // blocks and states being downloaded by this analyzer, the API client is shared in hybrid mode
 
 	downloadCache ChainCache // store the blocks and states downloaded
+	forkTree      *forkTree  // head mode: every block seen through the events, until finality
+	forkMu        sync.Mutex // blocks are added to the fork tree one at a time, and not during finalization
+
+	worker       workerConfig   // distributed mode: worker identity and lease parameters
+	backfill     *ChainAnalyzer // hybrid mode: analyzer that fills the historical range in the background
+	headAnalyzer *ChainAnalyzer // hybrid mode: head analyzer the backfill has to give priority to
 
 	initTime    time.Time
 	PromMetrics *prom_metrics.PrometheusMetrics // metrics to be stored to prometheus
@@ -54,15 +69,17 @@ func NewChainAnalyzer(
 	pCtx context.Context,
 	iConfig config.AnalyzerConfig) (*ChainAnalyzer, error) {
 
-	// gen new ctx from parent
+	// gen new ctx from parent, cancelled as soon as any routine fails
 	ctx, cancel := context.WithCancel(pCtx)
+	eg, ctx := errgroup.WithContext(ctx)
 
 	// generate the central exporting service
 	promethMetrics := prom_metrics.NewPrometheusMetrics(ctx, "0.0.0.0", iConfig.PrometheusPort)
 
 	// calculate the list of slots that we will analyze
 
-	if iConfig.DownloadMode == "historical" {
+	if iConfig.DownloadMode == "historical" || iConfig.DownloadMode == "hybrid" ||
+		iConfig.DownloadMode == "gaps" || iConfig.DownloadMode == "distributed" {
 
 		if iConfig.FinalSlot <= iConfig.InitSlot {
 			return &ChainAnalyzer{
@@ -70,9 +87,20 @@ func NewChainAnalyzer(
 				cancel: cancel,
 			}, errors.Errorf("Final Slot cannot be greater than Init Slot")
 		}
-		iConfig.InitSlot = iConfig.InitSlot / spec.SlotsPerEpoch * spec.SlotsPerEpoch
-		iConfig.FinalSlot = iConfig.FinalSlot / spec.SlotsPerEpoch * spec.SlotsPerEpoch
-		log.Infof("generating new Block Analyzer from slots %d:%d", iConfig.InitSlot, iConfig.FinalSlot)
+	}
+
+	if iConfig.DownloadMode == "distributed" && (iConfig.ChunkEpochs <= 0 || iConfig.LeaseTimeout <= 0) {
+		return &ChainAnalyzer{
+			ctx:    ctx,
+			cancel: cancel,
+		}, errors.Errorf("Chunk epochs and lease timeout must be greater than 0")
+	}
+
+	if (iConfig.DownloadMode == "gaps" || iConfig.DownloadMode == "distributed") && db.IsFileUrl(iConfig.DBUrl) {
+		return &ChainAnalyzer{
+			ctx:    ctx,
+			cancel: cancel,
+		}, errors.Errorf("%s mode needs to read from a database server", iConfig.DownloadMode)
 	}
 
 	metricsObj, err := db.NewMetrics(iConfig.Metrics)
@@ -83,7 +111,8 @@ func NewChainAnalyzer(
 		}, errors.Wrap(err, "unable to read metric.")
 	}
 
-	idbClient, err := db.New(ctx, iConfig.DBUrl)
+	// the db client keeps the parent ctx, so in-flight persists are flushed even if a routine fails
+	idbClient, err := db.New(pCtx, iConfig.DBUrl)
 	if err != nil {
 		return &ChainAnalyzer{
 			ctx:    ctx,
@@ -104,6 +133,12 @@ func NewChainAnalyzer(
 		iConfig.BnEndpoint,
 		clientapi.WithELEndpoint(iConfig.ElEndpoint),
 		clientapi.WithDBMetrics(metricsObj),
+		clientapi.WithEraDir(iConfig.EraDir),
+		clientapi.WithRateLimits(
+			clientapi.RateLimit{RPS: iConfig.StatesRPS, Concurrency: iConfig.StatesConcurrency},
+			clientapi.RateLimit{RPS: iConfig.BlocksRPS, Concurrency: iConfig.BlocksConcurrency},
+			clientapi.RateLimit{RPS: iConfig.ElRPS, Concurrency: iConfig.ElConcurrency},
+			iConfig.AdaptiveRateLimit),
 		clientapi.WithPromMetrics(promethMetrics))
 	if err != nil {
 		return &ChainAnalyzer{
@@ -112,6 +147,29 @@ func NewChainAnalyzer(
 		}, errors.Wrap(err, "unable to generate API Client.")
 	}
 
+	// the range is rounded to whole epochs, whose length depends on the chain
+	chainSpec := cli.ChainSpec
+	if iConfig.DownloadMode == "historical" || iConfig.DownloadMode == "hybrid" ||
+		iConfig.DownloadMode == "gaps" || iConfig.DownloadMode == "distributed" {
+		iConfig.InitSlot = chainSpec.FirstSlotInEpoch(iConfig.InitSlot)
+		iConfig.FinalSlot = chainSpec.FirstSlotInEpoch(iConfig.FinalSlot)
+		log.Infof("generating new Block Analyzer from slots %d:%d", iConfig.InitSlot, iConfig.FinalSlot)
+	}
+	if iConfig.DownloadMode == "hybrid" {
+		// the backfill cannot go past the chain, the head routine covers the rest
+		headSlot, err := cli.RequestCurrentHead()
+		if err != nil {
+			return &ChainAnalyzer{
+				ctx:    ctx,
+				cancel: cancel,
+			}, errors.Wrap(err, "unable to request the current head.")
+		}
+		if iConfig.FinalSlot > headSlot {
+			iConfig.FinalSlot = chainSpec.FirstSlotInEpoch(headSlot)
+			log.Infof("final slot is ahead of the chain, the backfill stops at slot %d", iConfig.FinalSlot)
+		}
+	}
+
 	genesisTime := cli.RequestGenesis()
 
 	// generate the relays client
@@ -124,25 +182,63 @@ func NewChainAnalyzer(
 	}
 
 	idbClient.InitGenesis(genesisTime)
+	idbClient.InitChainSpec(chainSpec)
 
 	analyzer := &ChainAnalyzer{
 		ctx:              ctx,
 		cancel:           cancel,
 		initSlot:         phase0.Slot(iConfig.InitSlot),
 		finalSlot:        phase0.Slot(iConfig.FinalSlot),
-		downloadTaskChan: make(chan phase0.Slot, rateLimit), // TODO: define size of buffer depending on performance
+		downloadTaskChan: make(chan phase0.Slot, downloadTaskBuffer), // TODO: define size of buffer depending on performance
 		cli:              cli,
 		relayCli:         relayCli,
 		dbClient:         idbClient,
+		chainSpec:        chainSpec,
 		routineClosed:    make(chan struct{}, 1),
 		eventsObj:        events.NewEventsObj(ctx, cli),
 		downloadMode:     iConfig.DownloadMode,
 		metrics:          metricsObj,
 		PromMetrics:      promethMetrics,
-		downloadCache:    NewQueue(),
-		processerBook:    utils.NewRoutineBook(32, "processer"), // one whole epoch
+		processerBook:    utils.NewRoutineBook(int(chainSpec.SlotsPerEpoch), "processer"), // one whole epoch
 		wgMainRoutine:    &sync.WaitGroup{},
 		wgDownload:       &sync.WaitGroup{},
+		eg:               eg,
+		forkTree:         newForkTree(),
+		worker: workerConfig{
+			id:           iConfig.WorkerID,
+			chunkEpochs:  phase0.Epoch(iConfig.ChunkEpochs),
+			leaseTimeout: time.Duration(iConfig.LeaseTimeout) * time.Second,
+		},
+	}
+
+	analyzer.processors, err = newProcessors(pCtx, metricsObj, analyzer.downloadMode, idbClient)
+	if err != nil {
+		return analyzer, errors.Wrap(err, "unable to read metric.")
+	}
+
+	analyzer.downloadCache, err = newChainCache(iConfig, "head", chainSpec)
+	if err != nil {
+		return analyzer, errors.Wrap(err, "unable to generate the download cache.")
+	}
+
+	if iConfig.DownloadMode == "hybrid" {
+		analyzer.backfill = analyzer.newBackfillAnalyzer()
+		analyzer.backfill.processors, err = newProcessors(pCtx, metricsObj, analyzer.backfill.downloadMode, idbClient)
+		if err != nil {
+			return analyzer, errors.Wrap(err, "unable to read metric.")
+		}
+		analyzer.backfill.downloadCache, err = newChainCache(iConfig, "backfill", chainSpec)
+		if err != nil {
+			return analyzer, errors.Wrap(err, "unable to generate the backfill cache.")
+		}
+		promethMetrics.AddMeticsModule(analyzer.backfill.processerBook.GetPrometheusMetrics())
+	}
+
+	if iConfig.DownloadMode == "finalized" || iConfig.DownloadMode == "hybrid" {
+		analyzer.headStartSlot, err = analyzer.headStart()
+		if err != nil {
+			return analyzer, err
+		}
 	}
 
 	analyzerMet := analyzer.GetPrometheusMetrics()
@@ -153,51 +249,149 @@ func NewChainAnalyzer(
 	return analyzer, nil
 }
 
-func (s *ChainAnalyzer) Run() {
+// newBackfillAnalyzer returns an analyzer that shares the connections of s
+// but keeps its own task channel and processer book, so the historical
+// range can be processed without interfering with the head routine.
+// The caller has to provide its own download cache and processors
+func (s *ChainAnalyzer) newBackfillAnalyzer() *ChainAnalyzer {
+	return &ChainAnalyzer{
+		ctx:              s.ctx,
+		cancel:           s.cancel,
+		initSlot:         s.initSlot,
+		finalSlot:        s.finalSlot,
+		downloadTaskChan: make(chan phase0.Slot, downloadTaskBuffer),
+		cli:              s.cli,
+		relayCli:         s.relayCli,
+		dbClient:         s.dbClient,
+		chainSpec:        s.chainSpec,
+		routineClosed:    make(chan struct{}, 1),
+		downloadMode:     "historical",
+		metrics:          s.metrics,
+		PromMetrics:      s.PromMetrics,
+		processerBook:    utils.NewRoutineBook(int(s.chainSpec.SlotsPerEpoch), "backfill-processer"),
+		wgMainRoutine:    &sync.WaitGroup{},
+		wgDownload:       &sync.WaitGroup{},
+		eg:               s.eg,
+		headAnalyzer:     s,
+	}
+}
+
+// headStart returns the slot the head routine fills from, 0 to start from the current finalized.
+// In hybrid mode the backfill covers the configured range, so the head continues some epochs
+// before it ends: the epoch metrics at the seam need the states of the previous epochs.
+// Otherwise it continues some epochs before the last slot in the database
+func (s *ChainAnalyzer) headStart() (phase0.Slot, error) {
+	tentative := s.chainSpec.Slots(epochsToFinalizedTentative)
+	if s.backfill != nil {
+		finalSlot := s.chainSpec.FirstSlotInEpoch(s.finalSlot)
+		if finalSlot < s.initSlot+tentative {
+			return s.initSlot, nil
+		}
+		return finalSlot - tentative, nil
+	}
+
+	dbHead, err := s.dbClient.RetrieveLastSlot()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not get head block from database")
+	}
+	if dbHead <= tentative {
+		return 0, nil
+	}
+	return s.chainSpec.FirstSlotInEpoch(dbHead - tentative), nil
+}
+
+// newChainCache returns an in-memory cache, or a disk backed one if a cache dir was configured
+func newChainCache(iConfig config.AnalyzerConfig, name string, chainSpec *spec.ChainSpec) (ChainCache, error) {
+	if iConfig.CacheDir == "" {
+		return NewQueue(chainSpec), nil
+	}
+	return NewDiskQueue(filepath.Join(iConfig.CacheDir, name), iConfig.CacheMemStates, iConfig.CacheMemBlocks, chainSpec)
+}
+
+// Run launches the routines of the configured download mode and blocks until they finish.
+// It returns the first error raised by any routine, as an *AnalyzerError when it
+// refers to a slot or epoch
+func (s *ChainAnalyzer) Run() error {
 	defer s.cancel()
 	// Get init time
 	s.initTime = time.Now()
 
 	log.Info("Blocks Analyzer initialized at ", s.initTime)
 
-	totalTime := int64(0)
-	start := time.Now()
-
 	s.wgDownload.Add(1)
-	go s.runDownloadBlocks()
-	if s.downloadMode == "historical" {
-		// Block requester + Task generator
-		s.wgMainRoutine.Add(1)
+	s.eg.Go(func() error {
+		defer s.wgDownload.Done()
+		return s.runDownloadBlocks()
+	})
 
-		go s.runHistorical(s.initSlot, s.finalSlot)
-	}
+	switch s.downloadMode {
+	case "historical":
+		// Block requester + Task generator
+		s.goMainRoutine(func() error {
+			return s.runHistorical(s.initSlot, s.finalSlot)
+		})
 
-	if s.downloadMode == "finalized" {
+	case "finalized":
 		// Block requester in finalized slots, not used for now
-		s.wgMainRoutine.Add(1)
-		go s.runHead()
+		s.goMainRoutine(s.runHead)
+
+	case "hybrid":
+		// follow the chain head and fill the historical range with the spare capacity
+		s.goMainRoutine(s.runHead)
+		s.goMainRoutine(s.runBackfill)
+
+	case "distributed":
+		// claim chunks of the historical range shared with other workers
+		s.goMainRoutine(s.runDistributed)
+
+	case "gaps":
+		// fill the ranges missing in the progress ledger
+		s.goMainRoutine(s.runGaps)
 	}
 
 	s.PromMetrics.Start()
 
 	s.wgMainRoutine.Wait()
-	s.stop = true
+	s.stop.Store(true)
 	log.Infof("main routine finished, waiting for downloader...")
 
 	s.wgDownload.Wait()
 
-	log.Infof("downloader finished, waiting for db client...")
+	log.Infof("downloader finished, waiting for processers...")
+	err := s.eg.Wait()
+	if err != nil {
+		log.Errorf("analyzer stopped: %s", err)
+	}
 
+	log.Infof("processers finished, waiting for db client...")
 	s.dbClient.Finish()
 
-	totalTime += int64(time.Since(start).Seconds())
 	analysisDuration := time.Since(s.initTime).Seconds()
 	log.Info("Blocks Analyzer finished in ", analysisDuration)
 	s.routineClosed <- struct{}{}
+	return err
+}
+
+// goMainRoutine launches one of the main routines (historical, head...),
+// its error is reported to the central errgroup
+func (s *ChainAnalyzer) goMainRoutine(routine func() error) {
+	s.wgMainRoutine.Add(1)
+	s.eg.Go(func() error {
+		defer s.wgMainRoutine.Done()
+		return routine()
+	})
+}
+
+// stopped returns whether the routines have to finish, either gracefully or because of an error
+func (s *ChainAnalyzer) stopped() bool {
+	return s.stop.Load() || s.ctx.Err() != nil
 }
 
 func (s *ChainAnalyzer) Close() {
 	log.Info("Sudden closed detected, closing StateAnalyzer")
-	s.stop = true
+	s.stop.Store(true)
+	if s.backfill != nil {
+		s.backfill.stop.Store(true)
+	}
 	<-s.routineClosed // Wait for services to stop before returning
 }
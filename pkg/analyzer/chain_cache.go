@@ -1,6 +1,9 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"sync"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -14,44 +17,106 @@ type ChainCache struct {
 	sync.Mutex
 	HeadBlock       *spec.AgnosticBlock
 	LatestFinalized *spec.AgnosticBlock
+
+	chainSpec *spec.ChainSpec // defines which blocks belong to each state
 }
 
-func NewQueue() ChainCache {
+func NewQueue(chainSpec *spec.ChainSpec) ChainCache {
 	return ChainCache{
 		StateHistory: NewAgnosticMap[spec.AgnosticState](),
 		BlockHistory: NewAgnosticMap[spec.AgnosticBlock](),
+		chainSpec:    chainSpec,
+	}
+}
+
+// NewDiskQueue returns a cache that keeps at most memStates states and memBlocks blocks
+// in memory, spilling the least recently used ones to files under dir.
+// The blocks of a spilled state are not written with it, they are taken again from the block history
+func NewDiskQueue(dir string, memStates int, memBlocks int, chainSpec *spec.ChainSpec) (ChainCache, error) {
+	blockStore, err := newDiskStore[spec.AgnosticBlock](filepath.Join(dir, "blocks"), memBlocks)
+	if err != nil {
+		return ChainCache{}, err
+	}
+	blockHistory := NewAgnosticMap(WithDiskStore(blockStore))
+
+	stateStore, err := newDiskStore[spec.AgnosticState](filepath.Join(dir, "states"), memStates,
+		withSpillF(func(state *spec.AgnosticState) *spec.AgnosticState {
+			withoutBlocks := *state
+			withoutBlocks.Blocks = nil
+			return &withoutBlocks
+		}),
+		withLoadF(func(state *spec.AgnosticState) error {
+			return relinkBlocks(state, blockHistory, chainSpec)
+		}))
+	if err != nil {
+		return ChainCache{}, err
+	}
+	return ChainCache{
+		StateHistory: NewAgnosticMap(WithDiskStore(stateStore)),
+		BlockHistory: blockHistory,
+		chainSpec:    chainSpec,
+	}, nil
+}
+
+// relinkBlocks points the state to the blocks of its epoch in the block history,
+// the same ones it was built with in AddNewState, and to the chain spec of the cache
+func relinkBlocks(state *spec.AgnosticState, blockHistory *AgnosticMap[spec.AgnosticBlock], chainSpec *spec.ChainSpec) error {
+	blockList := make([]*spec.AgnosticBlock, 0, chainSpec.SlotsPerEpoch)
+	for i := chainSpec.EpochStartSlot(state.Epoch); i <= chainSpec.EpochEndSlot(state.Epoch); i++ {
+		block, ok, err := blockHistory.Get(SlotTo[uint64](i))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("block %d of epoch %d is not in the cache", i, state.Epoch)
+		}
+		blockList = append(blockList, block)
 	}
+	// withdrawals and deposits were written with the state
+	state.Blocks = blockList
+	state.Spec = chainSpec
+	return nil
 }
 
-func (s *ChainCache) AddNewState(newState *spec.AgnosticState) {
+func (s *ChainCache) AddNewState(ctx context.Context, newState *spec.AgnosticState) error {
 
 	blockList := make([]*spec.AgnosticBlock, 0)
-	epochStartSlot := phase0.Slot(newState.Epoch * spec.SlotsPerEpoch)
-	epochEndSlot := phase0.Slot((newState.Epoch+1)*spec.SlotsPerEpoch - 1)
+	epochStartSlot := s.chainSpec.EpochStartSlot(newState.Epoch)
+	epochEndSlot := s.chainSpec.EpochEndSlot(newState.Epoch)
 
 	for i := epochStartSlot; i <= epochEndSlot; i++ {
-		block := s.BlockHistory.Wait(SlotTo[uint64](i))
+		block, err := s.BlockHistory.Wait(ctx, SlotTo[uint64](i))
+		if err != nil {
+			return err
+		}
 
 		blockList = append(blockList, block)
 	}
 
-	// the 32 blocks were retrieved
+	// the blocks of the whole epoch were retrieved
 	newState.AddBlocks(blockList)
 
-	s.StateHistory.Set(EpochTo[uint64](newState.Epoch), newState)
+	err := s.StateHistory.Set(EpochTo[uint64](newState.Epoch), newState)
+	if err != nil {
+		return err
+	}
 	log.Debugf("state at slot %d successfully added to the queue", newState.Slot)
+	return nil
 }
 
-func (s *ChainCache) AddNewBlock(block *spec.AgnosticBlock) {
+func (s *ChainCache) AddNewBlock(block *spec.AgnosticBlock) error {
 
 	keys := s.BlockHistory.GetKeyList()
 
-	s.BlockHistory.Set(SlotTo[uint64](block.Slot), block)
+	err := s.BlockHistory.Set(SlotTo[uint64](block.Slot), block)
+	if err != nil {
+		return err
+	}
 	log.Tracef("block at slot %d successfully added to the queue", block.Slot)
 
 	for _, key := range keys {
 		if key >= uint64(block.Slot) { // if there is any key greater than the current evaluated block
-			return // no more tasks
+			return nil // no more tasks
 		}
 	}
 
@@ -59,7 +124,7 @@ func (s *ChainCache) AddNewBlock(block *spec.AgnosticBlock) {
 	s.Lock()
 	s.HeadBlock = block
 	s.Unlock()
-
+	return nil
 }
 
 func (s *ChainCache) GetHeadBlock() spec.AgnosticBlock {
@@ -75,14 +140,15 @@ func (s *ChainCache) CleanUpTo(maxSlot phase0.Slot) {
 	// Delete from History
 
 	for _, epoch := range stateKeys {
-		if (epoch * spec.SlotsPerEpoch) >= uint64(maxSlot) {
+		epochStartSlot := s.chainSpec.EpochStartSlot(phase0.Epoch(epoch))
+		if epochStartSlot >= maxSlot {
 			continue // only process epochs that are before the maxSlot
 		}
 
 		s.StateHistory.Delete(epoch)
 		// loop over slots in the epoch
-		for slot := (epoch * spec.SlotsPerEpoch); slot < ((epoch + 1) * spec.SlotsPerEpoch); slot++ {
-			s.BlockHistory.Delete(slot)
+		for slot := epochStartSlot; slot <= s.chainSpec.EpochEndSlot(phase0.Epoch(epoch)); slot++ {
+			s.BlockHistory.Delete(uint64(slot))
 		}
 	}
 
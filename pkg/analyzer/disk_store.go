@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/snappy"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// diskStore spills the items of an AgnosticMap to snappy compressed files in dir.
+// The wrappers are not SSZ containers, so items are gob encoded
+type diskStore[T spec.AgnosticBlock |
+	spec.AgnosticState] struct {
+	dir      string
+	memItems int                      // max number of items kept in memory
+	lru      *list.List               // keys in memory, most recently used at the front
+	lruKeys  map[uint64]*list.Element // position of each key in the lru
+	onDisk   map[uint64]struct{}      // keys that were spilled to disk
+
+	spillF func(*T) *T    // returns what is written for an item, by default the item itself
+	loadF  func(*T) error // restores what spillF left out of a loaded item
+}
+
+type diskStoreOption[T spec.AgnosticBlock |
+	spec.AgnosticState] func(*diskStore[T])
+
+// withSpillF and withLoadF keep the pointers that are shared with other items out of the files,
+// gob would write a copy of the pointed values
+func withSpillF[T spec.AgnosticBlock |
+	spec.AgnosticState](f func(*T) *T) diskStoreOption[T] {
+	return func(d *diskStore[T]) {
+		d.spillF = f
+	}
+}
+
+func withLoadF[T spec.AgnosticBlock |
+	spec.AgnosticState](f func(*T) error) diskStoreOption[T] {
+	return func(d *diskStore[T]) {
+		d.loadF = f
+	}
+}
+
+func newDiskStore[T spec.AgnosticBlock |
+	spec.AgnosticState](dir string, memItems int, opts ...diskStoreOption[T]) (*diskStore[T], error) {
+
+	if memItems < 1 {
+		return nil, fmt.Errorf("at least one item has to be kept in memory")
+	}
+	// files from previous runs are not valid anymore
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &diskStore[T]{
+		dir:      dir,
+		memItems: memItems,
+		lru:      list.New(),
+		lruKeys:  make(map[uint64]*list.Element),
+		onDisk:   make(map[uint64]struct{}),
+		spillF:   func(item *T) *T { return item },
+		loadF:    func(_ *T) error { return nil },
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store, nil
+}
+
+func (d *diskStore[T]) path(key uint64) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%d.gob.snappy", key))
+}
+
+// touch marks the key as the most recently used in memory
+func (d *diskStore[T]) touch(key uint64) {
+	if elem, ok := d.lruKeys[key]; ok {
+		d.lru.MoveToFront(elem)
+		return
+	}
+	d.lruKeys[key] = d.lru.PushFront(key)
+}
+
+// forget removes the key from both tiers
+func (d *diskStore[T]) forget(key uint64) {
+	if elem, ok := d.lruKeys[key]; ok {
+		d.lru.Remove(elem)
+		delete(d.lruKeys, key)
+	}
+	if _, ok := d.onDisk[key]; ok {
+		delete(d.onDisk, key)
+		if err := os.Remove(d.path(key)); err != nil {
+			log.Warnf("could not remove cache file %s: %s", d.path(key), err)
+		}
+	}
+}
+
+// evictKeys returns the least recently used keys that have to leave memory
+func (d *diskStore[T]) evictKeys() []uint64 {
+	keys := make([]uint64, 0)
+	for elem := d.lru.Back(); elem != nil && d.lru.Len()-len(keys) > d.memItems; elem = elem.Prev() {
+		keys = append(keys, elem.Value.(uint64))
+	}
+	return keys
+}
+
+// spill writes the item to disk and removes the key from the memory tier
+func (d *diskStore[T]) spill(key uint64, item *T) error {
+	file, err := os.Create(d.path(key))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := snappy.NewBufferedWriter(file)
+	err = gob.NewEncoder(writer).Encode(d.spillF(item))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	if elem, ok := d.lruKeys[key]; ok {
+		d.lru.Remove(elem)
+		delete(d.lruKeys, key)
+	}
+	d.onDisk[key] = struct{}{}
+	return nil
+}
+
+// load reads the item from disk, the key stays on disk until it is promoted
+func (d *diskStore[T]) load(key uint64) (*T, error) {
+	file, err := os.Open(d.path(key))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	item := new(T)
+	err = gob.NewDecoder(bufio.NewReader(snappy.NewReader(file))).Decode(item)
+	if err != nil {
+		return nil, err
+	}
+	err = d.loadF(item)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// promote moves a loaded key back to the memory tier
+func (d *diskStore[T]) promote(key uint64) {
+	if _, ok := d.onDisk[key]; ok {
+		delete(d.onDisk, key)
+		if err := os.Remove(d.path(key)); err != nil {
+			log.Warnf("could not remove cache file %s: %s", d.path(key), err)
+		}
+	}
+	d.touch(key)
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskBackedAgnosticMap(t *testing.T) {
+
+	dir := t.TempDir()
+	store, err := newDiskStore[spec.AgnosticBlock](dir, 2)
+	assert.Nil(t, err)
+	blocks := NewAgnosticMap(WithDiskStore(store))
+
+	for slot := uint64(0); slot < 5; slot++ {
+		blocks.Set(slot, &spec.AgnosticBlock{
+			Slot:          phase0.Slot(slot),
+			ProposerIndex: phase0.ValidatorIndex(slot * 10),
+			Attestations:  []*spec.AgnosticAttestation{},
+		})
+	}
+
+	// only two blocks are kept in memory, the rest were spilled
+	assert.Equal(t, 2, len(blocks.m))
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, 3, len(files))
+	assert.Equal(t, 5, len(blocks.GetKeyList()))
+
+	for slot := uint64(0); slot < 5; slot++ {
+		assert.True(t, blocks.Available(slot))
+		block, err := blocks.Wait(context.Background(), slot)
+		assert.Nil(t, err)
+		assert.Equal(t, phase0.Slot(slot), block.Slot)
+		assert.Equal(t, phase0.ValidatorIndex(slot*10), block.ProposerIndex)
+	}
+
+	// deleting removes the key from both tiers
+	for slot := uint64(0); slot < 5; slot++ {
+		blocks.Delete(slot)
+		assert.False(t, blocks.Available(slot))
+	}
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, 0, len(files))
+	assert.Equal(t, 0, len(blocks.GetKeyList()))
+
+	_, err = os.Stat(dir)
+	assert.Nil(t, err)
+
+	// spill errors are returned, the items stay in memory
+	assert.Nil(t, os.RemoveAll(dir))
+	for slot := uint64(0); slot < 3; slot++ {
+		err = blocks.Set(slot, &spec.AgnosticBlock{Slot: phase0.Slot(slot)})
+		assert.Equal(t, slot == 2, err != nil)
+	}
+	assert.Equal(t, 3, len(blocks.m))
+}
+
+func TestDiskQueueStateRoundTrip(t *testing.T) {
+
+	chainSpec := &spec.ChainSpec{SlotsPerEpoch: 4}
+	cache, err := NewDiskQueue(t.TempDir(), 1, 16, chainSpec)
+	assert.Nil(t, err)
+
+	for slot := phase0.Slot(0); slot < 8; slot++ {
+		err = cache.AddNewBlock(&spec.AgnosticBlock{
+			Slot:          slot,
+			ProposerIndex: phase0.ValidatorIndex(slot),
+			Proposed:      slot != 5,
+			Attestations:  []*spec.AgnosticAttestation{},
+		})
+		assert.Nil(t, err)
+	}
+	for epoch := phase0.Epoch(0); epoch < 2; epoch++ {
+		err = cache.AddNewState(context.Background(), &spec.AgnosticState{
+			Epoch:    epoch,
+			Slot:     chainSpec.EpochEndSlot(epoch),
+			Balances: []phase0.Gwei{32, 31},
+			Validators: []*phase0.Validator{
+				{PublicKey: phase0.BLSPubKey{0x01}, EffectiveBalance: 32},
+				{PublicKey: phase0.BLSPubKey{0x02}, EffectiveBalance: 31, Slashed: true},
+			},
+			MissedBlocks: []phase0.Slot{5},
+			Spec:         chainSpec,
+		})
+		assert.Nil(t, err)
+	}
+
+	// the state of epoch 0 was spilled without its blocks
+	assert.Equal(t, 1, len(cache.StateHistory.m))
+	state, err := cache.StateHistory.Wait(context.Background(), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(3), state.Slot)
+	assert.Equal(t, []phase0.Gwei{32, 31}, state.Balances)
+	assert.True(t, state.Validators[1].Slashed)
+	assert.Equal(t, []phase0.Gwei{0, 0}, state.Withdrawals)
+	assert.Same(t, chainSpec, state.Spec)
+
+	// loading it links the blocks of the block history again, so updates are shared
+	assert.Equal(t, 4, len(state.Blocks))
+	for i, block := range state.Blocks {
+		cacheBlock, err := cache.BlockHistory.Wait(context.Background(), uint64(i))
+		assert.Nil(t, err)
+		assert.Same(t, cacheBlock, block)
+	}
+
+	// the state of epoch 1 was spilled in turn
+	state, err = cache.StateHistory.Wait(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(4), state.Blocks[0].Slot)
+	assert.False(t, state.Blocks[1].Proposed)
+}
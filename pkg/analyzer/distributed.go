@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+)
+
+var (
+	warmUpEpochs       = phase0.Epoch(2) // states needed before the chunk to compute rewards
+	leaseSettleTime    = 2 * time.Second // time to let competing claims land before checking ownership
+	leasePollFrequency = 30 * time.Second
+)
+
+// workerConfig holds the parameters of the distributed mode
+type workerConfig struct {
+	id           string
+	chunkEpochs  phase0.Epoch
+	leaseTimeout time.Duration
+}
+
+// splitChunks divides the epoch range [initEpoch, finalEpoch] in chunks of chunkEpochs
+func splitChunks(initEpoch phase0.Epoch, finalEpoch phase0.Epoch, chunkEpochs phase0.Epoch) []db.WorkLease {
+	chunks := make([]db.WorkLease, 0)
+	if chunkEpochs == 0 {
+		return chunks
+	}
+	for start := initEpoch; start <= finalEpoch; start += chunkEpochs {
+		end := start + chunkEpochs - 1
+		if end > finalEpoch {
+			end = finalEpoch
+		}
+		chunks = append(chunks, db.WorkLease{InitEpoch: start, FinalEpoch: end})
+	}
+	return chunks
+}
+
+// runDistributed claims chunks of the historical range through the lease table
+// and processes them until every chunk is done
+func (s *ChainAnalyzer) runDistributed() error {
+
+	initEpoch := s.chainSpec.EpochAtSlot(s.initSlot)
+	finalEpoch := s.chainSpec.EpochAtSlot(s.finalSlot)
+	chunks := splitChunks(initEpoch, finalEpoch, s.worker.chunkEpochs)
+
+	log.Infof("distributed mode: worker %s, %d chunks of %d epochs", s.worker.id, len(chunks), s.worker.chunkEpochs)
+
+	for {
+		if s.stopped() {
+			log.Info("sudden shutdown detected, distributed routine")
+			return nil
+		}
+
+		chunk, pending, err := s.claimNextChunk(chunks, initEpoch, finalEpoch)
+		if err != nil {
+			log.Errorf("could not claim chunk: %s", err)
+			s.sleep(leasePollFrequency)
+			continue
+		}
+		if chunk == nil {
+			if pending == 0 {
+				log.Infof("distributed mode: all chunks done")
+				return nil
+			}
+			// other workers hold the remaining chunks, wait in case they expire
+			log.Debugf("distributed mode: %d chunks claimed by other workers, waiting...", pending)
+			s.sleep(leasePollFrequency)
+			continue
+		}
+
+		lease, err := s.processChunk(*chunk)
+		if err != nil {
+			return err
+		}
+		if s.stopped() {
+			continue // do not mark as done, the chunk will expire and be claimed again
+		}
+
+		_, owned, err := s.dbClient.UpdateWorkLease(lease, db.LeaseDone)
+		if err != nil {
+			log.Errorf("could not mark chunk %d-%d as done: %s", chunk.InitEpoch, chunk.FinalEpoch, err)
+			continue
+		}
+		if !owned {
+			log.Warnf("chunk %d-%d was taken over by another worker, not marked as done", chunk.InitEpoch, chunk.FinalEpoch)
+			continue
+		}
+		log.Infof("chunk %d-%d done", chunk.InitEpoch, chunk.FinalEpoch)
+	}
+}
+
+// sleep waits for the given time, unless the analyzer is cancelled meanwhile
+func (s *ChainAnalyzer) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-s.ctx.Done():
+	}
+}
+
+// claimNextChunk returns the first chunk this worker could claim, if any,
+// and the number of chunks that are not done yet
+func (s *ChainAnalyzer) claimNextChunk(
+	chunks []db.WorkLease,
+	initEpoch phase0.Epoch,
+	finalEpoch phase0.Epoch) (*db.WorkLease, int, error) {
+
+	leases, err := s.dbClient.RetrieveWorkLeases(initEpoch, finalEpoch)
+	if err != nil {
+		return nil, 0, err
+	}
+	leaseByEpoch := make(map[phase0.Epoch]db.WorkLease)
+	for _, lease := range leases {
+		leaseByEpoch[lease.InitEpoch] = lease
+	}
+
+	pending := 0
+	for _, chunk := range chunks {
+		lease, found := leaseByEpoch[chunk.InitEpoch]
+		if found && lease.Status == db.LeaseDone {
+			continue
+		}
+		pending++
+		if found && lease.WorkerID != s.worker.id && !lease.Expired(s.worker.leaseTimeout) {
+			continue // owned by an active worker
+		}
+
+		claim := chunk
+		claim.WorkerID = s.worker.id
+		claim.Status = db.LeaseClaimed
+		claim.Heartbeat = time.Now()
+		err = s.dbClient.PersistWorkLeases([]db.WorkLease{claim})
+		if err != nil {
+			return nil, pending, err
+		}
+
+		// several workers might have claimed it at the same time: the last version wins
+		s.sleep(leaseSettleTime)
+		lease, owned, err := s.ownsLease(claim)
+		if err != nil {
+			return nil, pending, err
+		}
+		if !owned {
+			log.Debugf("chunk %d-%d was claimed by another worker", claim.InitEpoch, claim.FinalEpoch)
+			continue
+		}
+		log.Infof("worker %s claimed chunk %d-%d", s.worker.id, claim.InitEpoch, claim.FinalEpoch)
+		return &lease, pending, nil
+	}
+	return nil, pending, nil
+}
+
+// ownsLease returns the latest lease of the chunk, with the version later writes are conditioned on
+func (s *ChainAnalyzer) ownsLease(claim db.WorkLease) (db.WorkLease, bool, error) {
+	lease, found, err := s.dbClient.RetrieveWorkLease(claim.InitEpoch)
+	if err != nil || !found {
+		return lease, false, err
+	}
+	return lease, lease.WorkerID == s.worker.id && lease.Status == db.LeaseClaimed, nil
+}
+
+// processChunk runs the historical routine over the chunk plus its warm-up epochs,
+// sending heartbeats meanwhile. It returns the lease as last written by the heartbeat
+func (s *ChainAnalyzer) processChunk(chunk db.WorkLease) (db.WorkLease, error) {
+	warmUpEpoch := phase0.Epoch(0)
+	if chunk.InitEpoch > warmUpEpochs {
+		warmUpEpoch = chunk.InitEpoch - warmUpEpochs
+	}
+	init := s.chainSpec.EpochStartSlot(warmUpEpoch)
+	end := s.chainSpec.EpochEndSlot(chunk.FinalEpoch)
+
+	// the previous chunk has to be completely processed before moving initSlot
+	s.waitProcessingIdle()
+	s.initSlot = init
+
+	heartbeatDone := make(chan struct{})
+	heartbeatLease := make(chan db.WorkLease, 1)
+	s.eg.Go(func() error { return s.runLeaseHeartbeat(chunk, heartbeatDone, heartbeatLease) })
+
+	err := s.runHistorical(init, end)
+	if err == nil {
+		s.waitProcessingIdle()
+	}
+	close(heartbeatDone)
+	return <-heartbeatLease, err
+}
+
+// runLeaseHeartbeat refreshes the lease until done is closed, then sends its latest version to result.
+// Every refresh is conditioned on the previous version, so a lease taken over is never written back.
+// If another worker took the lease over, the returned error stops the analyzer
+func (s *ChainAnalyzer) runLeaseHeartbeat(chunk db.WorkLease, done chan struct{}, result chan<- db.WorkLease) error {
+	ticker := time.NewTicker(s.worker.leaseTimeout / 3)
+	defer ticker.Stop()
+	defer func() { result <- chunk }()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-s.ctx.Done():
+			return nil
+		case <-ticker.C:
+			lease, owned, err := s.dbClient.UpdateWorkLease(chunk, db.LeaseClaimed)
+			if err != nil {
+				log.Errorf("could not send heartbeat for chunk %d-%d: %s", chunk.InitEpoch, chunk.FinalEpoch, err)
+				// the heartbeat might have been written, the next one is conditioned on the latest version we own
+				if lease, owned, err := s.ownsLease(chunk); err == nil && owned {
+					chunk = lease
+				}
+				continue
+			}
+			if !owned {
+				// we cannot keep processing a chunk that belongs to another worker
+				return epochError(s.chainSpec, "lease heartbeat", chunk.InitEpoch, fmt.Errorf("lease taken over by another worker"))
+			}
+			chunk = lease
+		}
+	}
+}
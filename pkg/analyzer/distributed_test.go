@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name        string
+		initEpoch   phase0.Epoch
+		finalEpoch  phase0.Epoch
+		chunkEpochs phase0.Epoch
+		expected    []db.WorkLease
+	}{
+		{"even range", 10, 19, 5, []db.WorkLease{
+			{InitEpoch: 10, FinalEpoch: 14},
+			{InitEpoch: 15, FinalEpoch: 19},
+		}},
+		{"uneven range, the last chunk is shorter", 10, 21, 5, []db.WorkLease{
+			{InitEpoch: 10, FinalEpoch: 14},
+			{InitEpoch: 15, FinalEpoch: 19},
+			{InitEpoch: 20, FinalEpoch: 21},
+		}},
+		{"one epoch range", 7, 7, 5, []db.WorkLease{
+			{InitEpoch: 7, FinalEpoch: 7},
+		}},
+		{"one epoch chunks", 0, 2, 1, []db.WorkLease{
+			{InitEpoch: 0, FinalEpoch: 0},
+			{InitEpoch: 1, FinalEpoch: 1},
+			{InitEpoch: 2, FinalEpoch: 2},
+		}},
+		{"chunk larger than the range", 3, 5, 100, []db.WorkLease{
+			{InitEpoch: 3, FinalEpoch: 5},
+		}},
+		{"no chunk size", 0, 10, 0, []db.WorkLease{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, splitChunks(test.initEpoch, test.finalEpoch, test.chunkEpochs))
+		})
+	}
+}
@@ -5,53 +5,66 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
-	"github.com/migalabs/goteth/pkg/spec"
 )
 
-func (s *ChainAnalyzer) DownloadBlockCotrolled(slot phase0.Slot) {
-	s.WaitForPrevState(slot)
-	s.DownloadBlock(slot)
+func (s *ChainAnalyzer) DownloadBlockCotrolled(slot phase0.Slot) error {
+	err := s.WaitForPrevState(slot)
+	if err != nil {
+		return err
+	}
+	return s.DownloadBlock(slot)
 }
 
-func (s *ChainAnalyzer) DownloadBlock(slot phase0.Slot) {
+func (s *ChainAnalyzer) DownloadBlock(slot phase0.Slot) error {
 	if !s.metrics.Block {
 		log.Infof("skipping block download at slot %d: no metrics activated for block...", slot)
-		return
+		return nil
 	}
 
+	s.activeReqs.Add(1)
+	defer s.activeReqs.Add(-1)
+
 	newBlock, err := s.cli.RequestBeaconBlock(slot)
 	if err != nil {
-		log.Errorf("block error at slot %d: %s", slot, err)
-		s.stop = true
+		return slotError(s.chainSpec, "block download", slot, err)
+	}
+	err = s.downloadCache.AddNewBlock(newBlock)
+	if err != nil {
+		return slotError(s.chainSpec, "block download", slot, err)
 	}
-	s.downloadCache.AddNewBlock(newBlock)
 	// check if the min Request time has been completed (to avoid spaming the API)
+	return nil
 }
 
-func (s *ChainAnalyzer) DownloadState(slot phase0.Slot) {
+func (s *ChainAnalyzer) DownloadState(slot phase0.Slot) error {
 	if !s.metrics.Epoch {
 		log.Infof("skipping state download: no metrics activated for state...")
-		return
+		return nil
 	}
-	log := log.WithField("routine", "download")
+
+	s.activeReqs.Add(1)
+	defer s.activeReqs.Add(-1)
 
 	state, err := s.cli.RequestBeaconState(slot)
 	if err != nil {
-		// close the channel (to tell other routines to stop processing and end)
-		log.Errorf("unable to retrieve beacon state from the beacon node, closing requester routine. %s", err.Error())
-		s.stop = true
+		// the error cancels the rest of routines
+		return slotError(s.chainSpec, "state download", slot, err)
 	}
 
-	s.downloadCache.AddNewState(state)
+	err = s.downloadCache.AddNewState(s.ctx, state)
+	if err != nil {
+		return slotError(s.chainSpec, "state download", slot, err)
+	}
 	// check if the min Request time has been completed (to avoid spaming the API)
+	return nil
 }
 
-func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) {
+func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) error {
 	// check if state two epochs before is available
 	// the idea is that blocks are too fast to download, wait for states as well
 
-	prevStateEpoch := slot/spec.SlotsPerEpoch - 2              // epoch to check if state downloaded
-	prevStateSlot := (prevStateEpoch+1)*spec.SlotsPerEpoch - 1 // slot at which the check state was downloaded
+	prevStateEpoch := s.chainSpec.EpochAtSlot(slot) - 2       // epoch to check if state downloaded
+	prevStateSlot := s.chainSpec.EpochEndSlot(prevStateEpoch) // slot at which the check state was downloaded
 
 	prevStateAvailable := s.downloadCache.StateHistory.Available(uint64(prevStateEpoch))
 	prevStateProcessing := s.processerBook.CheckPageActive(fmt.Sprintf("%s%d", epochProcesserTag, prevStateEpoch))
@@ -60,9 +73,15 @@ func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) {
 	// also check that prevstate was supposed to be downloaded
 	if (!prevStateAvailable || prevStateProcessing) && prevStateSlot >= s.initSlot {
 		ticker := time.NewTicker(4 * time.Second) // average max time for a state to be downloaded
+		defer ticker.Stop()
 	stateWaitLoop:
-		for range ticker.C {
-			if slot%spec.SlotsPerEpoch == 0 { // only print for first slot of epoch
+		for {
+			select {
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			case <-ticker.C:
+			}
+			if uint64(slot)%s.chainSpec.SlotsPerEpoch == 0 { // only print for first slot of epoch
 				log.Debugf("slot %d waiting for state at slot %d (epoch %d) to be downloaded or processed...", slot, prevStateSlot, prevStateEpoch)
 			}
 
@@ -75,4 +94,5 @@ func (s *ChainAnalyzer) WaitForPrevState(slot phase0.Slot) {
 			}
 		}
 	}
+	return nil
 }
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// AnalyzerError is returned by ChainAnalyzer.Run when a routine could not go on.
+// It tells at which slot or epoch the pipeline failed and why
+type AnalyzerError struct {
+	Stage   string // part of the pipeline that failed, e.g. "block download"
+	Slot    phase0.Slot
+	Epoch   phase0.Epoch
+	IsEpoch bool // whether the failure refers to Epoch rather than Slot
+	Err     error
+}
+
+func (e *AnalyzerError) Error() string {
+	if e.IsEpoch {
+		return fmt.Sprintf("%s failed at epoch %d: %s", e.Stage, e.Epoch, e.Err)
+	}
+	return fmt.Sprintf("%s failed at slot %d: %s", e.Stage, e.Slot, e.Err)
+}
+
+func (e *AnalyzerError) Unwrap() error {
+	return e.Err
+}
+
+func slotError(chainSpec *spec.ChainSpec, stage string, slot phase0.Slot, err error) error {
+	return &AnalyzerError{
+		Stage: stage,
+		Slot:  slot,
+		Epoch: chainSpec.EpochAtSlot(slot),
+		Err:   err,
+	}
+}
+
+func epochError(chainSpec *spec.ChainSpec, stage string, epoch phase0.Epoch, err error) error {
+	return &AnalyzerError{
+		Stage:   stage,
+		Slot:    chainSpec.EpochStartSlot(epoch),
+		Epoch:   epoch,
+		IsEpoch: true,
+		Err:     err,
+	}
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// forkNode is a block seen through the events, canonical or not
+type forkNode struct {
+	root          phase0.Root
+	parentRoot    phase0.Root
+	slot          phase0.Slot
+	proposerIndex phase0.ValidatorIndex
+}
+
+// forkTree keeps every block root seen through the block, head and chain_reorg events
+// until finality decides whether its branch is canonical or orphaned
+type forkTree struct {
+	sync.Mutex
+	nodes     map[phase0.Root]forkNode
+	finalized forkNode // blocks are followed back until this one
+}
+
+func newForkTree() *forkTree {
+	return &forkTree{
+		nodes: make(map[phase0.Root]forkNode),
+	}
+}
+
+func (t *forkTree) contains(root phase0.Root) bool {
+	t.Lock()
+	defer t.Unlock()
+	_, ok := t.nodes[root]
+	return ok
+}
+
+func (t *forkTree) add(node forkNode) {
+	t.Lock()
+	defer t.Unlock()
+	t.nodes[node.root] = node
+}
+
+func (t *forkTree) size() int {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.nodes)
+}
+
+// setFinalized adds the finalized block the tree starts from
+func (t *forkTree) setFinalized(node forkNode) {
+	t.Lock()
+	defer t.Unlock()
+	t.nodes[node.root] = node
+	t.finalized = node
+}
+
+func (t *forkTree) finalizedSlot() phase0.Slot {
+	t.Lock()
+	defer t.Unlock()
+	return t.finalized.slot
+}
+
+// finalize returns the blocks that do not descend from the finalized one, which can no longer
+// become canonical, and removes them from the tree together with the canonical blocks before
+// the finalized one. The finalized block is kept, so the next branches can be followed to it.
+// Blocks whose ancestors are missing cannot be told apart from canonical ones: they are
+// dropped without being reported once they are not newer than the finalized block
+func (t *forkTree) finalize(finalizedRoot phase0.Root) []spec.ForkOrphan {
+	t.Lock()
+	defer t.Unlock()
+
+	finalized, ok := t.nodes[finalizedRoot]
+	if !ok {
+		return nil
+	}
+
+	// ancestors of the finalized block
+	canonical := make(map[phase0.Root]bool)
+	for node, ok := finalized, true; ok; node, ok = t.nodes[node.parentRoot] {
+		canonical[node.root] = true
+	}
+
+	orphans := make([]spec.ForkOrphan, 0)
+	undecided := make([]phase0.Root, 0)
+	for root, node := range t.nodes {
+		if canonical[root] {
+			continue
+		}
+		// follow the branch back until it reaches the canonical chain
+		depth := uint64(1)
+		splitSlot := phase0.Slot(0)
+		descendant := false
+		parent, known := t.nodes[node.parentRoot]
+		for known {
+			if parent.root == finalizedRoot {
+				descendant = true
+				break
+			}
+			if canonical[parent.root] {
+				splitSlot = parent.slot
+				break
+			}
+			depth += 1
+			parent, known = t.nodes[parent.parentRoot]
+		}
+		if descendant {
+			continue
+		}
+		if !known {
+			if node.slot <= finalized.slot {
+				undecided = append(undecided, root)
+			}
+			continue
+		}
+		orphans = append(orphans, spec.ForkOrphan{
+			Slot:          node.slot,
+			Root:          node.root,
+			ParentRoot:    node.parentRoot,
+			ProposerIndex: node.proposerIndex,
+			Depth:         depth,
+			SplitSlot:     splitSlot,
+		})
+	}
+
+	for _, orphan := range orphans {
+		delete(t.nodes, orphan.Root)
+	}
+	for _, root := range undecided {
+		delete(t.nodes, root)
+	}
+	for root := range canonical {
+		if root != finalizedRoot {
+			delete(t.nodes, root)
+		}
+	}
+	t.finalized = finalized
+	return orphans
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkTreeFinalize(t *testing.T) {
+	node := func(root byte, parent byte, slot phase0.Slot) forkNode {
+		return forkNode{root: phase0.Root{root}, parentRoot: phase0.Root{parent}, slot: slot, proposerIndex: phase0.ValidatorIndex(root)}
+	}
+
+	tree := newForkTree()
+	tree.setFinalized(node(1, 0, 10))
+	// canonical chain 1 <- 2 <- 3 <- 4, a branch 2 <- 5 <- 6 and a block with an unknown parent
+	tree.add(node(2, 1, 11))
+	tree.add(node(3, 2, 12))
+	tree.add(node(4, 3, 14))
+	tree.add(node(5, 2, 13))
+	tree.add(node(6, 5, 14))
+	tree.add(node(7, 9, 15))
+
+	// the branch is not decided while the finalized block is its ancestor
+	assert.Empty(t, tree.finalize(phase0.Root{1}))
+	assert.Equal(t, 7, tree.size())
+
+	orphans := tree.finalize(phase0.Root{3})
+	assert.ElementsMatch(t, []spec.ForkOrphan{
+		{Slot: 13, Root: phase0.Root{5}, ParentRoot: phase0.Root{2}, ProposerIndex: 5, Depth: 1, SplitSlot: 11},
+		{Slot: 14, Root: phase0.Root{6}, ParentRoot: phase0.Root{5}, ProposerIndex: 6, Depth: 2, SplitSlot: 11},
+	}, orphans)
+	// the finalized block and its descendants are kept, and the unknown branch is newer
+	assert.Equal(t, 3, tree.size())
+	assert.Equal(t, phase0.Slot(12), tree.finalizedSlot())
+
+	orphans = tree.finalize(phase0.Root{4})
+	assert.Empty(t, orphans)
+	tree.add(node(8, 4, 16))
+	// a block with missing ancestors could be canonical, it is dropped without being reported
+	orphans = tree.finalize(phase0.Root{8})
+	assert.Empty(t, orphans)
+	assert.Equal(t, 1, tree.size())
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type slotRange struct {
+	init phase0.Slot
+	end  phase0.Slot
+}
+
+// LedgerGaps returns the ranges missing in the progress ledger for every
+// metric set activated, between initSlot and finalSlot
+func LedgerGaps(dbClient *db.DBService, metrics db.DBMetrics, chainSpec *spec.ChainSpec, initSlot phase0.Slot, finalSlot phase0.Slot) ([]db.LedgerGap, error) {
+	gaps := make([]db.LedgerGap, 0)
+
+	initEpoch := uint64(chainSpec.EpochAtSlot(initSlot))
+	finalEpoch := uint64(chainSpec.EpochAtSlot(finalSlot))
+
+	checks := []struct {
+		active bool
+		metric string
+		from   uint64
+		to     uint64
+	}{
+		{metrics.Block, db.LedgerBlock, uint64(initSlot), uint64(finalSlot)},
+		{metrics.Transactions, db.LedgerTransactions, uint64(initSlot), uint64(finalSlot)},
+		{metrics.Epoch, db.LedgerEpoch, initEpoch, finalEpoch},
+		{metrics.ValidatorRewards, db.LedgerRewards, initEpoch, finalEpoch},
+	}
+
+	for _, check := range checks {
+		if !check.active {
+			continue
+		}
+		metricGaps, err := dbClient.RetrieveLedgerGaps(check.metric, check.from, check.to)
+		if err != nil {
+			return gaps, err
+		}
+		gaps = append(gaps, metricGaps...)
+	}
+	return gaps, nil
+}
+
+// gapsToSlotRanges converts ledger gaps into the sorted, non overlapping slot ranges
+// that runHistorical has to go through to fill them, including the states each metric needs
+func gapsToSlotRanges(chainSpec *spec.ChainSpec, gaps []db.LedgerGap) []slotRange {
+	ranges := make([]slotRange, 0)
+
+	for _, gap := range gaps {
+		var fromEpoch, toEpoch uint64
+		switch gap.Metric {
+		case db.LedgerBlock, db.LedgerTransactions:
+			fromEpoch = gap.From / chainSpec.SlotsPerEpoch
+			toEpoch = gap.To / chainSpec.SlotsPerEpoch
+		case db.LedgerEpoch:
+			// epoch x is written when transitioning to x+1
+			fromEpoch = gap.From
+			toEpoch = gap.To + 1
+		case db.LedgerRewards:
+			// rewards at epoch x need the states at x-2 and x-1
+			fromEpoch = 0
+			if gap.From >= 2 {
+				fromEpoch = gap.From - 2
+			}
+			toEpoch = gap.To
+		default:
+			continue
+		}
+		ranges = append(ranges, slotRange{
+			init: chainSpec.EpochStartSlot(phase0.Epoch(fromEpoch)),
+			end:  chainSpec.EpochEndSlot(phase0.Epoch(toEpoch)),
+		})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].init < ranges[j].init
+	})
+
+	merged := make([]slotRange, 0, len(ranges))
+	for _, item := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && item.init <= merged[last].end+1 {
+			if item.end > merged[last].end {
+				merged[last].end = item.end
+			}
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// runGaps looks for the missing ranges in the progress ledger and runs
+// the historical routine over each of them, one after the other
+func (s *ChainAnalyzer) runGaps() error {
+
+	gaps, err := LedgerGaps(s.dbClient, s.metrics, s.chainSpec, s.initSlot, s.finalSlot)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve gaps from the progress ledger")
+	}
+	ranges := gapsToSlotRanges(s.chainSpec, gaps)
+	log.Infof("found %d ranges to fill", len(ranges))
+
+	for _, item := range ranges {
+		if s.stopped() {
+			log.Info("sudden shutdown detected, gaps routine")
+			return nil
+		}
+		// the previous range has to be completely processed before moving initSlot
+		s.waitProcessingIdle()
+		s.initSlot = item.init
+
+		err = s.runHistorical(item.init, item.end)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("gaps mode: all ranges sent")
+	return nil
+}
+
+// waitProcessingIdle blocks until there are no pending tasks nor active processers
+func (s *ChainAnalyzer) waitProcessingIdle() {
+	ticker := time.NewTicker(utils.RoutineFlushTimeout)
+	defer ticker.Stop()
+	for len(s.downloadTaskChan) > 0 || s.processerBook.ActivePages() > 0 || s.activeReqs.Load() > 0 {
+		if s.stopped() {
+			return
+		}
+		<-ticker.C
+	}
+}
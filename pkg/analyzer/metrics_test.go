@@ -2,19 +2,72 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/clientapi"
 	"github.com/migalabs/goteth/pkg/db"
-	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/fakenode"
 	"github.com/migalabs/goteth/pkg/spec/metrics"
 	"github.com/stretchr/testify/assert"
 )
 
-func BuildChainAnalyzer() (ChainAnalyzer, error) {
+var (
+	// responses recorded with `goteth record --fixtures-dir`, replayed by fake nodes
+	fixturesDir    = "testdata/fixtures"
+	bnTestEndpoint string
+	elTestEndpoint string
+	replayErr      error
+	replayOnce     sync.Once
+)
+
+// nodes to run the tests against when there are no fixtures, also used to record them through the proxies
+const (
+	bnEndpointEnv = "GOTETH_TEST_BN_ENDPOINT"
+	elEndpointEnv = "GOTETH_TEST_EL_ENDPOINT"
+)
+
+// testEndpoints starts the fake nodes the first time if there are fixtures, they live until the tests end.
+// Otherwise it returns the nodes set in the environment, and without them the test is skipped
+func testEndpoints(t *testing.T) (string, string) {
+	replayOnce.Do(func() {
+		if _, err := os.Stat(fixturesDir); errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		bn, err := fakenode.NewReplayServer(filepath.Join(fixturesDir, fakenode.BeaconFixtures))
+		if err != nil {
+			replayErr = err
+			return
+		}
+		el, err := fakenode.NewReplayServer(filepath.Join(fixturesDir, fakenode.ExecutionFixtures))
+		if err != nil {
+			replayErr = err
+			return
+		}
+		bnTestEndpoint = bn.URL
+		elTestEndpoint = el.URL
+	})
+	if replayErr != nil {
+		t.Fatalf("could not replay the fixtures in %s: %s", fixturesDir, replayErr)
+	}
+	if bnTestEndpoint != "" {
+		return bnTestEndpoint, elTestEndpoint
+	}
+
+	bnEndpoint, elEndpoint := os.Getenv(bnEndpointEnv), os.Getenv(elEndpointEnv)
+	if bnEndpoint == "" || elEndpoint == "" {
+		t.Skipf("no fixtures in %s and no nodes in %s and %s", fixturesDir, bnEndpointEnv, elEndpointEnv)
+	}
+	return bnEndpoint, elEndpoint
+}
+
+func BuildChainAnalyzer(t *testing.T) (ChainAnalyzer, error) {
 
 	ctx := context.Background()
 
@@ -26,11 +79,13 @@ func BuildChainAnalyzer() (ChainAnalyzer, error) {
 		APIRewards:       true,
 	}
 
+	bnEndpoint, elEndpoint := testEndpoints(t)
+
 	// generate the httpAPI client
 	cli, err := clientapi.NewAPIClient(
 		ctx,
-		"http://localhost:5052",
-		clientapi.WithELEndpoint("http://localhost:8545"),
+		bnEndpoint,
+		clientapi.WithELEndpoint(elEndpoint),
 		clientapi.WithDBMetrics(dbMetrics))
 	if err != nil {
 		return ChainAnalyzer{}, err
@@ -43,12 +98,14 @@ func BuildChainAnalyzer() (ChainAnalyzer, error) {
 	}, nil
 }
 
-func BuildChainAnalyzerWithEL() (ChainAnalyzer, error) {
+func BuildChainAnalyzerWithEL(t *testing.T) (ChainAnalyzer, error) {
 
 	ctx := context.Background()
 
+	bnEndpoint, elEndpoint := testEndpoints(t)
+
 	// generate the httpAPI client
-	cli, err := clientapi.NewAPIClient(ctx, "http://localhost:5052", clientapi.WithELEndpoint("http://localhost:8545"))
+	cli, err := clientapi.NewAPIClient(ctx, bnEndpoint, clientapi.WithELEndpoint(elEndpoint))
 	if err != nil {
 		return ChainAnalyzer{}, err
 	}
@@ -63,12 +120,11 @@ func BuildEpochTask(analyzer *ChainAnalyzer, slot phase0.Slot) (metrics.StateMet
 
 	// Review slot is well positioned
 
-	epoch := slot / spec.SlotsPerEpoch
-
-	slot = ((epoch + 1) * spec.SlotsPerEpoch) - 1
+	chainSpec := analyzer.cli.ChainSpec
+	slot = chainSpec.EpochEndSlot(chainSpec.EpochAtSlot(slot))
 
-	fmt.Printf("downloading state at slot: %d\n", slot-spec.SlotsPerEpoch)
-	prevState, err := analyzer.cli.RequestBeaconState(slot - spec.SlotsPerEpoch)
+	fmt.Printf("downloading state at slot: %d\n", slot-chainSpec.Slots(1))
+	prevState, err := analyzer.cli.RequestBeaconState(slot - chainSpec.Slots(1))
 	if err != nil {
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 
@@ -80,8 +136,8 @@ func BuildEpochTask(analyzer *ChainAnalyzer, slot phase0.Slot) (metrics.StateMet
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 	}
 
-	fmt.Printf("downloading state at slot: %d\n", slot+spec.SlotsPerEpoch)
-	nextState, err := analyzer.cli.RequestBeaconState(slot + spec.SlotsPerEpoch)
+	fmt.Printf("downloading state at slot: %d\n", slot+chainSpec.Slots(1))
+	nextState, err := analyzer.cli.RequestBeaconState(slot + chainSpec.Slots(1))
 	if err != nil {
 		return metrics.Phase0Metrics{}, fmt.Errorf("could not download state: %s", err)
 	}
@@ -96,7 +152,7 @@ func BuildEpochTask(analyzer *ChainAnalyzer, slot phase0.Slot) (metrics.StateMet
 
 func TestPhase0Epoch(t *testing.T) {
 
-	analyzer, err := BuildChainAnalyzer()
+	analyzer, err := BuildChainAnalyzer(t)
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
 		return
@@ -117,7 +173,7 @@ func TestPhase0Epoch(t *testing.T) {
 
 func TestAltairEpoch(t *testing.T) {
 
-	analyzer, err := BuildChainAnalyzer()
+	analyzer, err := BuildChainAnalyzer(t)
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
 		return
@@ -144,7 +200,7 @@ func TestAltairEpoch(t *testing.T) {
 
 func TestAltairRewards(t *testing.T) {
 
-	analyzer, err := BuildChainAnalyzer()
+	analyzer, err := BuildChainAnalyzer(t)
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
 		return
@@ -238,7 +294,7 @@ func TestAltairRewards(t *testing.T) {
 
 func TestAltairNegativeRewards(t *testing.T) {
 
-	analyzer, err := BuildChainAnalyzer()
+	analyzer, err := BuildChainAnalyzer(t)
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
 		return
@@ -292,7 +348,7 @@ func TestAltairNegativeRewards(t *testing.T) {
 
 func TestCapellaBlock(t *testing.T) {
 
-	blockAnalyzer, err := BuildChainAnalyzerWithEL()
+	blockAnalyzer, err := BuildChainAnalyzerWithEL(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -357,7 +413,7 @@ func TestCapellaBlock(t *testing.T) {
 
 func TestBellatrixBlock(t *testing.T) {
 
-	blockAnalyzer, err := BuildChainAnalyzer()
+	blockAnalyzer, err := BuildChainAnalyzer(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -422,7 +478,7 @@ func TestBellatrixBlock(t *testing.T) {
 
 func TestAltairBlock(t *testing.T) {
 
-	blockAnalyzer, err := BuildChainAnalyzer()
+	blockAnalyzer, err := BuildChainAnalyzer(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -474,7 +530,7 @@ func TestAltairBlock(t *testing.T) {
 
 func TestPhase0Block(t *testing.T) {
 
-	blockAnalyzer, err := BuildChainAnalyzer()
+	blockAnalyzer, err := BuildChainAnalyzer(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -525,7 +581,7 @@ func TestPhase0Block(t *testing.T) {
 }
 
 func TestTransactionGasWhenELIsProvided(t *testing.T) {
-	blockAnalyzer, err := BuildChainAnalyzerWithEL()
+	blockAnalyzer, err := BuildChainAnalyzerWithEL(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -554,7 +610,7 @@ func TestTransactionGasWhenELIsProvided(t *testing.T) {
 }
 
 func TestTransactionGasWhenELNotProvided(t *testing.T) {
-	blockAnalyzer, err := BuildChainAnalyzer()
+	blockAnalyzer, err := BuildChainAnalyzer(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -583,7 +639,7 @@ func TestTransactionGasWhenELNotProvided(t *testing.T) {
 }
 
 func TestBlockSizeIsSetWhenELIsProvided(t *testing.T) {
-	blockAnalyzer, err := BuildChainAnalyzerWithEL()
+	blockAnalyzer, err := BuildChainAnalyzerWithEL(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -595,7 +651,7 @@ func TestBlockSizeIsSetWhenELIsProvided(t *testing.T) {
 }
 
 func TestBlockSizeNotSetWhenELNotProvided(t *testing.T) {
-	blockAnalyzer, err := BuildChainAnalyzer()
+	blockAnalyzer, err := BuildChainAnalyzer(t)
 
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
@@ -608,7 +664,7 @@ func TestBlockSizeNotSetWhenELNotProvided(t *testing.T) {
 
 func TestBlockGasFees(t *testing.T) {
 
-	analyzer, err := BuildChainAnalyzer()
+	analyzer, err := BuildChainAnalyzer(t)
 	if err != nil {
 		t.Errorf("could not build analyzer: %s", err)
 		return
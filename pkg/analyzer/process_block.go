@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
@@ -11,48 +12,56 @@ var (
 	slotProcesserTag = "slot="
 )
 
-func (s *ChainAnalyzer) ProcessBlock(slot phase0.Slot) {
+func (s *ChainAnalyzer) ProcessBlock(slot phase0.Slot) error {
 	if !s.metrics.Block {
-		return
+		return nil
 	}
 	routineKey := fmt.Sprintf("%s%d", slotProcesserTag, slot)
 	s.processerBook.Acquire(routineKey) // register a new slot to process, good for monitoring
+	defer s.processerBook.FreePage(routineKey)
 
-	block := s.downloadCache.BlockHistory.Wait(SlotTo[uint64](slot))
-
-	err := s.dbClient.PersistBlocks([]spec.AgnosticBlock{*block})
+	block, err := s.downloadCache.BlockHistory.Wait(s.ctx, SlotTo[uint64](slot))
 	if err != nil {
-		log.Errorf("error persisting blocks: %s", err.Error())
+		return slotError(s.chainSpec, "block processing", slot, err)
 	}
 
-	var withdrawals []spec.Withdrawal
-	for _, item := range block.ExecutionPayload.Withdrawals {
-		withdrawals = append(withdrawals, spec.Withdrawal{
-			Slot:           block.Slot,
-			Index:          item.Index,
-			ValidatorIndex: item.ValidatorIndex,
-			Address:        item.Address,
-			Amount:         item.Amount,
-		})
+	err = s.dbClient.PersistBlocks([]spec.AgnosticBlock{*block})
+	if err != nil {
+		return slotError(s.chainSpec, "block persist", slot, err)
 	}
 
-	err = s.dbClient.PersistWithdrawals(withdrawals)
+	err = s.runBlockProcessors(block)
+	if err != nil {
+		return slotError(s.chainSpec, "block processors", slot, err)
+	}
+	// the slot is only complete once every block processor wrote its rows
+	err = s.recordProgress(db.LedgerBlock, uint64(slot))
 	if err != nil {
-		log.Errorf("error persisting withdrawals: %s", err.Error())
+		return slotError(s.chainSpec, "progress ledger", slot, err)
 	}
 
 	if s.metrics.Transactions {
-		s.processTransactions(block)
-		s.processBlobSidecars(block, block.ExecutionPayload.AgnosticTransactions)
+		txErr := s.processTransactions(block)
+		blobsPersisted, err := s.processBlobSidecars(block, block.ExecutionPayload.AgnosticTransactions)
+		if err != nil {
+			return slotError(s.chainSpec, "blob sidecars processing", slot, err)
+		}
+		if txErr == nil && blobsPersisted {
+			err = s.recordProgress(db.LedgerTransactions, uint64(slot))
+			if err != nil {
+				return slotError(s.chainSpec, "progress ledger", slot, err)
+			}
+		}
 	}
-	s.processerBook.FreePage(routineKey)
+	return nil
 }
 
-func (s *ChainAnalyzer) processTransactions(block *spec.AgnosticBlock) {
+func (s *ChainAnalyzer) processTransactions(block *spec.AgnosticBlock) error {
 
 	txs, err := s.cli.GetBlockTransactions(*block)
 	if err != nil {
 		log.Errorf("error getting slot %d transactions: %s", block.Slot, err.Error())
+		return err
 	}
 	block.ExecutionPayload.AgnosticTransactions = txs
 
@@ -60,17 +69,19 @@ func (s *ChainAnalyzer) processTransactions(block *spec.AgnosticBlock) {
 	if err != nil {
 		log.Errorf("error persisting transactions: %s", err.Error())
 	}
+	return err
 
 }
 
-func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spec.AgnosticTransaction) {
+// processBlobSidecars returns whether the blobs were persisted, and an error if they could not be downloaded
+func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spec.AgnosticTransaction) (bool, error) {
 
 	persistable := make([]*spec.AgnosticBlobSidecar, 0)
 
 	blobs, err := s.cli.RequestBlobSidecars(block.Slot)
 
 	if err != nil {
-		log.Fatalf("could not download blob sidecars for slot %d: %s", block.Slot, err)
+		return false, fmt.Errorf("could not download blob sidecars: %s", err)
 	}
 
 	if len(blobs) > 0 {
@@ -78,6 +89,14 @@ func (s *ChainAnalyzer) processBlobSidecars(block *spec.AgnosticBlock, txs []spe
 			blob.GetTxHash(txs)
 			persistable = append(persistable, blob)
 		}
-		s.dbClient.PersistBlobSidecars(blobs)
+		err = s.dbClient.PersistBlobSidecars(blobs)
+		return err == nil, nil
 	}
+	return true, nil
+}
+
+// recordProgress marks the slot or epoch as processed for the given metric set.
+// Epochs are recorded by the db client together with their rows
+func (s *ChainAnalyzer) recordProgress(metric string, index uint64) error {
+	return s.dbClient.PersistLedgerEntries([]db.LedgerEntry{db.NewLedgerEntry(metric, index)})
 }
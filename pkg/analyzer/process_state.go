@@ -16,189 +16,105 @@ var (
 
 // We always provide the epoch we transition to
 // To process the transition from epoch 9 to 10, we provide 10 and we retrieve 8, 9, 10
-func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) {
+func (s *ChainAnalyzer) ProcessStateTransitionMetrics(epoch phase0.Epoch) error {
 
 	if !s.metrics.Epoch {
-		return
+		return nil
 	}
 
 	routineKey := fmt.Sprintf("%s%d", epochProcesserTag, epoch)
 	s.processerBook.Acquire(routineKey) // resgiter we are about to process metrics for epoch
+	defer s.processerBook.FreePage(routineKey)
 
 	// Retrieve states to process metrics
 
 	prevState := &spec.AgnosticState{}
 	currentState := &spec.AgnosticState{}
 	nextState := &spec.AgnosticState{}
+	var err error
 
 	// this state may never be downloaded if it is below initSlot
-	if epoch >= 2 && epoch-2 >= phase0.Epoch(s.initSlot/spec.SlotsPerEpoch) {
-		prevState = s.downloadCache.StateHistory.Wait(EpochTo[uint64](epoch) - 2)
-	}
-	if epoch >= 1 && epoch-1 >= phase0.Epoch(s.initSlot/spec.SlotsPerEpoch) {
-		currentState = s.downloadCache.StateHistory.Wait(EpochTo[uint64](epoch) - 1)
-	}
-	nextState = s.downloadCache.StateHistory.Wait(EpochTo[uint64](epoch))
-
-	bundle, err := metrics.StateMetricsByForkVersion(nextState, currentState, prevState, s.cli.Api)
-	if err != nil {
-		s.processerBook.FreePage(routineKey)
-		log.Errorf("could not parse bundle metrics at epoch: %s", err)
-		s.stop = true
+	if epoch >= 2 && epoch-2 >= s.chainSpec.EpochAtSlot(s.initSlot) {
+		prevState, err = s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)-2)
+		if err != nil {
+			return epochError(s.chainSpec, "epoch processing", epoch, err)
+		}
 	}
-
-	// If nextState is filled, we can process proposer duties
-	if !nextState.EmptyStateRoot() {
-		s.processEpochDuties(bundle)
-		s.processValLastStatus(bundle)
-
-		// If currentState and nextState are filled, we can process epoch metrics
-		if !currentState.EmptyStateRoot() {
-			s.processPoolMetrics(bundle.GetMetricsBase().CurrentState.Epoch)
-			s.processEpochMetrics(bundle)
-
-			// If prevState, currentState and nextState are filled, we can process validator rewards
-			if !prevState.EmptyStateRoot() {
-				s.processBlockRewards(bundle) // block rewards depend on two previous epochs
-				if s.metrics.ValidatorRewards {
-					s.processEpochValRewards(bundle)
-				}
-			}
+	if epoch >= 1 && epoch-1 >= s.chainSpec.EpochAtSlot(s.initSlot) {
+		currentState, err = s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)-1)
+		if err != nil {
+			return epochError(s.chainSpec, "epoch processing", epoch, err)
 		}
 	}
-
-	s.processerBook.FreePage(routineKey)
-
-}
-
-func (s *ChainAnalyzer) processEpochMetrics(bundle metrics.StateMetrics) {
-
-	// we need sameEpoch and nextEpoch
-
-	epoch := bundle.GetMetricsBase().ExportToEpoch()
-
-	log.Debugf("persisting epoch metrics: epoch %d", epoch.Epoch)
-
-	err := s.dbClient.PersistEpochs([]spec.Epoch{epoch})
+	nextState, err = s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch))
 	if err != nil {
-		log.Errorf("error persisting epoch: %s", err.Error())
+		return epochError(s.chainSpec, "epoch processing", epoch, err)
 	}
 
-}
-
-func (s *ChainAnalyzer) processPoolMetrics(epoch phase0.Epoch) {
-
-	log.Debugf("persisting pool summaries: epoch %d", epoch)
-
-	err := s.dbClient.InsertPoolSummary(epoch)
-
-	// we need sameEpoch and nextEpoch
-
+	bundle, err := metrics.StateMetricsByForkVersion(nextState, currentState, prevState, s.cli.Api)
 	if err != nil {
-		log.Fatalf("error persisting pool metrics: %s", err.Error())
+		return epochError(s.chainSpec, "state metrics bundle", epoch, err)
 	}
 
-}
+	// once the bundle is ready, every metric of the transition is persisted:
+	// cancellation is only checked while waiting for the states, so epochs are never half written
 
-func (s *ChainAnalyzer) processEpochDuties(bundle metrics.StateMetrics) {
-
-	missedBlocks := bundle.GetMetricsBase().NextState.MissedBlocks
-
-	var duties []spec.ProposerDuty
-
-	for _, item := range bundle.GetMetricsBase().NextState.EpochStructs.ProposerDuties {
+	if nextState.EmptyStateRoot() {
+		return nil
+	}
 
-		newDuty := spec.ProposerDuty{
-			ValIdx:       item.ValidatorIndex,
-			ProposerSlot: item.Slot,
-			Proposed:     true,
+	// If currentState and nextState are filled, we can process epoch metrics
+	var rewardsErr error
+	if !currentState.EmptyStateRoot() {
+		// the epoch is recorded in the progress ledger together with its metrics
+		err = s.processEpochMetrics(bundle)
+		if err != nil {
+			return epochError(s.chainSpec, "epoch persist", epoch, err)
 		}
-		for _, item := range missedBlocks {
-			if newDuty.ProposerSlot == item { // we found the proposer slot in the missed blocks
-				newDuty.Proposed = false
-			}
+
+		// If prevState, currentState and nextState are filled, we can process block rewards
+		if !prevState.EmptyStateRoot() {
+			rewardsErr = s.processBlockRewards(bundle) // block rewards depend on two previous epochs
 		}
-		duties = append(duties, newDuty)
 	}
 
-	err := s.dbClient.PersistDuties(duties)
+	err = s.runEpochProcessors(bundle)
 	if err != nil {
-		log.Fatalf("error persisting proposer duties: %s", err.Error())
+		return epochError(s.chainSpec, "epoch processors", epoch, err)
 	}
 
-}
-
-func (s *ChainAnalyzer) processValLastStatus(bundle metrics.StateMetrics) {
-
-	if s.downloadMode == "finalized" {
-		var valStatusArr []spec.ValidatorLastStatus
-		for valIdx, validator := range bundle.GetMetricsBase().NextState.Validators {
-
-			newVal := spec.ValidatorLastStatus{
-				ValIdx:          phase0.ValidatorIndex(valIdx),
-				Epoch:           bundle.GetMetricsBase().NextState.Epoch,
-				CurrentBalance:  bundle.GetMetricsBase().NextState.Balances[valIdx],
-				CurrentStatus:   bundle.GetMetricsBase().NextState.GetValStatus(phase0.ValidatorIndex(valIdx)),
-				Slashed:         validator.Slashed,
-				ActivationEpoch: validator.ActivationEpoch,
-				WithdrawalEpoch: validator.WithdrawableEpoch,
-				ExitEpoch:       validator.ExitEpoch,
-				PublicKey:       validator.PublicKey,
-			}
-			valStatusArr = append(valStatusArr, newVal)
-		}
-		if len(valStatusArr) > 0 { // persist everything
-
-			err := s.dbClient.PersistValLastStatus(valStatusArr)
-			if err != nil {
-				log.Errorf("error persisting validator last status: %s", err.Error())
-			}
-			err = s.dbClient.DeleteValLastStatus(bundle.GetMetricsBase().NextState.Epoch)
-			if err != nil {
-				log.Errorf("error deleting validator last status: %s", err.Error())
-			}
+	// the rewards are left out of the ledger if their block rewards could not be written
+	if s.metrics.ValidatorRewards && !currentState.EmptyStateRoot() && !prevState.EmptyStateRoot() && rewardsErr == nil {
+		err = s.recordProgress(db.LedgerRewards, uint64(bundle.GetMetricsBase().NextState.Epoch))
+		if err != nil {
+			return epochError(s.chainSpec, "progress ledger", epoch, err)
 		}
 	}
-}
-
-func (s *ChainAnalyzer) processEpochValRewards(bundle metrics.StateMetrics) {
 
-	if s.metrics.ValidatorRewards { // only if flag is activated
-		var insertValsObj []spec.ValidatorRewards
-		log.Debugf("persising validator metrics: epoch %d", bundle.GetMetricsBase().NextState.Epoch)
-
-		// process each validator
-		for valIdx := range bundle.GetMetricsBase().NextState.Validators {
+	return nil
+}
 
-			if valIdx >= len(bundle.GetMetricsBase().NextState.Validators) {
-				continue // validator is not in the chain yet
-			}
-			// get max reward at given epoch using the formulas
-			maxRewards, err := bundle.GetMaxReward(phase0.ValidatorIndex(valIdx))
+func (s *ChainAnalyzer) processEpochMetrics(bundle metrics.StateMetrics) error {
 
-			if err != nil {
-				log.Errorf("Error obtaining max reward: %s", err.Error())
-				continue
-			}
+	// we need sameEpoch and nextEpoch
 
-			insertValsObj = append(insertValsObj, maxRewards)
-		}
-		if len(insertValsObj) > 0 { // persist everything
-			err := s.dbClient.PersistValidatorRewards(insertValsObj)
-			if err != nil {
-				log.Fatalf("error persisting validator rewards: %s", err.Error())
-			}
+	epoch := bundle.GetMetricsBase().ExportToEpoch()
 
-		}
+	log.Debugf("persisting epoch metrics: epoch %d", epoch.Epoch)
 
+	err := s.dbClient.PersistEpochs([]spec.Epoch{epoch})
+	if err != nil {
+		log.Errorf("error persisting epoch: %s", err.Error())
 	}
+	return err
+
 }
 
-func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) {
+func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) error {
 
 	blockRewards := make([]db.BlockReward, 0)
 
-	mevBids, err := s.relayCli.GetDeliveredBidsPerSlotRange(bundle.GetMetricsBase().NextState.Slot, spec.SlotsPerEpoch)
+	mevBids, err := s.relayCli.GetDeliveredBidsPerSlotRange(bundle.GetMetricsBase().NextState.Slot, int(s.chainSpec.SlotsPerEpoch))
 	if err != nil {
 		log.Errorf("error getting mev bids: %s", err.Error())
 	}
@@ -207,8 +123,7 @@ func (s *ChainAnalyzer) processBlockRewards(bundle metrics.StateMetrics) {
 		blockRewards = append(blockRewards, s.getSingleBlockRewards(*block, mevBids))
 	}
 
-	s.dbClient.PersistBlockRewards(blockRewards)
-
+	return s.dbClient.PersistBlockRewards(blockRewards)
 }
 
 func (s *ChainAnalyzer) getSingleBlockRewards(
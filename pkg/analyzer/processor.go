@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+)
+
+// Processor derives metrics from every block and epoch transition the analyzer goes through.
+// OnBlock is called once the block has been persisted, OnEpoch once the state metrics
+// bundle of the transition is ready. States that were not downloaded (below the init slot)
+// are empty, check EmptyStateRoot before using PrevState or CurrentState.
+// Processors are called in order (built-in first, then custom ones as listed in --metrics),
+// and again with the new data whenever a reorg or a finalized check rewrites a slot or epoch.
+// A returned error stops the analyzer
+type Processor interface {
+	OnBlock(block *spec.AgnosticBlock) error
+	OnEpoch(bundle metrics.StateMetrics) error
+}
+
+// ProcessorReverter can be implemented by processors that need to delete
+// what they wrote before a slot or epoch is processed again
+type ProcessorReverter interface {
+	RevertBlock(slot phase0.Slot) error
+	RevertEpoch(epoch phase0.Epoch) error
+}
+
+// ProcessorBuilder creates a processor for an analyzer.
+// Hybrid mode runs two analyzers, so the builder may be called twice
+type ProcessorBuilder func(ctx context.Context, dbClient *db.DBService) (Processor, error)
+
+var (
+	processorsMu       sync.Mutex
+	registeredBuilders = make(map[string]ProcessorBuilder)
+)
+
+// RegisterProcessor makes a custom processor available under the given name,
+// so it can be enabled through the --metrics list. It is meant to be called from init
+func RegisterProcessor(name string, builder ProcessorBuilder) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	registeredBuilders[name] = builder
+}
+
+// newProcessors returns the built-in processors enabled by the metrics
+// followed by the custom processors listed in them
+func newProcessors(
+	ctx context.Context,
+	dbMetrics db.DBMetrics,
+	downloadMode string,
+	dbClient *db.DBService) ([]Processor, error) {
+
+	processors := make([]Processor, 0)
+
+	if dbMetrics.Block {
+		processors = append(processors, &withdrawalsProcessor{dbClient: dbClient})
+		processors = append(processors, &blsToExecutionChangesProcessor{dbClient: dbClient})
+		processors = append(processors, &executionRequestsProcessor{dbClient: dbClient})
+	}
+	if dbMetrics.Epoch {
+		processors = append(processors, &dutiesProcessor{dbClient: dbClient})
+		if downloadMode == "finalized" || downloadMode == "hybrid" {
+			processors = append(processors, &valLastStatusProcessor{dbClient: dbClient})
+		}
+		processors = append(processors, &poolsProcessor{dbClient: dbClient})
+		processors = append(processors, &blockPackingProcessor{dbClient: dbClient})
+		processors = append(processors, &valEventsProcessor{dbClient: dbClient})
+	}
+	if dbMetrics.ValidatorRewards {
+		processors = append(processors, &valRewardsProcessor{dbClient: dbClient})
+	}
+	if dbMetrics.AttDuties {
+		processors = append(processors, &attDutiesProcessor{dbClient: dbClient})
+	}
+
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	for _, name := range dbMetrics.Processors {
+		builder, ok := registeredBuilders[name]
+		if !ok {
+			return processors, fmt.Errorf("could not parse metric: %s", name)
+		}
+		processor, err := builder(ctx, dbClient)
+		if err != nil {
+			return processors, fmt.Errorf("could not create processor %s: %s", name, err)
+		}
+		processors = append(processors, processor)
+	}
+	return processors, nil
+}
+
+func (s *ChainAnalyzer) runBlockProcessors(block *spec.AgnosticBlock) error {
+	for _, processor := range s.processors {
+		err := processor.OnBlock(block)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ChainAnalyzer) runEpochProcessors(bundle metrics.StateMetrics) error {
+	for _, processor := range s.processors {
+		err := processor.OnEpoch(bundle)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBlockMetrics removes everything written for the slot, so it can be processed again
+func (s *ChainAnalyzer) deleteBlockMetrics(slot phase0.Slot) {
+	err := s.dbClient.DeleteBlockMetrics(slot)
+	if err != nil {
+		log.Errorf("error deleting block metrics for slot %d: %s", slot, err)
+	}
+	for _, processor := range s.processors {
+		if reverter, ok := processor.(ProcessorReverter); ok {
+			err = reverter.RevertBlock(slot)
+			if err != nil {
+				log.Errorf("error reverting processor for slot %d: %s", slot, err)
+			}
+		}
+	}
+}
+
+// deleteStateMetrics removes everything written using the state at the epoch, so it can be processed again
+func (s *ChainAnalyzer) deleteStateMetrics(epoch phase0.Epoch) {
+	err := s.dbClient.DeleteStateMetrics(epoch)
+	if err != nil {
+		log.Errorf("error deleting state metrics for epoch %d: %s", epoch, err)
+	}
+	for _, processor := range s.processors {
+		if reverter, ok := processor.(ProcessorReverter); ok {
+			err = reverter.RevertEpoch(epoch)
+			if err != nil {
+				log.Errorf("error reverting processor for epoch %d: %s", epoch, err)
+			}
+		}
+	}
+}
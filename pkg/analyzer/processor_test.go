@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProcessor struct {
+	blocks int
+}
+
+func (p *countingProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	p.blocks++
+	return nil
+}
+
+func (p *countingProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	return nil
+}
+
+func TestNewProcessors(t *testing.T) {
+
+	dbMetrics, err := db.NewMetrics("rewards")
+	assert.Nil(t, err)
+	processors, err := newProcessors(context.Background(), dbMetrics, "historical", nil)
+	assert.Nil(t, err)
+	// withdrawals, bls to execution changes, execution requests, duties, pools, block packing, validator events and rewards: val last status only follows the head
+	assert.Equal(t, 8, len(processors))
+
+	processors, err = newProcessors(context.Background(), dbMetrics, "finalized", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, len(processors))
+
+	// custom processors have to be registered
+	dbMetrics, err = db.NewMetrics("block,counter")
+	assert.Nil(t, err)
+	_, err = newProcessors(context.Background(), dbMetrics, "historical", nil)
+	assert.NotNil(t, err)
+
+	counter := &countingProcessor{}
+	RegisterProcessor("counter", func(ctx context.Context, dbClient *db.DBService) (Processor, error) {
+		return counter, nil
+	})
+	processors, err = newProcessors(context.Background(), dbMetrics, "historical", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(processors))
+	assert.Equal(t, counter, processors[3]) // custom processors go after the built-in ones
+
+	analyzer := &ChainAnalyzer{processors: processors[3:]}
+	assert.Nil(t, analyzer.runBlockProcessors(&spec.AgnosticBlock{}))
+	assert.Equal(t, 1, counter.blocks)
+}
@@ -0,0 +1,359 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+)
+
+// withdrawalsProcessor persists the withdrawals included in each block
+type withdrawalsProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *withdrawalsProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	var withdrawals []spec.Withdrawal
+	for _, item := range block.ExecutionPayload.Withdrawals {
+		withdrawals = append(withdrawals, spec.Withdrawal{
+			Slot:           block.Slot,
+			Index:          item.Index,
+			ValidatorIndex: item.ValidatorIndex,
+			Address:        item.Address,
+			Amount:         item.Amount,
+		})
+	}
+
+	err := p.dbClient.PersistWithdrawals(withdrawals)
+	if err != nil {
+		return fmt.Errorf("error persisting withdrawals: %s", err.Error())
+	}
+	return nil
+}
+
+func (p *withdrawalsProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	return nil
+}
+
+// blsToExecutionChangesProcessor persists the withdrawal credential changes
+// included in each block from Capella
+type blsToExecutionChangesProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *blsToExecutionChangesProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	var changes []spec.BLSToExecutionChange
+	for _, item := range block.BLSToExecutionChanges {
+		changes = append(changes, spec.BLSToExecutionChange{
+			Slot:               block.Slot,
+			ValidatorIndex:     item.Message.ValidatorIndex,
+			FromBLSPubkey:      item.Message.FromBLSPubkey,
+			ToExecutionAddress: item.Message.ToExecutionAddress,
+		})
+	}
+
+	err := p.dbClient.PersistBLSToExecutionChanges(changes)
+	if err != nil {
+		return fmt.Errorf("error persisting bls to execution changes: %s", err.Error())
+	}
+	return nil
+}
+
+func (p *blsToExecutionChangesProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	return nil
+}
+
+// executionRequestsProcessor persists the deposit, withdrawal and consolidation
+// requests included in each block from Electra
+type executionRequestsProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *executionRequestsProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	requests := block.ExecutionRequests
+
+	err := p.dbClient.PersistDepositRequests(requests.Deposits)
+	if err != nil {
+		return fmt.Errorf("error persisting deposit requests: %s", err.Error())
+	}
+	err = p.dbClient.PersistWithdrawalRequests(requests.Withdrawals)
+	if err != nil {
+		return fmt.Errorf("error persisting withdrawal requests: %s", err.Error())
+	}
+	err = p.dbClient.PersistConsolidationRequests(requests.Consolidations)
+	if err != nil {
+		return fmt.Errorf("error persisting consolidation requests: %s", err.Error())
+	}
+	return nil
+}
+
+func (p *executionRequestsProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	return nil
+}
+
+// dutiesProcessor persists the proposer duties of nextState
+type dutiesProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *dutiesProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *dutiesProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+
+	missedBlocks := bundle.GetMetricsBase().NextState.MissedBlocks
+
+	var duties []spec.ProposerDuty
+
+	for _, item := range bundle.GetMetricsBase().NextState.EpochStructs.ProposerDuties {
+
+		newDuty := spec.ProposerDuty{
+			ValIdx:       item.ValidatorIndex,
+			ProposerSlot: item.Slot,
+			Proposed:     true,
+		}
+		for _, item := range missedBlocks {
+			if newDuty.ProposerSlot == item { // we found the proposer slot in the missed blocks
+				newDuty.Proposed = false
+			}
+		}
+		duties = append(duties, newDuty)
+	}
+
+	err := p.dbClient.PersistDuties(duties)
+	if err != nil {
+		return fmt.Errorf("error persisting proposer duties: %s", err.Error())
+	}
+	return nil
+}
+
+// valLastStatusProcessor keeps the last known status of every validator,
+// only meaningful when following the head
+type valLastStatusProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *valLastStatusProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *valLastStatusProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+
+	var valStatusArr []spec.ValidatorLastStatus
+	for valIdx, validator := range bundle.GetMetricsBase().NextState.Validators {
+
+		newVal := spec.ValidatorLastStatus{
+			ValIdx:          phase0.ValidatorIndex(valIdx),
+			Epoch:           bundle.GetMetricsBase().NextState.Epoch,
+			CurrentBalance:  bundle.GetMetricsBase().NextState.Balances[valIdx],
+			CurrentStatus:   bundle.GetMetricsBase().NextState.GetValStatus(phase0.ValidatorIndex(valIdx)),
+			Slashed:         validator.Slashed,
+			ActivationEpoch: validator.ActivationEpoch,
+			WithdrawalEpoch: validator.WithdrawableEpoch,
+			ExitEpoch:       validator.ExitEpoch,
+			PublicKey:       validator.PublicKey,
+		}
+		valStatusArr = append(valStatusArr, newVal)
+	}
+	if len(valStatusArr) > 0 { // persist everything
+
+		err := p.dbClient.PersistValLastStatus(valStatusArr)
+		if err != nil {
+			return fmt.Errorf("error persisting validator last status: %s", err.Error())
+		}
+		err = p.dbClient.DeleteValLastStatus(bundle.GetMetricsBase().NextState.Epoch)
+		if err != nil {
+			return fmt.Errorf("error deleting validator last status: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// valEventsProcessor persists the changes of the validator registry from currentState to nextState
+type valEventsProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *valEventsProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *valEventsProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	if bundle.GetMetricsBase().CurrentState.EmptyStateRoot() {
+		return nil
+	}
+	events := spec.GetValidatorEvents(bundle.GetMetricsBase().CurrentState, bundle.GetMetricsBase().NextState)
+
+	err := p.dbClient.PersistValidatorEvents(events)
+	if err != nil {
+		return fmt.Errorf("error persisting validator events: %s", err.Error())
+	}
+	return nil
+}
+
+// poolsProcessor aggregates the validator rewards of currentState per pool
+type poolsProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *poolsProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *poolsProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	if bundle.GetMetricsBase().CurrentState.EmptyStateRoot() {
+		return nil
+	}
+	epoch := bundle.GetMetricsBase().CurrentState.Epoch
+
+	log.Debugf("persisting pool summaries: epoch %d", epoch)
+
+	err := p.dbClient.InsertPoolSummary(epoch)
+	if err != nil {
+		return fmt.Errorf("error persisting pool metrics: %s", err.Error())
+	}
+	return nil
+}
+
+// blockPackingProcessor persists again the blocks of currentState once their attestation
+// packing and head vote misses can be measured with the votes of nextState. The new rows
+// replace the ones written when the blocks were downloaded
+type blockPackingProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *blockPackingProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *blockPackingProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	// packing needs the blocks of prevState, currentState and nextState
+	if bundle.GetMetricsBase().PrevState.EmptyStateRoot() || bundle.GetMetricsBase().CurrentState.EmptyStateRoot() {
+		return nil
+	}
+	// phase0 votes are pending attestations, only altair onwards is supported
+	packingBundle, ok := bundle.(blockPackingBundle)
+	if !ok {
+		return nil
+	}
+	// the head vote misses were counted with the participation flags
+	err := packingBundle.ProcessBlockPacking()
+	if err != nil {
+		return fmt.Errorf("error processing block packing: %s", err.Error())
+	}
+
+	blocks := make([]spec.AgnosticBlock, 0, len(bundle.GetMetricsBase().CurrentState.Blocks))
+	for _, block := range bundle.GetMetricsBase().CurrentState.Blocks {
+		blocks = append(blocks, *block)
+	}
+	err = p.dbClient.PersistBlocks(blocks)
+	if err != nil {
+		return fmt.Errorf("error persisting block packing: %s", err.Error())
+	}
+	return nil
+}
+
+// blockPackingBundle is implemented by the metrics of forks with participation flags
+type blockPackingBundle interface {
+	ProcessBlockPacking() error
+}
+
+// valRewardsProcessor persists the rewards of every validator at nextState
+type valRewardsProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *valRewardsProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *valRewardsProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	// rewards need prevState, currentState and nextState
+	if bundle.GetMetricsBase().PrevState.EmptyStateRoot() || bundle.GetMetricsBase().CurrentState.EmptyStateRoot() {
+		return nil
+	}
+
+	log.Debugf("persising validator metrics: epoch %d", bundle.GetMetricsBase().NextState.Epoch)
+	insertValsObj := validatorRewards(bundle)
+
+	if len(insertValsObj) > 0 { // persist everything
+		err := p.dbClient.PersistValidatorRewards(insertValsObj)
+		if err != nil {
+			return fmt.Errorf("error persisting validator rewards: %s", err.Error())
+		}
+	}
+
+	return persistSyncParticipation(p.dbClient, bundle)
+}
+
+// persistSyncParticipation persists the sync committee participation in the blocks of nextState
+func persistSyncParticipation(dbClient *db.DBService, bundle metrics.StateMetrics) error {
+	// sync committees exist from altair
+	syncBundle, ok := bundle.(syncParticipationBundle)
+	if ok && len(syncBundle.GetSyncParticipation()) > 0 {
+		err := dbClient.PersistSyncParticipation(syncBundle.GetSyncParticipation())
+		if err != nil {
+			return fmt.Errorf("error persisting sync committee participation: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// syncParticipationBundle is implemented by the metrics of forks with sync committees
+type syncParticipationBundle interface {
+	GetSyncParticipation() []spec.SyncCommitteeParticipation
+}
+
+// validatorRewards returns the rewards of every validator at nextState
+func validatorRewards(bundle metrics.StateMetrics) []spec.ValidatorRewards {
+	var insertValsObj []spec.ValidatorRewards
+
+	// process each validator
+	for valIdx := range bundle.GetMetricsBase().NextState.Validators {
+
+		// get max reward at given epoch using the formulas
+		maxRewards, err := bundle.GetMaxReward(phase0.ValidatorIndex(valIdx))
+
+		if err != nil {
+			log.Errorf("Error obtaining max reward: %s", err.Error())
+			continue
+		}
+
+		insertValsObj = append(insertValsObj, maxRewards)
+	}
+	return insertValsObj
+}
+
+// attDutiesProcessor persists the attestation duty of every validator in prevState's epoch
+type attDutiesProcessor struct {
+	dbClient *db.DBService
+}
+
+func (p *attDutiesProcessor) OnBlock(block *spec.AgnosticBlock) error {
+	return nil
+}
+
+func (p *attDutiesProcessor) OnEpoch(bundle metrics.StateMetrics) error {
+	// duties need the blocks of prevState and currentState
+	if bundle.GetMetricsBase().PrevState.EmptyStateRoot() || bundle.GetMetricsBase().CurrentState.EmptyStateRoot() {
+		return nil
+	}
+	dutiesBundle, ok := bundle.(attDutiesBundle)
+	if !ok {
+		return fmt.Errorf("attestation duties not available for epoch %d", bundle.GetMetricsBase().NextState.Epoch)
+	}
+
+	err := p.dbClient.PersistAttestationDuties(dutiesBundle.GetAttestationDuties())
+	if err != nil {
+		return fmt.Errorf("error persisting attestation duties: %s", err.Error())
+	}
+	return nil
+}
+
+// attDutiesBundle is implemented by the metrics of every fork
+type attDutiesBundle interface {
+	GetAttestationDuties() []spec.AttestationDuty
+}
@@ -8,9 +8,9 @@ import (
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
-func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
+func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) error {
 
-	finalizedEpoch := newFinalizedSlot / spec.SlotsPerEpoch
+	finalizedEpoch := s.chainSpec.EpochAtSlot(newFinalizedSlot)
 
 	stateKeys := s.downloadCache.StateHistory.GetKeyList()
 
@@ -23,36 +23,54 @@ func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 		advance = true // only set flag if there is something to do
 
 		// Retrieve stored root and redownload root once finalized
-		cacheState := s.downloadCache.StateHistory.Wait(epoch)
-		finalizedStateRoot := s.cli.RequestStateRoot(phase0.Slot(cacheState.Slot))
+		cacheState, err := s.downloadCache.StateHistory.Wait(s.ctx, epoch)
+		if err != nil {
+			return epochError(s.chainSpec, "finalized check", phase0.Epoch(epoch), err)
+		}
+		finalizedStateRoot, err := s.cli.RequestStateRoot(phase0.Slot(cacheState.Slot))
+		if err != nil {
+			return epochError(s.chainSpec, "finalized check", phase0.Epoch(epoch), err)
+		}
 		cacheStateRoot := cacheState.StateRoot
 
 		if finalizedStateRoot != cacheStateRoot { // no match, reorg happened
 			log.Warnf("cache state root: %s\nfinalized block root: %s", cacheStateRoot, finalizedStateRoot)
 			log.Warnf("state root for state (slot=%d) incorrect, redownload", cacheState.Slot)
 
-			s.dbClient.DeleteStateMetrics(phase0.Epoch(epoch))
+			s.deleteStateMetrics(phase0.Epoch(epoch))
 			log.Infof("rewriting metrics for epoch %d", epoch)
 			// write epoch metrics
-			s.ProcessStateTransitionMetrics(phase0.Epoch(epoch))
+			err = s.ProcessStateTransitionMetrics(phase0.Epoch(epoch))
+			if err != nil {
+				return err
+			}
 		}
 
 		// loop over slots in the epoch
-		for slot := (epoch * spec.SlotsPerEpoch); slot < ((epoch + 1) * spec.SlotsPerEpoch); slot++ {
+		for slot := epoch * s.chainSpec.SlotsPerEpoch; slot < (epoch+1)*s.chainSpec.SlotsPerEpoch; slot++ {
 
 			// Retrieve stored root and redownload root once finalized
-			cacheBlock := s.downloadCache.BlockHistory.Wait(slot)
-			finalizedBlockRoot := s.cli.RequestBlockRoot(phase0.Slot(cacheBlock.Slot))
+			cacheBlock, err := s.downloadCache.BlockHistory.Wait(s.ctx, slot)
+			if err != nil {
+				return slotError(s.chainSpec, "finalized check", phase0.Slot(slot), err)
+			}
+			finalizedBlockRoot, err := s.cli.RequestBlockRoot(phase0.Slot(cacheBlock.Slot))
+			if err != nil {
+				return slotError(s.chainSpec, "finalized check", phase0.Slot(slot), err)
+			}
 			cacheBlockRoot := cacheBlock.Root
 
 			if finalizedBlockRoot != cacheBlockRoot {
 				log.Warnf("cache block root: %s\nfinalized block root: %s", cacheBlockRoot, finalizedBlockRoot)
 				log.Warnf("block root for block (slot=%d) incorrect, redownload", cacheBlock.Slot)
 
-				s.dbClient.DeleteBlockMetrics(phase0.Slot(slot))
+				s.deleteBlockMetrics(phase0.Slot(slot))
 				log.Infof("rewriting metrics for slot %d", slot)
 				// write slot metrics
-				s.ProcessBlock(phase0.Slot(slot))
+				err = s.ProcessBlock(phase0.Slot(slot))
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -60,12 +78,13 @@ func (s *ChainAnalyzer) AdvanceFinalized(newFinalizedSlot phase0.Slot) {
 	s.downloadCache.CleanUpTo(newFinalizedSlot)
 
 	if advance {
-		log.Infof("checked states until slot %d, epoch %d", newFinalizedSlot, newFinalizedSlot/spec.SlotsPerEpoch)
+		log.Infof("checked states until slot %d, epoch %d", newFinalizedSlot, finalizedEpoch)
 
 	}
+	return nil
 }
 
-func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
+func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) error {
 	depth := newReorg.Depth
 	reorgSlot := newReorg.Slot
 
@@ -76,45 +95,113 @@ func (s *ChainAnalyzer) HandleReorg(newReorg v1.ChainReorgEvent) {
 
 	for reorgedSlots <= depth { // for every slot in the reorg
 
-		block := s.downloadCache.BlockHistory.Wait(SlotTo[uint64](i)) // first check that it was already in the cache
+		block, err := s.downloadCache.BlockHistory.Wait(s.ctx, SlotTo[uint64](i)) // first check that it was already in the cache
+		if err != nil {
+			return slotError(s.chainSpec, "reorg handling", i, err)
+		}
 		if i < reorgSlot && block.Proposed {
 			reorgedSlots += 1 // only count as reorged slot if there was a block porposed and we are not at the reorg slot
 		}
 		s.processerBook.WaitUntilInactive(fmt.Sprintf("%s%d", slotProcesserTag, i)) // wait until has been processed
 		oldBlock := *block
 
-		s.DownloadBlock(i) // -> inserts into the queue and replaces old block
-		newBlock := s.downloadCache.BlockHistory.Wait(SlotTo[uint64](i))
+		err = s.DownloadBlock(i) // -> inserts into the queue and replaces old block
+		if err != nil {
+			return err
+		}
+		newBlock, err := s.downloadCache.BlockHistory.Wait(s.ctx, SlotTo[uint64](i))
+		if err != nil {
+			return slotError(s.chainSpec, "reorg handling", i, err)
+		}
 
 		if newBlock.Root != oldBlock.Root { // only rewrite if stateroots are different
 			if block.Proposed { // keep orphans -> if previous block was proposed and roots have changed
 				s.dbClient.PersistOrphans([]spec.AgnosticBlock{oldBlock})
 			}
-			s.dbClient.DeleteBlockMetrics(i)
+			s.deleteBlockMetrics(i)
 			log.Infof("rewriting metrics for slot %d", i)
 			// write slot metrics
-			s.ProcessBlock(i)
+			err = s.ProcessBlock(i)
+			if err != nil {
+				return err
+			}
 		} else {
 			log.Infof("reorg slot %d: block roots are the same", i)
 		}
 
-		if (i+1)%spec.SlotsPerEpoch == 0 { // then we are at the end of the epoch, rewrite state
-			epoch := phase0.Epoch(i / spec.SlotsPerEpoch)
+		if s.chainSpec.IsEpochEnd(i) { // then we are at the end of the epoch, rewrite state
+			epoch := s.chainSpec.EpochAtSlot(i)
 
-			state := s.downloadCache.StateHistory.Wait(EpochTo[uint64](epoch))           // first check that it was already in the cache
+			state, err := s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch)) // first check that it was already in the cache
+			if err != nil {
+				return epochError(s.chainSpec, "reorg handling", epoch, err)
+			}
 			s.processerBook.WaitUntilInactive(fmt.Sprintf("%s%d", epochProcesserTag, i)) // wait until has been processed
 			oldState := *state
-			s.DownloadState(i) // -> inserts into the queue and replaces old block
-			newState := s.downloadCache.StateHistory.Wait(EpochTo[uint64](epoch))
+			err = s.DownloadState(i) // -> inserts into the queue and replaces old block
+			if err != nil {
+				return err
+			}
+			newState, err := s.downloadCache.StateHistory.Wait(s.ctx, EpochTo[uint64](epoch))
+			if err != nil {
+				return epochError(s.chainSpec, "reorg handling", epoch, err)
+			}
 
 			if newState.StateRoot != oldState.StateRoot {
-				s.dbClient.DeleteStateMetrics(epoch)
+				s.deleteStateMetrics(epoch)
 				log.Infof("rewriting metrics for epoch %d", epoch)
 				// write epoch metrics
-				s.ProcessStateTransitionMetrics(epoch)
+				err = s.ProcessStateTransitionMetrics(epoch)
+				if err != nil {
+					return err
+				}
 			}
 		}
 		i -= 1
 	}
+	return nil
+}
+
+// trackForkBlock adds the block to the fork tree, together with the ancestors
+// that were not seen yet, down to the last finalized block
+func (s *ChainAnalyzer) trackForkBlock(root phase0.Root) {
+	s.forkMu.Lock()
+	defer s.forkMu.Unlock()
+
+	finalizedSlot := s.forkTree.finalizedSlot()
+	for root != (phase0.Root{}) && !s.forkTree.contains(root) {
+		header, err := s.cli.RequestBlockHeader(root)
+		if err != nil {
+			log.Errorf("could not track block %s in the fork tree: %s", root, err)
+			return
+		}
+		if header == nil || header.Header == nil {
+			log.Warnf("block %s is unknown to the beacon node, not tracked in the fork tree", root)
+			return
+		}
+		message := header.Header.Message
+		s.forkTree.add(forkNode{
+			root:          root,
+			parentRoot:    message.ParentRoot,
+			slot:          message.Slot,
+			proposerIndex: message.ProposerIndex,
+		})
+		if message.Slot <= finalizedSlot {
+			return
+		}
+		root = message.ParentRoot
+	}
+}
 
+// finalizeForkTree persists the branches that the given finalized block leaves out
+func (s *ChainAnalyzer) finalizeForkTree(finalizedRoot phase0.Root) {
+	s.trackForkBlock(finalizedRoot)
+
+	s.forkMu.Lock()
+	defer s.forkMu.Unlock()
+	orphans := s.forkTree.finalize(finalizedRoot)
+	if len(orphans) > 0 {
+		log.Infof("finalized block %s left %d orphaned blocks", finalizedRoot, len(orphans))
+		s.dbClient.PersistForkOrphans(orphans)
+	}
 }
@@ -8,16 +8,17 @@ import (
 	"github.com/migalabs/goteth/pkg/db"
 	"github.com/migalabs/goteth/pkg/spec"
 	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 var (
-	rateLimit = 5 // limits the number of goroutines per second
+	downloadTaskBuffer = 5 // slots waiting to be downloaded, requests are limited by the API client
 )
 
-func (s *ChainAnalyzer) runDownloadBlocks() {
-	defer s.wgDownload.Done()
+func (s *ChainAnalyzer) runDownloadBlocks() error {
 	log.Info("Launching Beacon Block Requester")
 	ticker := time.NewTicker(utils.RoutineFlushTimeout)
+	defer ticker.Stop()
 
 downloadRoutine:
 	for {
@@ -27,31 +28,53 @@ downloadRoutine:
 		case downloadSlot := <-s.downloadTaskChan: // wait for new head event
 			log.Tracef("received new download signal: %d", downloadSlot)
 
-			go s.DownloadBlockCotrolled(phase0.Slot(downloadSlot))
-			go s.ProcessBlock(downloadSlot)
+			s.eg.Go(func() error { return s.DownloadBlockCotrolled(downloadSlot) })
+			s.eg.Go(func() error { return s.ProcessBlock(downloadSlot) })
 
 			// if epoch boundary, download state
-			if (downloadSlot % spec.SlotsPerEpoch) == (spec.SlotsPerEpoch - 1) { // last slot of epoch
+			if s.chainSpec.IsEpochEnd(downloadSlot) { // last slot of epoch
 				// new epoch
-				go s.DownloadState(downloadSlot)
-				go s.ProcessStateTransitionMetrics(phase0.Epoch(downloadSlot / spec.SlotsPerEpoch))
+				s.eg.Go(func() error { return s.DownloadState(downloadSlot) })
+				s.eg.Go(func() error {
+					return s.ProcessStateTransitionMetrics(s.chainSpec.EpochAtSlot(downloadSlot))
+				})
 			}
+		case <-s.ctx.Done():
+			// a routine failed, the pending tasks are dropped
+			break downloadRoutine
+
 		case <-ticker.C: // every certain amount of time check if need to finish
-			if s.stop && len(s.downloadTaskChan) == 0 && s.cli.ActiveReqNum() == 0 && s.processerBook.ActivePages() == 0 {
+			if s.stop.Load() && len(s.downloadTaskChan) == 0 && s.activeReqs.Load() == 0 && s.processerBook.ActivePages() == 0 {
 				break downloadRoutine
 			}
 		}
 	}
 	log.Infof("Block Download routine finished")
+	return nil
+}
+
+// sendTask queues the slot for download, unless the analyzer is cancelled meanwhile
+func (s *ChainAnalyzer) sendTask(slot phase0.Slot) error {
+	select {
+	case s.downloadTaskChan <- slot:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
 }
 
-func (s *ChainAnalyzer) runHead() {
-	defer s.wgMainRoutine.Done()
+func (s *ChainAnalyzer) runHead() error {
 	log.Info("launching head routine")
-	nextSlotDownload := s.fillToHead()
+	nextSlotDownload, err := s.fillToHead()
+	if err != nil {
+		return err
+	}
 
-	s.downloadCache.BlockHistory.Wait(SlotTo[uint64](nextSlotDownload))
 	// do not continue until fill is done
+	_, err = s.downloadCache.BlockHistory.Wait(s.ctx, SlotTo[uint64](nextSlotDownload))
+	if err != nil {
+		return err
+	}
 
 	log.Infof("Switch to head mode: following chain head")
 
@@ -59,6 +82,9 @@ func (s *ChainAnalyzer) runHead() {
 
 	// -----------------------------------------------------------------------------------
 	s.eventsObj.SubscribeToHeadEvents()
+	if err := s.eventsObj.SubscribeToBlockEvents(); err != nil {
+		return err
+	}
 	s.eventsObj.SubscribeToFinalizedCheckpointEvents()
 	s.eventsObj.SubscribeToReorgsEvents()
 	s.eventsObj.SubscribeToBlobSidecarsEvents()
@@ -72,94 +98,109 @@ func (s *ChainAnalyzer) runHead() {
 			// make the block query
 			log.Tracef("received new head signal: %d", event.HeadEvent.Slot)
 			s.dbClient.PersistHeadEvents([]db.HeadEvent{event})
+			s.eg.Go(func() error { s.trackForkBlock(event.HeadEvent.Block); return nil })
 			for nextSlotDownload <= event.HeadEvent.Slot {
 
 				if s.processerBook.NumFreePages() > 0 {
-					s.downloadTaskChan <- nextSlotDownload
+					if err := s.sendTask(nextSlotDownload); err != nil {
+						return err
+					}
 					nextSlotDownload = nextSlotDownload + 1
 				}
 
 			}
+		case arrival := <-s.eventsObj.BlockChan:
+			s.dbClient.PersistBlockArrivals([]spec.BlockArrival{arrival})
+			s.eg.Go(func() error { s.trackForkBlock(arrival.Root); return nil })
+
 		case newFinalCheckpoint := <-s.eventsObj.FinalizedChan:
 			s.dbClient.PersistFinalized([]v1.FinalizedCheckpointEvent{newFinalCheckpoint})
-			finalizedSlot := phase0.Slot(newFinalCheckpoint.Epoch * spec.SlotsPerEpoch)
+			finalizedSlot := s.chainSpec.EpochStartSlot(newFinalCheckpoint.Epoch)
 
-			go s.AdvanceFinalized(finalizedSlot - (2 * spec.SlotsPerEpoch))
+			s.eg.Go(func() error { return s.AdvanceFinalized(finalizedSlot - s.chainSpec.Slots(2)) })
+			s.eg.Go(func() error { s.finalizeForkTree(newFinalCheckpoint.Block); return nil })
 
 		case newReorg := <-s.eventsObj.ReorgChan:
 			s.dbClient.PersistReorgs([]v1.ChainReorgEvent{newReorg})
-			go s.HandleReorg(newReorg)
+			s.eg.Go(func() error {
+				s.trackForkBlock(newReorg.OldHeadBlock)
+				s.trackForkBlock(newReorg.NewHeadBlock)
+				return nil
+			})
+			s.eg.Go(func() error { return s.HandleReorg(newReorg) })
 
 		case newBlobSidecarEvent := <-s.eventsObj.BlobSidecarChan:
 			s.dbClient.PersistBlobSidecarsEvents([]spec.BlobSideCarEventWraper{newBlobSidecarEvent})
 
 		case <-s.ctx.Done():
 			log.Info("context has died, closing block requester routine")
-			return
+			return nil
 
 		case <-ticker.C:
-			if s.stop {
+			if s.stop.Load() {
 				log.Info("sudden shutdown detected, block downloader routine")
-				return
+				return nil
 			}
 		}
 
 	}
 }
 
-func (s *ChainAnalyzer) fillToHead() phase0.Slot {
+func (s *ChainAnalyzer) fillToHead() (phase0.Slot, error) {
 	// ------ fill from last epoch in database to current head -------
 
 	// obtain current finalized
 	finalizedBlock, err := s.cli.RequestFinalizedBeaconBlock()
 	if err != nil {
-		log.Panicf("could not request the finalized block: %s", err)
+		return 0, errors.Wrap(err, "could not request the finalized block")
 	}
+	// blocks seen from now on are followed back to it
+	s.forkTree.setFinalized(forkNode{
+		root:          finalizedBlock.Root,
+		parentRoot:    finalizedBlock.ParentRoot,
+		slot:          finalizedBlock.Slot,
+		proposerIndex: finalizedBlock.ProposerIndex,
+	})
 
 	// obtain current head
-	headSlot := s.cli.RequestCurrentHead()
-	s.DownloadBlock(headSlot) // inserts in the queue the headblock
-
-	// obtain last slot in database
-	dbHead, err := s.dbClient.RetrieveLastSlot()
+	headSlot, err := s.cli.RequestCurrentHead()
 	if err != nil {
-		log.Fatalf("could not get head block from database: %s", err)
+		return 0, err
 	}
-	nextSlotDownload := spec.FirstSlotInEpoch(dbHead)
-
+	err = s.DownloadBlock(headSlot) // inserts in the queue the headblock
 	if err != nil {
-		log.Errorf("could not obtain last slot in database: %s", err)
+		return 0, err
 	}
-	// if we did not get a last slot from the database, or we were too close to the head
+
+	// the start was fixed before the routines were launched,
+	// the database may already hold slots written by the backfill
+	nextSlotDownload := s.headStartSlot
+
+	// if there is no start slot, or it is too close to the head
 	// then start from the current finalized in the chain
 	if nextSlotDownload == 0 || nextSlotDownload > finalizedBlock.Slot {
-		log.Infof("continue from finalized slot %d, epoch %d", finalizedBlock.Slot, finalizedBlock.Slot/spec.SlotsPerEpoch)
+		log.Infof("continue from finalized slot %d, epoch %d", finalizedBlock.Slot, s.chainSpec.EpochAtSlot(finalizedBlock.Slot))
 		nextSlotDownload = finalizedBlock.Slot
 	} else {
-		// database detected
-		log.Infof("database detected, continue from slot %d, epoch %d", nextSlotDownload, nextSlotDownload/spec.SlotsPerEpoch)
-		nextSlotDownload = nextSlotDownload - (epochsToFinalizedTentative * spec.SlotsPerEpoch) // 2 epochs before
-
+		log.Infof("continue from slot %d, epoch %d", nextSlotDownload, s.chainSpec.EpochAtSlot(nextSlotDownload))
 	}
-	nextSlotDownload = nextSlotDownload / spec.SlotsPerEpoch * spec.SlotsPerEpoch
-	s.initSlot = nextSlotDownload / spec.SlotsPerEpoch * spec.SlotsPerEpoch
+	nextSlotDownload = s.chainSpec.FirstSlotInEpoch(nextSlotDownload)
+	s.initSlot = nextSlotDownload
 
 	log.Infof("filling to head...")
-	s.wgMainRoutine.Add(1) // add because historical will defer it
-	s.runHistorical(nextSlotDownload, headSlot)
-	return headSlot
+	err = s.runHistorical(nextSlotDownload, headSlot)
+	return headSlot, err
 }
 
-func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) {
-	defer s.wgMainRoutine.Done()
+func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) error {
 
 	log.Infof("Switch to historical mode: %d - %d", init, end)
 
 	i := init
 	for i <= end {
-		if s.stop {
+		if s.stopped() {
 			log.Info("sudden shutdown detected, block downloader routine")
-			return
+			return nil
 		}
 		if s.processerBook.NumFreePages() == 0 {
 			log.Debugf("hit limit of concurrent processers")
@@ -167,27 +208,69 @@ func (s *ChainAnalyzer) runHistorical(init phase0.Slot, end phase0.Slot) {
 			<-limitTicker.C // if rate limit, wait for ticker
 			continue
 		}
-		if i%spec.SlotsPerEpoch == 0 { // every time a new epoch is crossed
+		if s.headBusy() {
+			log.Tracef("head routine has pending work, backfill waiting at slot %d", i)
+			limitTicker := time.NewTicker(utils.RoutineFlushTimeout)
+			<-limitTicker.C // head always goes first
+			continue
+		}
+		if uint64(i)%s.chainSpec.SlotsPerEpoch == 0 { // every time a new epoch is crossed
 			finalizedSlot, err := s.cli.RequestFinalizedBeaconBlock()
 
 			if err != nil {
-				log.Fatalf("could not request finalized slot: %s", err)
+				return slotError(s.chainSpec, "finalized block request", i, err)
 			}
 
 			if i >= finalizedSlot.Slot {
 				// keep 2 epochs before finalized, needed to calculate epoch metrics
-				s.AdvanceFinalized(finalizedSlot.Slot - spec.SlotsPerEpoch*5) // includes check and clean
+				err = s.AdvanceFinalized(finalizedSlot.Slot - s.chainSpec.Slots(5)) // includes check and clean
+				if err != nil {
+					return err
+				}
 			} else {
 				// keep 5 epochs before current downloading slot, need 3 at least for epoch metrics
 				// magic number, 2 extra if processer takes long
-				s.downloadCache.CleanUpTo(i - (5 * spec.SlotsPerEpoch)) // only clean, no check, keep
+				s.downloadCache.CleanUpTo(i - s.chainSpec.Slots(5)) // only clean, no check, keep
 			}
 		}
 
-		s.downloadTaskChan <- i
+		if err := s.sendTask(i); err != nil {
+			return err
+		}
 		i += 1
 
 	}
 	log.Infof("historical mode: all download tasks sent")
+	return nil
+}
 
+// runBackfill processes the configured slot range in hybrid mode.
+// It runs on its own cache and processer book, while the head routine keeps priority
+func (s *ChainAnalyzer) runBackfill() error {
+	backfill := s.backfill
+
+	log.Infof("launching backfill routine: %d - %d", backfill.initSlot, backfill.finalSlot)
+
+	backfill.wgDownload.Add(1)
+	s.eg.Go(func() error {
+		defer backfill.wgDownload.Done()
+		return backfill.runDownloadBlocks()
+	})
+
+	err := backfill.runHistorical(backfill.initSlot, backfill.finalSlot)
+
+	backfill.stop.Store(true)
+	backfill.wgDownload.Wait()
+	log.Infof("backfill routine finished")
+	return err
+}
+
+// headBusy returns whether the head analyzer still has work in progress.
+// Only applies to the backfill analyzer in hybrid mode
+func (s *ChainAnalyzer) headBusy() bool {
+	if s.headAnalyzer == nil {
+		return false
+	}
+	head := s.headAnalyzer
+	return len(head.downloadTaskChan) > 0 || head.processerBook.ActivePages() > 0 || head.activeReqs.Load() > 0
 }
@@ -1,6 +1,8 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,12 +12,10 @@ import (
 )
 
 const (
-	ValidatorSetSize           = 500000                 // Estimation of current number of validators, used for channel length declaration
-	maxWorkers                 = 50                     // maximum number of workers allowed in the tool
-	minBlockReqTime            = 100 * time.Millisecond // max 10 queries per second, dont spam beacon node
-	minStateReqTime            = 1 * time.Second        // max 1 query per second, dont spam beacon node
-	epochsToFinalizedTentative = 3                      // usually, 2 full epochs before the head it is finalized
-	dataWaitInterval           = 1 * time.Minute        // wait for block or epoch to be in the cache
+	ValidatorSetSize           = 500000          // Estimation of current number of validators, used for channel length declaration
+	maxWorkers                 = 50              // maximum number of workers allowed in the tool
+	epochsToFinalizedTentative = 3               // usually, 2 full epochs before the head it is finalized
+	dataWaitInterval           = 1 * time.Minute // wait for block or epoch to be in the cache
 )
 
 var (
@@ -48,6 +48,8 @@ type AgnosticMap[T spec.AgnosticBlock |
 
 	setCollisionF func(*T) // extra code we would like to do depending on an existing collision between an existing key and a new one
 	deleteF       func(*T) // extra code we want to run when deleting a key from the map
+
+	store *diskStore[T] // optional, spills the least recently used items to disk
 }
 
 func NewAgnosticMap[T spec.AgnosticBlock |
@@ -82,37 +84,108 @@ func WithDeleteF[T spec.AgnosticBlock |
 	}
 }
 
-func (m *AgnosticMap[T]) Set(key uint64, value *T) {
+func WithDiskStore[T spec.AgnosticBlock |
+	spec.AgnosticState](store *diskStore[T]) AgnosticMapOption[T] {
+	return func(m *AgnosticMap[T]) {
+		m.store = store
+	}
+}
+
+// get returns the item from memory, or from disk if it was spilled.
+// Must be called with the lock held
+func (m *AgnosticMap[T]) get(key uint64) (*T, bool, error) {
+	value, ok := m.m[key]
+	if ok {
+		if m.store != nil {
+			m.store.touch(key)
+		}
+		return value, true, nil
+	}
+	if m.store == nil {
+		return nil, false, nil
+	}
+	if _, ok := m.store.onDisk[key]; !ok {
+		return nil, false, nil
+	}
+
+	value, err := m.store.load(key)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not load %T %d from disk: %s", *new(T), key, err)
+	}
+	m.m[key] = value
+	m.store.promote(key)
+	return value, true, m.evict()
+}
+
+// evict spills the least recently used items over the memory limit.
+// The items that could not be spilled are kept in memory.
+// Must be called with the lock held
+func (m *AgnosticMap[T]) evict() error {
+	if m.store == nil {
+		return nil
+	}
+	for _, key := range m.store.evictKeys() {
+		err := m.store.spill(key, m.m[key])
+		if err != nil {
+			return fmt.Errorf("could not spill %T %d to disk: %s", *new(T), key, err)
+		}
+		delete(m.m, key)
+	}
+	return nil
+}
+
+// Set stores the value and sends it to the subscribers of the key.
+// The value is always kept, the error reports an item that could not be loaded or spilled
+func (m *AgnosticMap[T]) Set(key uint64, value *T) error {
 	m.Lock()
 	defer m.Unlock()
 
-	prevItem, ok := m.m[key]
+	prevItem, ok, err := m.get(key)
 	if ok {
 		m.setCollisionF(prevItem)
 	}
 	m.m[key] = value
+	if m.store != nil {
+		m.store.promote(key)
+		if evictErr := m.evict(); err == nil {
+			err = evictErr
+		}
+	}
 
 	// Send the new value to all waiting subscribers of the key
 	for _, sub := range m.subs[key] {
-		sub <- m.m[key]
+		sub <- value
 	}
 	delete(m.subs, key)
+	return err
 }
 
-func (m *AgnosticMap[T]) Wait(key uint64) *T {
+// Get returns the value for the given key without waiting for it
+func (m *AgnosticMap[T]) Get(key uint64) (*T, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.get(key)
+}
+
+// Wait returns the value for the given key, blocking until it is Set
+// or the context is done
+func (m *AgnosticMap[T]) Wait(ctx context.Context, key uint64) (*T, error) {
 	m.Lock()
 	// Unlock cannot be deferred so we can unblock Set() while waiting
 
-	value, ok := m.m[key]
-	if ok {
+	value, ok, err := m.get(key)
+	if ok || err != nil {
 		m.Unlock()
-		return value
+		return value, err
 	}
 
 	ticker := time.NewTicker(dataWaitInterval)
+	defer ticker.Stop()
 
 	// if there is no value yet, subscribe to any new values for this key
-	ch := make(chan *T)
+	// buffered, so Set never blocks on a subscriber that left
+	ch := make(chan *T, 1)
 	m.subs[key] = append(m.subs[key], ch)
 	m.Unlock()
 
@@ -122,21 +195,44 @@ func (m *AgnosticMap[T]) Wait(key uint64) *T {
 			log.Warnf("Waiting for %T %d...", *new(T), key)
 
 		case item := <-ch:
-			return item
+			return item, nil
+
+		case <-ctx.Done():
+			m.unsubscribe(key, ch)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (m *AgnosticMap[T]) unsubscribe(key uint64, ch chan *T) {
+	m.Lock()
+	defer m.Unlock()
+
+	subs := m.subs[key]
+	for i, sub := range subs {
+		if sub == ch {
+			m.subs[key] = append(subs[:i], subs[i+1:]...)
+			break
 		}
 	}
+	if len(m.subs[key]) == 0 {
+		delete(m.subs, key)
+	}
 }
 
 func (m *AgnosticMap[T]) Delete(key uint64) {
 	m.Lock()
 
-	_, valueExists := m.m[key]
+	valueExists := m.exists(key)
 
 	_, subsExist := m.subs[key]
 
 	if valueExists && !subsExist {
 		delete(m.m, key)
 		delete(m.subs, key)
+		if m.store != nil {
+			m.store.forget(key)
+		}
 	}
 
 	m.Unlock()
@@ -147,11 +243,24 @@ func (m *AgnosticMap[T]) Available(key uint64) bool {
 	m.Lock()
 	// Unlock cannot be deferred so we can unblock Set() while waiting
 
-	_, ok := m.m[key]
+	ok := m.exists(key)
 	m.Unlock()
 	return ok
 }
 
+// exists checks both memory and disk, without loading the item.
+// Must be called with the lock held
+func (m *AgnosticMap[T]) exists(key uint64) bool {
+	if _, ok := m.m[key]; ok {
+		return true
+	}
+	if m.store != nil {
+		_, ok := m.store.onDisk[key]
+		return ok
+	}
+	return false
+}
+
 func (m *AgnosticMap[T]) GetKeyList() []uint64 {
 	m.Lock()
 	// Unlock cannot be deferred so we can unblock Set() while waiting
@@ -160,6 +269,11 @@ func (m *AgnosticMap[T]) GetKeyList() []uint64 {
 	for key := range m.m {
 		result = append(result, key)
 	}
+	if m.store != nil {
+		for key := range m.store.onDisk {
+			result = append(result, key)
+		}
+	}
 
 	m.Unlock()
 	return result
@@ -0,0 +1,351 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/clientapi"
+	"github.com/migalabs/goteth/pkg/config"
+	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/spec/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	verifyEpochsTable     = "t_epoch_metrics_summary"
+	verifyValRewardsTable = "t_validator_rewards_summary"
+)
+
+// VerifyDiff is a stored row that does not match the recomputed one.
+// A whole missing or extra row is reported with an empty Column
+type VerifyDiff struct {
+	Table  string
+	Epoch  phase0.Epoch
+	ValIdx phase0.ValidatorIndex // only for validator rewards
+	db.FieldDiff
+}
+
+// Verifier recomputes the epoch metrics and validator rewards between two epochs
+// and compares them with the stored rows, optionally rewriting the ones that differ
+type Verifier struct {
+	ctx      context.Context
+	cli      *clientapi.APIClient
+	dbClient *db.DBService
+
+	initEpoch  phase0.Epoch
+	finalEpoch phase0.Epoch
+	fix        bool
+	metrics    db.DBMetrics // tables that are rewritten besides epochs, proposer duties and rewards
+	stop       atomic.Bool  // finish after the current epoch
+
+	states map[phase0.Epoch]*spec.AgnosticState // sliding window of the last three states
+
+	// rows deleted by DeleteStateMetrics, waiting to be rewritten.
+	// Except for epochs, keyed by the epoch of nextState at the transition that writes them
+	deletedEpochs            map[phase0.Epoch]bool
+	deletedRewards           map[phase0.Epoch]bool
+	deletedDuties            map[phase0.Epoch]bool
+	deletedAttDuties         map[phase0.Epoch]bool
+	deletedValEvents         map[phase0.Epoch]bool
+	deletedSyncParticipation map[phase0.Epoch]bool
+}
+
+func NewVerifier(ctx context.Context, iConfig config.AnalyzerConfig, fix bool) (*Verifier, error) {
+
+	if iConfig.FinalSlot <= iConfig.InitSlot {
+		return nil, errors.Errorf("Final Slot cannot be greater than Init Slot")
+	}
+
+	if db.IsFileUrl(iConfig.DBUrl) {
+		return nil, errors.Errorf("verify needs to read from a database server")
+	}
+
+	metricsObj, err := db.NewMetrics(iConfig.Metrics)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read metric.")
+	}
+	metricsObj.Transactions = false // receipts are not needed to compute rewards
+
+	dbClient, err := db.New(ctx, iConfig.DBUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate DB Client.")
+	}
+	err = dbClient.Connect()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect DB Client.")
+	}
+
+	cli, err := clientapi.NewAPIClient(ctx,
+		iConfig.BnEndpoint,
+		clientapi.WithDBMetrics(metricsObj))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate API Client.")
+	}
+	dbClient.InitChainSpec(cli.ChainSpec)
+
+	return &Verifier{
+		ctx:                      ctx,
+		cli:                      cli,
+		dbClient:                 dbClient,
+		initEpoch:                cli.ChainSpec.EpochAtSlot(iConfig.InitSlot),
+		finalEpoch:               cli.ChainSpec.EpochAtSlot(iConfig.FinalSlot),
+		fix:                      fix,
+		metrics:                  metricsObj,
+		states:                   make(map[phase0.Epoch]*spec.AgnosticState),
+		deletedEpochs:            make(map[phase0.Epoch]bool),
+		deletedRewards:           make(map[phase0.Epoch]bool),
+		deletedDuties:            make(map[phase0.Epoch]bool),
+		deletedAttDuties:         make(map[phase0.Epoch]bool),
+		deletedValEvents:         make(map[phase0.Epoch]bool),
+		deletedSyncParticipation: make(map[phase0.Epoch]bool),
+	}, nil
+}
+
+// Run goes through every epoch transition in the range, calling onDiff for each difference.
+// The epoch metrics of currentState and the rewards of nextState are checked at each transition
+func (v *Verifier) Run(onDiff func(VerifyDiff)) error {
+	defer v.dbClient.Finish()
+
+	finalEpoch := v.finalEpoch
+	for epoch := v.initEpoch; epoch <= finalEpoch; epoch++ {
+		if v.ctx.Err() != nil {
+			return v.ctx.Err()
+		}
+		pending := len(v.deletedEpochs) + len(v.deletedRewards) + len(v.deletedDuties) +
+			len(v.deletedAttDuties) + len(v.deletedValEvents) + len(v.deletedSyncParticipation)
+		if v.stop.Load() && pending == 0 {
+			log.Infof("verification stopped at epoch %d", epoch)
+			return nil
+		}
+
+		bundle, err := v.bundle(epoch)
+		if err != nil {
+			return epochError(v.cli.ChainSpec, "verify", epoch, err)
+		}
+
+		diffs, err := v.compare(bundle)
+		if err != nil {
+			return epochError(v.cli.ChainSpec, "verify", epoch, err)
+		}
+		for _, diff := range diffs {
+			onDiff(diff)
+		}
+
+		if !v.fix {
+			continue
+		}
+		if len(diffs) > 0 {
+			err = v.dbClient.DeleteStateMetrics(epoch)
+			if err != nil {
+				return epochError(v.cli.ChainSpec, "verify", epoch, err)
+			}
+			if epoch > 0 {
+				v.deletedEpochs[epoch-1] = true
+			}
+			v.deletedEpochs[epoch] = true
+			v.deletedDuties[epoch] = true
+			v.deletedSyncParticipation[epoch] = true
+			for e := epoch; e <= epoch+2; e++ {
+				v.deletedRewards[e] = true
+				v.deletedAttDuties[e] = true // duties of prevState
+			}
+			for e := epoch; e <= epoch+1; e++ {
+				v.deletedValEvents[e] = true
+			}
+			// the following transitions have to rewrite what was deleted
+			if finalEpoch < epoch+2 {
+				finalEpoch = epoch + 2
+			}
+		}
+		err = v.rewrite(bundle)
+		if err != nil {
+			return epochError(v.cli.ChainSpec, "verify", epoch, err)
+		}
+	}
+	return nil
+}
+
+// Close stops the verification once the current epoch is done
+// and every deleted row has been rewritten
+func (v *Verifier) Close() {
+	v.stop.Store(true)
+}
+
+// bundle returns the metrics of the transition to the epoch, downloading the states it is missing
+func (v *Verifier) bundle(epoch phase0.Epoch) (metrics.StateMetrics, error) {
+	prevState := &spec.AgnosticState{}
+	currentState := &spec.AgnosticState{}
+	var err error
+
+	if epoch >= 2 {
+		prevState, err = v.state(epoch - 2)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if epoch >= 1 {
+		currentState, err = v.state(epoch - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	nextState, err := v.state(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	for cached := range v.states {
+		if cached+2 < epoch {
+			delete(v.states, cached)
+		}
+	}
+
+	return metrics.StateMetricsByForkVersion(nextState, currentState, prevState, v.cli.Api)
+}
+
+func (v *Verifier) state(epoch phase0.Epoch) (*spec.AgnosticState, error) {
+	if state, ok := v.states[epoch]; ok {
+		return state, nil
+	}
+
+	startSlot := v.cli.ChainSpec.EpochStartSlot(epoch)
+	endSlot := v.cli.ChainSpec.EpochEndSlot(epoch)
+
+	blocks := make([]*spec.AgnosticBlock, 0, v.cli.ChainSpec.SlotsPerEpoch)
+	for slot := startSlot; slot <= endSlot; slot++ {
+		block, err := v.cli.RequestBeaconBlock(slot)
+		if err != nil {
+			return nil, fmt.Errorf("could not download block at slot %d: %s", slot, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	state, err := v.cli.RequestBeaconState(endSlot)
+	if err != nil {
+		return nil, fmt.Errorf("could not download state at slot %d: %s", endSlot, err)
+	}
+	state.AddBlocks(blocks)
+
+	v.states[epoch] = state
+	return state, nil
+}
+
+// compare returns the differences of the rows written at this transition,
+// skipping the ones deleted by a previous fix
+func (v *Verifier) compare(bundle metrics.StateMetrics) ([]VerifyDiff, error) {
+	diffs := make([]VerifyDiff, 0)
+	base := bundle.GetMetricsBase()
+
+	if !base.CurrentState.EmptyStateRoot() && !v.deletedEpochs[base.CurrentState.Epoch] {
+		epoch := base.CurrentState.Epoch
+		computed := db.NewEpochRow(base.ExportToEpoch())
+		stored, found, err := v.dbClient.RetrieveEpochRow(epoch)
+		if err != nil {
+			return diffs, err
+		}
+		if !found {
+			diffs = append(diffs, VerifyDiff{Table: verifyEpochsTable, Epoch: epoch, FieldDiff: db.FieldDiff{Stored: "missing", Computed: "row"}})
+		} else {
+			for _, diff := range db.DiffRows(stored, computed) {
+				diffs = append(diffs, VerifyDiff{Table: verifyEpochsTable, Epoch: epoch, FieldDiff: diff})
+			}
+		}
+	}
+
+	if !base.PrevState.EmptyStateRoot() && !base.CurrentState.EmptyStateRoot() && !v.deletedRewards[base.NextState.Epoch] {
+		epoch := base.NextState.Epoch
+		storedRows, err := v.dbClient.RetrieveValidatorRewardsRows(epoch)
+		if err != nil {
+			return diffs, err
+		}
+		stored := make(map[phase0.ValidatorIndex]db.ValidatorRewardsRow, len(storedRows))
+		for _, row := range storedRows {
+			stored[phase0.ValidatorIndex(row.F_val_idx)] = row
+		}
+
+		for _, rewards := range validatorRewards(bundle) {
+			computed := db.NewValidatorRewardsRow(rewards, v.cli.ChainSpec)
+			storedRow, found := stored[rewards.ValidatorIndex]
+			if !found {
+				diffs = append(diffs, VerifyDiff{Table: verifyValRewardsTable, Epoch: epoch, ValIdx: rewards.ValidatorIndex, FieldDiff: db.FieldDiff{Stored: "missing", Computed: "row"}})
+				continue
+			}
+			delete(stored, rewards.ValidatorIndex)
+			for _, diff := range db.DiffRows(storedRow, computed) {
+				diffs = append(diffs, VerifyDiff{Table: verifyValRewardsTable, Epoch: epoch, ValIdx: rewards.ValidatorIndex, FieldDiff: diff})
+			}
+		}
+		for valIdx := range stored {
+			diffs = append(diffs, VerifyDiff{Table: verifyValRewardsTable, Epoch: epoch, ValIdx: valIdx, FieldDiff: db.FieldDiff{Stored: "row", Computed: "missing"}})
+		}
+	}
+	return diffs, nil
+}
+
+// rewrite persists again the rows of this transition that were deleted
+func (v *Verifier) rewrite(bundle metrics.StateMetrics) error {
+	base := bundle.GetMetricsBase()
+
+	if epoch := base.CurrentState.Epoch; !base.CurrentState.EmptyStateRoot() && v.deletedEpochs[epoch] {
+		err := v.dbClient.PersistEpochs([]spec.Epoch{base.ExportToEpoch()})
+		if err != nil {
+			return err
+		}
+		delete(v.deletedEpochs, epoch)
+	}
+
+	if epoch := base.NextState.Epoch; v.deletedDuties[epoch] {
+		err := (&dutiesProcessor{dbClient: v.dbClient}).OnEpoch(bundle)
+		if err != nil {
+			return err
+		}
+		delete(v.deletedDuties, epoch)
+	}
+
+	if epoch := base.NextState.Epoch; !base.PrevState.EmptyStateRoot() && !base.CurrentState.EmptyStateRoot() && v.deletedRewards[epoch] {
+		err := v.dbClient.PersistValidatorRewards(validatorRewards(bundle))
+		if err != nil {
+			return err
+		}
+		err = v.dbClient.PersistLedgerEntries([]db.LedgerEntry{db.NewLedgerEntry(db.LedgerRewards, uint64(epoch))})
+		if err != nil {
+			return err
+		}
+		delete(v.deletedRewards, epoch)
+	}
+
+	// the tables below are only written when their metrics are enabled, as the analyzer does
+	if epoch := base.NextState.Epoch; v.deletedValEvents[epoch] {
+		if v.metrics.Epoch {
+			err := (&valEventsProcessor{dbClient: v.dbClient}).OnEpoch(bundle)
+			if err != nil {
+				return err
+			}
+		}
+		delete(v.deletedValEvents, epoch)
+	}
+
+	if epoch := base.NextState.Epoch; v.deletedSyncParticipation[epoch] {
+		if v.metrics.ValidatorRewards && !base.PrevState.EmptyStateRoot() && !base.CurrentState.EmptyStateRoot() {
+			err := persistSyncParticipation(v.dbClient, bundle)
+			if err != nil {
+				return err
+			}
+		}
+		delete(v.deletedSyncParticipation, epoch)
+	}
+
+	if epoch := base.NextState.Epoch; v.deletedAttDuties[epoch] {
+		if v.metrics.AttDuties {
+			err := (&attDutiesProcessor{dbClient: v.dbClient}).OnEpoch(bundle)
+			if err != nil {
+				return err
+			}
+		}
+		delete(v.deletedAttDuties, epoch)
+	}
+	return nil
+}
@@ -3,14 +3,15 @@ package clientapi
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/http"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/migalabs/goteth/pkg/db"
+	"github.com/migalabs/goteth/pkg/era"
 	prom_metrics "github.com/migalabs/goteth/pkg/metrics"
-	"github.com/migalabs/goteth/pkg/utils"
-	"github.com/rs/zerolog"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,50 +19,61 @@ var (
 	moduleName = "api-cli"
 	log        = logrus.WithField(
 		"module", moduleName)
-	QueryTimeout     = 3 * time.Minute
-	maxParallelConns = 3
-	maxRetries       = 3
+	QueryTimeout = 3 * time.Minute
+	maxRetries   = 3
+
+	DefaultStatesRateLimit = RateLimit{RPS: 1, Concurrency: 1}
+	DefaultBlocksRateLimit = RateLimit{RPS: 10, Concurrency: 1}
+	DefaultELRateLimit     = RateLimit{RPS: 0, Concurrency: 3}
 )
 
 type APIClientOption func(*APIClient) error
 
 type APIClient struct {
-	ctx     context.Context
-	Api     *http.Service     // Beacon Node
-	ELApi   *ethclient.Client // Execution Node
-	Metrics db.DBMetrics
-
-	statesBook *utils.RoutineBook // Book to track what is being downloaded through the CL API: states
-	blocksBook *utils.RoutineBook // Book to track what is being downloaded through the CL API: blocks
-	txBook     *utils.RoutineBook // Book to track what is being downloaded through the EL API: transactions
+	ctx        context.Context
+	httpClient *nethttp.Client   // states and blocks are requested directly, to measure and decode them as SSZ
+	Api        *http.Service     // Beacon Node: the healthiest one at start
+	pool       *beaconPool       // every Beacon Node, requests go to the healthiest one
+	ELApi      *ethclient.Client // Execution Node
+	Metrics    db.DBMetrics
+	ChainSpec  *local_spec.ChainSpec // parameters of the chain, read from the beacon node at start
+	era        *era.Archive          // blocks and states read from ERA files instead of the beacon node, when covered
+
+	// The CL limiters are per request type and shared by every beacon node of the pool:
+	// requests are not spread over the nodes, they all go to the healthiest one and only move
+	// to the next one when it fails, so one limiter per type bounds the node serving them.
+	// The concurrency also bounds the memory used by goteth, states being the largest objects.
+	// In adaptive mode, a rate lowered by a failing node is kept for the node taking over,
+	// which ramps back up with its fast answers
+	statesLimiter *rateLimiter // limits and tracks what is being downloaded through the CL API: states
+	blocksLimiter *rateLimiter // limits and tracks what is being downloaded through the CL API: blocks
+	elLimiter     *rateLimiter // limits and tracks what is being downloaded through the EL API: blocks and receipts
 }
 
+// NewAPIClient accepts a comma separated list of beacon node endpoints
 func NewAPIClient(ctx context.Context, bnEndpoint string, options ...APIClientOption) (*APIClient, error) {
 	log.Debugf("generating http client at %s", bnEndpoint)
 
 	apiService := &APIClient{
-		ctx:        ctx,
-		statesBook: utils.NewRoutineBook(1, "api-cli-states"),
-		blocksBook: utils.NewRoutineBook(1, "api-cli-blocks"),
-		txBook:     utils.NewRoutineBook(maxParallelConns, "api-cli-tx"),
+		ctx:           ctx,
+		httpClient:    &nethttp.Client{Timeout: QueryTimeout},
+		statesLimiter: newRateLimiter(ctx, "api-cli-states", DefaultStatesRateLimit, false),
+		blocksLimiter: newRateLimiter(ctx, "api-cli-blocks", DefaultBlocksRateLimit, false),
+		elLimiter:     newRateLimiter(ctx, "api-cli-tx", DefaultELRateLimit, false),
 	}
 
-	bnCli, err := http.New(
-		ctx,
-		http.WithAddress(bnEndpoint),
-		http.WithLogLevel(zerolog.WarnLevel),
-		http.WithTimeout(QueryTimeout),
-	)
+	pool, err := newBeaconPool(ctx, bnEndpoint)
 	if err != nil {
 		return &APIClient{}, err
 	}
+	apiService.pool = pool
+	apiService.Api = pool.best().service()
 
-	hc, ok := bnCli.(*http.Service)
-	if !ok {
-		log.Error("gernerating the http api client")
+	apiService.ChainSpec, err = apiService.RequestChainSpec()
+	if err != nil {
+		return &APIClient{}, fmt.Errorf("could not load the chain spec: %s", err)
 	}
-
-	apiService.Api = hc
+	go pool.runHealthChecks(time.Duration(apiService.ChainSpec.SecondsPerSlot) * time.Second)
 
 	for _, o := range options {
 		err := o(apiService)
@@ -94,18 +106,41 @@ func WithDBMetrics(metrics db.DBMetrics) APIClientOption {
 	}
 }
 
-func WithPromMetrics(metrics *prom_metrics.PrometheusMetrics) APIClientOption {
+// WithEraDir reads the blocks, and the states every SLOTS_PER_HISTORICAL_ROOT slots, from the ERA files in dir
+func WithEraDir(dir string) APIClientOption {
 	return func(s *APIClient) error {
+		if dir == "" {
+			return nil
+		}
+		archive, err := era.NewArchive(dir, s.ChainSpec)
+		if err != nil {
+			return fmt.Errorf("era files not used: %s", err)
+		}
+		s.era = archive
+		return nil
+	}
+}
 
-		metrics.AddMeticsModule(s.statesBook.GetPrometheusMetrics())
-		metrics.AddMeticsModule(s.blocksBook.GetPrometheusMetrics())
-		metrics.AddMeticsModule(s.txBook.GetPrometheusMetrics())
-
+// WithRateLimits replaces the default limits of each request type, the beacon node ones apply to the whole pool.
+// In adaptive mode the configured rates are the ceiling the limiters back off from
+func WithRateLimits(states RateLimit, blocks RateLimit, el RateLimit, adaptive bool) APIClientOption {
+	return func(s *APIClient) error {
+		s.statesLimiter = newRateLimiter(s.ctx, "api-cli-states", states, adaptive)
+		s.blocksLimiter = newRateLimiter(s.ctx, "api-cli-blocks", blocks, adaptive)
+		s.elLimiter = newRateLimiter(s.ctx, "api-cli-tx", el, adaptive)
 		return nil
 	}
 }
 
-func (s APIClient) ActiveReqNum() int {
+func WithPromMetrics(metrics *prom_metrics.PrometheusMetrics) APIClientOption {
+	return func(s *APIClient) error {
+
+		metrics.AddMeticsModule(s.statesLimiter.GetPrometheusMetrics())
+		metrics.AddMeticsModule(s.blocksLimiter.GetPrometheusMetrics())
+		metrics.AddMeticsModule(s.elLimiter.GetPrometheusMetrics())
+		metrics.AddMeticsModule(s.pool.GetPrometheusMetrics())
+		metrics.AddMeticsModule(GetDownloadPrometheusMetrics())
 
-	return s.blocksBook.ActivePages() + s.statesBook.ActivePages() + s.txBook.ActivePages()
+		return nil
+	}
 }
@@ -0,0 +1,388 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/metrics"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+var (
+	healthCheckTimeout = 5 * time.Second
+	errorRateDecay     = 0.9  // weight of the previous error rate on every new request
+	errorRateWeight    = 64.0 // slots of lag a node with every request failing is worth
+	syncingPenalty     = 1000.0
+	unreachableScore   = 1e9
+
+	poolModName    = "beacon_nodes"
+	poolModDetails = "health of every configured beacon node"
+
+	BeaconNodeUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: poolModName,
+		Name:      "up",
+		Help:      "Whether the beacon node answered the last health check",
+	}, []string{"endpoint"})
+	BeaconNodeSyncing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: poolModName,
+		Name:      "syncing",
+		Help:      "Whether the beacon node reported to be syncing",
+	}, []string{"endpoint"})
+	BeaconNodeHeadLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: poolModName,
+		Name:      "head_lag",
+		Help:      "Slots the beacon node head is behind the highest head among all nodes",
+	}, []string{"endpoint"})
+	BeaconNodeErrorRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: poolModName,
+		Name:      "error_rate",
+		Help:      "Exponentially weighted rate of failed requests to the beacon node",
+	}, []string{"endpoint"})
+)
+
+// beaconNode is one of the configured beacon endpoints together with its health
+type beaconNode struct {
+	address string
+
+	mu        sync.Mutex
+	api       *http.Service // nil until the node could be reached once
+	reachable bool
+	syncing   bool
+	headSlot  phase0.Slot
+	headLag   phase0.Slot
+	errorRate float64
+}
+
+// healthy returns whether the node answered the last health check and is not syncing
+func (n *beaconNode) healthy() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.api != nil && n.reachable && !n.syncing
+}
+
+func (n *beaconNode) service() *http.Service {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.api
+}
+
+// score ranks the node, the lower the healthier
+func (n *beaconNode) score() float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.api == nil || !n.reachable {
+		return unreachableScore
+	}
+	score := float64(n.headLag) + n.errorRate*errorRateWeight
+	if n.syncing {
+		score += syncingPenalty
+	}
+	return score
+}
+
+// record updates the error rate with the result of a request
+func (n *beaconNode) record(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+	n.errorRate = n.errorRate*errorRateDecay + failed*(1-errorRateDecay)
+}
+
+// beaconPool keeps every configured beacon node and routes requests to the healthiest one
+type beaconPool struct {
+	ctx   context.Context
+	nodes []*beaconNode
+
+	subsMu sync.Mutex
+	subs   []*eventSubscription // moved to the healthiest node when theirs becomes unhealthy
+}
+
+// eventSubscription is an event stream opened against one of the nodes
+type eventSubscription struct {
+	ctx       context.Context
+	subscribe func(ctx context.Context, api *http.Service) error
+	node      *beaconNode
+	cancel    context.CancelFunc // closes the stream of node
+}
+
+// newBeaconPool connects to the comma separated endpoints, at least one has to be reachable.
+// The periodic health checks are launched with runHealthChecks once the chain spec is known
+func newBeaconPool(ctx context.Context, endpoints string) (*beaconPool, error) {
+	pool := &beaconPool{
+		ctx: ctx,
+	}
+	for _, address := range strings.Split(endpoints, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		pool.nodes = append(pool.nodes, &beaconNode{address: address})
+	}
+	if len(pool.nodes) == 0 {
+		return nil, fmt.Errorf("no beacon node endpoint given")
+	}
+
+	pool.checkHealth()
+	if len(pool.ranked()) == 0 {
+		return nil, fmt.Errorf("none of the beacon nodes could be reached: %s", endpoints)
+	}
+	return pool, nil
+}
+
+func (p *beaconPool) connect(node *beaconNode) error {
+	bnCli, err := http.New(
+		p.ctx,
+		http.WithAddress(node.address),
+		http.WithLogLevel(zerolog.WarnLevel),
+		http.WithTimeout(QueryTimeout),
+	)
+	if err != nil {
+		return err
+	}
+	hc, ok := bnCli.(*http.Service)
+	if !ok {
+		return fmt.Errorf("unexpected client type for %s", node.address)
+	}
+	node.mu.Lock()
+	node.api = hc
+	node.mu.Unlock()
+	return nil
+}
+
+// checkHealth asks every node for its sync status and updates the head lag of each of them
+func (p *beaconPool) checkHealth() {
+	var wg sync.WaitGroup
+	for _, node := range p.nodes {
+		wg.Add(1)
+		go func(node *beaconNode) {
+			defer wg.Done()
+			p.checkNode(node)
+		}(node)
+	}
+	wg.Wait()
+
+	maxHead := phase0.Slot(0)
+	for _, node := range p.nodes {
+		node.mu.Lock()
+		if node.reachable && node.headSlot > maxHead {
+			maxHead = node.headSlot
+		}
+		node.mu.Unlock()
+	}
+	for _, node := range p.nodes {
+		node.mu.Lock()
+		node.headLag = 0
+		if node.headSlot < maxHead {
+			node.headLag = maxHead - node.headSlot
+		}
+		node.mu.Unlock()
+	}
+	p.moveSubscriptions()
+}
+
+func (p *beaconPool) checkNode(node *beaconNode) {
+	if node.service() == nil {
+		err := p.connect(node)
+		if err != nil {
+			log.Warnf("beacon node %s not reachable: %s", node.address, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, healthCheckTimeout)
+	defer cancel()
+	syncState, err := node.service().NodeSyncing(ctx, &api.NodeSyncingOpts{})
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if err != nil {
+		if node.reachable {
+			log.Warnf("beacon node %s failed the health check: %s", node.address, err)
+		}
+		node.reachable = false
+		return
+	}
+	node.reachable = true
+	node.syncing = syncState.Data.IsSyncing
+	node.headSlot = syncState.Data.HeadSlot
+}
+
+// runHealthChecks checks the nodes every interval, usually one slot
+func (p *beaconPool) runHealthChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// ranked returns the nodes that could be connected to, the healthiest first
+func (p *beaconPool) ranked() []*beaconNode {
+	nodes := make([]*beaconNode, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		if node.service() != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].score() < nodes[j].score()
+	})
+	return nodes
+}
+
+// best returns the healthiest node
+func (p *beaconPool) best() *beaconNode {
+	nodes := p.ranked()
+	if len(nodes) == 0 {
+		return p.nodes[0]
+	}
+	return nodes[0]
+}
+
+// withFailover runs the request against the healthiest node first,
+// and against the next ones while it keeps failing
+func (p *beaconPool) withFailover(request func(api *http.Service) error) error {
+	err := fmt.Errorf("no beacon node available")
+	for _, node := range p.ranked() {
+		err = request(node.service())
+		node.record(err)
+		if err == nil || p.ctx.Err() != nil {
+			return err
+		}
+		if len(p.nodes) > 1 {
+			log.Warnf("request to beacon node %s failed, trying the next one: %s", node.address, err)
+		}
+	}
+	return err
+}
+
+// subscribe opens the event stream against the healthiest node, see moveSubscriptions
+func (p *beaconPool) subscribe(ctx context.Context, subscribe func(ctx context.Context, api *http.Service) error) error {
+	sub := &eventSubscription{
+		ctx:       ctx,
+		subscribe: subscribe,
+	}
+	err := p.open(sub, p.best())
+	if err != nil {
+		return err
+	}
+	p.subsMu.Lock()
+	p.subs = append(p.subs, sub)
+	p.subsMu.Unlock()
+	return nil
+}
+
+// open subscribes against the node, closing the stream opened against the previous one
+func (p *beaconPool) open(sub *eventSubscription, node *beaconNode) error {
+	ctx, cancel := context.WithCancel(sub.ctx)
+	err := sub.subscribe(ctx, node.service())
+	if err != nil {
+		cancel()
+		return err
+	}
+	if sub.cancel != nil {
+		sub.cancel()
+	}
+	sub.node = node
+	sub.cancel = cancel
+	return nil
+}
+
+// moveSubscriptions subscribes again through the healthiest node the streams
+// whose node became unhealthy. The events of the switch might be lost
+func (p *beaconPool) moveSubscriptions() {
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+
+	for _, sub := range p.subs {
+		if sub.ctx.Err() != nil || sub.node.healthy() {
+			continue
+		}
+		best := p.best()
+		if best == sub.node || !best.healthy() {
+			continue // no better node to move to
+		}
+		prev := sub.node
+		err := p.open(sub, best)
+		if err != nil {
+			log.Warnf("could not move event subscription from %s to %s: %s", prev.address, best.address, err)
+			continue
+		}
+		log.Warnf("beacon node %s is unhealthy, events are now received from %s", prev.address, best.address)
+	}
+}
+
+func (p *beaconPool) GetPrometheusMetrics() *metrics.MetricsModule {
+	metricsMod := metrics.NewMetricsModule(
+		poolModName,
+		poolModDetails,
+	)
+	metricsMod.AddIndvMetric(p.getNodesHealth())
+	return metricsMod
+}
+
+func (p *beaconPool) getNodesHealth() *metrics.IndvMetrics {
+
+	initFn := func() error {
+		prometheus.MustRegister(BeaconNodeUp)
+		prometheus.MustRegister(BeaconNodeSyncing)
+		prometheus.MustRegister(BeaconNodeHeadLag)
+		prometheus.MustRegister(BeaconNodeErrorRate)
+		return nil
+	}
+
+	updateFn := func() (interface{}, error) {
+		healthy := 0
+		for _, node := range p.nodes {
+			node.mu.Lock()
+			up, syncing := 0.0, 0.0
+			if node.api != nil && node.reachable {
+				up = 1
+				healthy++
+			}
+			if node.syncing {
+				syncing = 1
+			}
+			BeaconNodeUp.WithLabelValues(node.address).Set(up)
+			BeaconNodeSyncing.WithLabelValues(node.address).Set(syncing)
+			BeaconNodeHeadLag.WithLabelValues(node.address).Set(float64(node.headLag))
+			BeaconNodeErrorRate.WithLabelValues(node.address).Set(node.errorRate)
+			node.mu.Unlock()
+		}
+		return healthy, nil
+	}
+
+	indvMetr, err := metrics.NewIndvMetrics(
+		"beacon_nodes_health",
+		initFn,
+		updateFn,
+	)
+	if err != nil {
+		log.Error(errors.Wrap(err, "unable to init beacon_nodes_health"))
+		return nil
+	}
+
+	return indvMetr
+}
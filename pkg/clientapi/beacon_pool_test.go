@@ -0,0 +1,85 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeaconPoolFailover(t *testing.T) {
+	lagging := &beaconNode{address: "lagging", api: &http.Service{}, reachable: true, headLag: 2}
+	failing := &beaconNode{address: "failing", api: &http.Service{}, reachable: true, errorRate: 0.5}
+	syncing := &beaconNode{address: "syncing", api: &http.Service{}, reachable: true, syncing: true}
+	down := &beaconNode{address: "down", api: &http.Service{}}
+	neverConnected := &beaconNode{address: "never-connected"}
+
+	pool := &beaconPool{
+		ctx:   context.Background(),
+		nodes: []*beaconNode{down, syncing, failing, neverConnected, lagging},
+	}
+
+	assert.Equal(t, []*beaconNode{lagging, failing, syncing, down}, pool.ranked())
+	assert.Equal(t, lagging, pool.best())
+
+	// the first node fails, the request falls back to the next one
+	asked := make([]*http.Service, 0)
+	err := pool.withFailover(func(api *http.Service) error {
+		asked = append(asked, api)
+		if len(asked) == 1 {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(asked))
+	assert.True(t, asked[0] == lagging.api)
+	assert.True(t, asked[1] == failing.api)
+	assert.InDelta(t, 0.1, lagging.errorRate, 1e-9)
+	assert.InDelta(t, 0.45, failing.errorRate, 1e-9)
+
+	// every node failing returns the last error
+	err = pool.withFailover(func(api *http.Service) error {
+		return fmt.Errorf("unavailable")
+	})
+	assert.NotNil(t, err)
+}
+
+func TestBeaconPoolMovesSubscriptions(t *testing.T) {
+	first := &beaconNode{address: "first", api: &http.Service{}, reachable: true}
+	second := &beaconNode{address: "second", api: &http.Service{}, reachable: true, headLag: 1}
+
+	pool := &beaconPool{
+		ctx:   context.Background(),
+		nodes: []*beaconNode{first, second},
+	}
+
+	streams := make([]context.Context, 0)
+	subscribed := make([]*http.Service, 0)
+	err := pool.subscribe(context.Background(), func(ctx context.Context, api *http.Service) error {
+		streams = append(streams, ctx)
+		subscribed = append(subscribed, api)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, subscribed[0] == first.api)
+
+	// healthy nodes keep their streams
+	pool.moveSubscriptions()
+	assert.Equal(t, 1, len(subscribed))
+
+	// the stream moves to the next node once the first one stops answering
+	first.reachable = false
+	pool.moveSubscriptions()
+	assert.Equal(t, 2, len(subscribed))
+	assert.True(t, subscribed[1] == second.api)
+	assert.NotNil(t, streams[0].Err())
+	assert.Nil(t, streams[1].Err())
+
+	// without a healthy node to move to, the stream is kept
+	second.syncing = true
+	pool.moveSubscriptions()
+	assert.Equal(t, 2, len(subscribed))
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	local_spec "github.com/migalabs/goteth/pkg/spec"
 )
@@ -12,14 +14,24 @@ func (s *APIClient) RequestBlobSidecars(slot phase0.Slot) ([]*local_spec.Agnosti
 
 	agnosticBlobs := make([]*local_spec.AgnosticBlobSidecar, 0)
 
-	blobsResp, err := s.Api.BlobSidecars(s.ctx, &api.BlobSidecarsOpts{
-		Block: fmt.Sprintf("%d", slot),
+	var blobsResp *api.Response[[]*deneb.BlobSidecar]
+	missing := false
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		blobsResp, reqErr = bnApi.BlobSidecars(s.ctx, &api.BlobSidecarsOpts{
+			Block: fmt.Sprintf("%d", slot),
+		})
+		if reqErr != nil && response404(reqErr.Error()) {
+			missing = true
+			return nil
+		}
+		return reqErr
 	})
 
+	if missing {
+		return agnosticBlobs, nil
+	}
 	if err != nil {
-		if response404(err.Error()) {
-			return agnosticBlobs, nil
-		}
 		return nil, fmt.Errorf("could not retrieve blob sidecars for slot %d: %s", slot, err)
 	}
 
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
@@ -25,26 +27,40 @@ var (
 
 func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBlock, error) {
 	routineKey := fmt.Sprintf("%s%d", slotKeyTag, slot)
-	s.blocksBook.Acquire(routineKey)
-	defer s.blocksBook.FreePage(routineKey)
+	s.blocksLimiter.acquire(routineKey)
+	defer s.blocksLimiter.release(routineKey)
+
+	if s.era != nil && s.era.Covers(slot) {
+		return s.requestEraBlock(slot)
+	}
 
 	log.Debugf("downloading block at slot %d", slot)
 
 	startTime := time.Now()
 	err := errors.New("first attempt")
-	var newBlock *api.Response[*spec.VersionedSignedBeaconBlock]
+	var newBlock *spec.VersionedSignedBeaconBlock
 
 	attempts := 0
 	for err != nil && attempts < maxRetries {
 
-		newBlock, err = s.Api.SignedBeaconBlock(s.ctx, &api.SignedBeaconBlockOpts{
-			Block: fmt.Sprintf("%d", slot),
+		missing := false
+		err = s.pool.withFailover(func(bnApi *http.Service) error {
+			reqErr := s.blocksLimiter.do(func() error {
+				var reqErr error
+				newBlock, reqErr = s.downloadSignedBeaconBlock(bnApi, slot)
+				return reqErr
+			})
+			if reqErr != nil && response404(reqErr.Error()) {
+				missing = true // a valid answer, no need to ask another node
+				return nil
+			}
+			return reqErr
 		})
+		if missing {
+			log.Warnf("the beacon block at slot %d does not exist, missing block", slot)
+			return s.CreateMissingBlock(slot)
+		}
 		if err != nil {
-			if response404(err.Error()) {
-				log.Warnf("the beacon block at slot %d does not exist, missing block", slot)
-				return s.CreateMissingBlock(slot), nil
-			}
 
 			if errors.Is(err, context.DeadlineExceeded) {
 				ticker := time.NewTicker(utils.RoutineFlushTimeout)
@@ -59,13 +75,44 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 		// close the channel (to tell other routines to stop processing and end)
 		return &local_spec.AgnosticBlock{}, fmt.Errorf("unable to retrieve Beacon Block at slot %d: %s", slot, err.Error())
 	}
-	customBlock, err := local_spec.GetCustomBlock(*newBlock.Data)
+	customBlock, err := local_spec.GetCustomBlock(*newBlock, s.ChainSpec)
 
 	if err != nil {
 		// close the channel (to tell other routines to stop processing and end)
 		return &local_spec.AgnosticBlock{}, fmt.Errorf("unable to parse Beacon Block at slot %d: %s", slot, err.Error())
 	}
 
+	customBlock.StateRoot, err = s.RequestStateRoot(slot)
+	if err != nil {
+		return &local_spec.AgnosticBlock{}, err
+	}
+
+	s.completeBlock(&customBlock)
+	log.Infof("block at slot %d downloaded in %f seconds", slot, time.Since(startTime).Seconds())
+
+	return &customBlock, nil
+}
+
+// requestEraBlock reads the block from the ERA archive, missed slots are still built from the beacon node duties
+func (s *APIClient) requestEraBlock(slot phase0.Slot) (*local_spec.AgnosticBlock, error) {
+	startTime := time.Now()
+	customBlock, err := s.era.Block(slot)
+	if err != nil {
+		return &local_spec.AgnosticBlock{}, fmt.Errorf("unable to read Beacon Block at slot %d from the era files: %s", slot, err.Error())
+	}
+	if customBlock == nil {
+		log.Warnf("the beacon block at slot %d does not exist, missing block", slot)
+		return s.CreateMissingBlock(slot)
+	}
+
+	s.completeBlock(customBlock)
+	log.Infof("block at slot %d read from era files in %f seconds", slot, time.Since(startTime).Seconds())
+
+	return customBlock, nil
+}
+
+// completeBlock adds what is not part of the beacon block itself
+func (s *APIClient) completeBlock(customBlock *local_spec.AgnosticBlock) {
 	// fill in block size on custom block using RequestBlockByHash
 	// shows error inside function if ELApi is not defined
 	block, err := s.RequestExecutionBlockByHash(common.Hash(customBlock.ExecutionPayload.BlockHash))
@@ -76,8 +123,7 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 		customBlock.ExecutionPayload.PayloadSize = uint32(block.Size())
 	}
 
-	customBlock.StateRoot = s.RequestStateRoot(slot)
-
+	slot := customBlock.Slot
 	// optional depending on metrics
 	if s.Metrics.APIRewards {
 		reward, err := s.RequestBlockRewards(slot)
@@ -88,52 +134,92 @@ func (s *APIClient) RequestBeaconBlock(slot phase0.Slot) (*local_spec.AgnosticBl
 		customBlock.Reward = reward
 	}
 	if s.Metrics.Transactions {
-		txs, err := s.GetBlockTransactions(customBlock)
+		txs, err := s.GetBlockTransactions(*customBlock)
 		if err != nil {
 			log.Errorf("error getting slot %d transactions: %s", customBlock.Slot, err.Error())
 		}
 		customBlock.ExecutionPayload.AgnosticTransactions = txs
 	}
-	log.Infof("block at slot %d downloaded in %f seconds", slot, time.Since(startTime).Seconds())
-
-	return &customBlock, nil
 }
 
 func (s *APIClient) RequestFinalizedBeaconBlock() (*local_spec.AgnosticBlock, error) {
 
-	finalityCheckpoint, _ := s.Api.Finality(s.ctx, &api.FinalityOpts{
-		State: "head",
-	})
+	finalityCheckpoint, err := s.requestFinality()
+	if err != nil {
+		return &local_spec.AgnosticBlock{}, fmt.Errorf("could not determine the current finalized checkpoint: %s", err)
+	}
 
-	finalizedSlot := finalityCheckpoint.Data.Finalized.Epoch * local_spec.SlotsPerEpoch
+	finalizedSlot := s.ChainSpec.EpochStartSlot(finalityCheckpoint.Data.Finalized.Epoch)
 
-	return s.RequestBeaconBlock(phase0.Slot(finalizedSlot))
+	return s.RequestBeaconBlock(finalizedSlot)
 }
 
-func (s *APIClient) RequestBlockRoot(slot phase0.Slot) phase0.Root {
+func (s *APIClient) RequestBlockRoot(slot phase0.Slot) (phase0.Root, error) {
 
-	root, err := s.Api.BeaconBlockRoot(s.ctx, &api.BeaconBlockRootOpts{
-		Block: fmt.Sprintf("%d", slot),
+	var root *api.Response[*phase0.Root]
+	missing := false
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		root, reqErr = bnApi.BeaconBlockRoot(s.ctx, &api.BeaconBlockRootOpts{
+			Block: fmt.Sprintf("%d", slot),
+		})
+		if reqErr != nil && strings.Contains(reqErr.Error(), "404") {
+			missing = true
+			return nil
+		}
+		return reqErr
 	})
+	if missing {
+		// block was not found => block does not exist
+		return phase0.Root{}, nil
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
-			// block was not found => block does not exist
-			return phase0.Root{}
-		}
-		log.Panicf("could not download the block root at %d: %s", slot, err)
+		return phase0.Root{}, fmt.Errorf("could not download the block root at %d: %s", slot, err)
 	}
 
 	if root == nil { // block root may be empty
-		return phase0.Root{}
+		return phase0.Root{}, nil
+	}
+
+	return *root.Data, nil
+}
+
+// RequestBlockHeader returns the header of the block with the given root,
+// which may not be canonical. It returns nil when the node does not know the block
+func (s *APIClient) RequestBlockHeader(root phase0.Root) (*apiv1.BeaconBlockHeader, error) {
+
+	var header *api.Response[*apiv1.BeaconBlockHeader]
+	missing := false
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		header, reqErr = bnApi.BeaconBlockHeader(s.ctx, &api.BeaconBlockHeaderOpts{
+			Block: root.String(),
+		})
+		if reqErr != nil && strings.Contains(reqErr.Error(), "404") {
+			missing = true
+			return nil
+		}
+		return reqErr
+	})
+	if missing {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not download the block header %s: %s", root, err)
 	}
 
-	return *root.Data
+	return header.Data, nil
 }
 
-func (s *APIClient) CreateMissingBlock(slot phase0.Slot) *local_spec.AgnosticBlock {
-	duties, err := s.Api.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
-		Indices: []phase0.ValidatorIndex{},
-		Epoch:   phase0.Epoch(slot / 32),
+func (s *APIClient) CreateMissingBlock(slot phase0.Slot) (*local_spec.AgnosticBlock, error) {
+	var duties *api.Response[[]*apiv1.ProposerDuty]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		duties, reqErr = bnApi.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
+			Indices: []phase0.ValidatorIndex{},
+			Epoch:   s.ChainSpec.EpochAtSlot(slot),
+		})
+		return reqErr
 	})
 	proposerValIdx := phase0.ValidatorIndex(0)
 	if err != nil {
@@ -146,13 +232,19 @@ func (s *APIClient) CreateMissingBlock(slot phase0.Slot) *local_spec.AgnosticBlo
 		}
 	}
 
+	stateRoot, err := s.RequestStateRoot(slot)
+	if err != nil {
+		return &local_spec.AgnosticBlock{}, err
+	}
+
 	return &local_spec.AgnosticBlock{
 		Slot:              slot,
-		StateRoot:         s.RequestStateRoot(slot),
+		Epoch:             s.ChainSpec.EpochAtSlot(slot),
+		StateRoot:         stateRoot,
 		ProposerIndex:     proposerValIdx,
 		Graffiti:          [32]byte{},
 		Proposed:          false,
-		Attestations:      make([]*phase0.Attestation, 0),
+		Attestations:      make([]*local_spec.AgnosticAttestation, 0),
 		Deposits:          make([]*phase0.Deposit, 0),
 		ProposerSlashings: make([]*phase0.ProposerSlashing, 0),
 		AttesterSlashings: make([]*phase0.AttesterSlashing, 0),
@@ -175,7 +267,7 @@ func (s *APIClient) CreateMissingBlock(slot phase0.Slot) *local_spec.AgnosticBlo
 		SnappySize:        uint32(0),
 		CompressionTime:   0 * time.Second,
 		DecompressionTime: 0 * time.Second,
-	}
+	}, nil
 }
 
 // RequestBlockByHash retrieves block from the execution client for the given hash
@@ -191,24 +283,34 @@ func (s *APIClient) RequestExecutionBlockByHash(hash common.Hash) (*types.Block,
 	}
 
 	routineKey := "block=" + hash.String()
-	s.txBook.Acquire(routineKey)
-	defer s.txBook.FreePage(routineKey)
-
-	block, err := s.ELApi.BlockByHash(s.ctx, hash)
+	s.elLimiter.acquire(routineKey)
+	defer s.elLimiter.release(routineKey)
+
+	var block *types.Block
+	err := s.elLimiter.do(func() error {
+		var reqErr error
+		block, reqErr = s.ELApi.BlockByHash(s.ctx, hash)
+		return reqErr
+	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve block by hash %s: %s", hash.String(), err.Error())
 	}
 	return block, nil
 }
 
-func (s *APIClient) RequestCurrentHead() phase0.Slot {
+func (s *APIClient) RequestCurrentHead() (phase0.Slot, error) {
 
-	head, err := s.Api.BeaconBlockHeader(s.ctx, &api.BeaconBlockHeaderOpts{
-		Block: "head",
+	var head *api.Response[*apiv1.BeaconBlockHeader]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		head, reqErr = bnApi.BeaconBlockHeader(s.ctx, &api.BeaconBlockHeaderOpts{
+			Block: "head",
+		})
+		return reqErr
 	})
 	if err != nil {
-		log.Panicf("could not request current head: %s", err)
+		return 0, fmt.Errorf("could not request current head: %s", err)
 	}
 
-	return head.Data.Header.Message.Slot
+	return head.Data.Header.Message.Slot, nil
 }
@@ -0,0 +1,28 @@
+package clientapi
+
+import (
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/attestantio/go-eth2-client/http"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+)
+
+// RequestChainSpec reads the preset and config of the chain from /eth/v1/config/spec
+func (s *APIClient) RequestChainSpec() (*local_spec.ChainSpec, error) {
+	var config *api.Response[map[string]any]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		config, reqErr = bnApi.Spec(s.ctx, &api.SpecOpts{})
+		return reqErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chainSpec, err := local_spec.ParseChainSpec(config.Data)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("chain spec of %s loaded: %d slots per epoch, %d seconds per slot",
+		chainSpec.ConfigName, chainSpec.SlotsPerEpoch, chainSpec.SecondsPerSlot)
+	return chainSpec, nil
+}
@@ -0,0 +1,209 @@
+package clientapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/metrics"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	contentTypeSSZ  = "application/octet-stream"
+	contentTypeJSON = "application/json"
+	acceptSSZ       = "application/octet-stream;q=1,application/json;q=0.9" // SSZ, JSON if the node does not support it
+
+	encodingSSZ  = "ssz"
+	encodingJSON = "json"
+
+	downloadModName    = "downloads"
+	downloadModDetails = "size and decode time of the downloaded states and blocks"
+
+	DownloadSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: downloadModName,
+		Name:      "size_bytes",
+		Help:      "Size of the downloaded states and blocks",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 12), // 1KiB to 4GiB
+	}, []string{"object", "encoding"})
+	DecodeTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: downloadModName,
+		Name:      "decode_seconds",
+		Help:      "Time spent decoding the downloaded states and blocks",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16), // 1ms to 32s
+	}, []string{"object", "encoding"})
+)
+
+// rawResponse is the body of a state or block request, together with its fork
+type rawResponse struct {
+	data    []byte // SSZ bytes, or the JSON data object
+	size    int
+	isJSON  bool
+	version spec.DataVersion
+}
+
+func (r *rawResponse) encoding() string {
+	if r.isJSON {
+		return encodingJSON
+	}
+	return encodingSSZ
+}
+
+// requestRaw sends a GET to the node preferring SSZ, and asks again for JSON if the node refuses to answer SSZ.
+// A status other than 200 is returned as an error containing the status code
+func (s *APIClient) requestRaw(address string, path string) (*rawResponse, error) {
+	resp, err := s.get(address, path, acceptSSZ)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == nethttp.StatusNotAcceptable || resp.StatusCode == nethttp.StatusUnsupportedMediaType {
+		resp.Body.Close()
+		resp, err = s.get(address, path, contentTypeJSON)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", path, err)
+	}
+	if resp.StatusCode != nethttp.StatusOK {
+		return nil, fmt.Errorf("GET %s failed with status %d: %s", path, resp.StatusCode, body)
+	}
+
+	raw := &rawResponse{
+		data: body,
+		size: len(body),
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected content type of %s: %s", path, err)
+	}
+	switch mediaType {
+	case contentTypeSSZ:
+		raw.version, err = parseVersion(resp.Header.Get("Eth-Consensus-Version"))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected consensus version of %s: %s", path, err)
+		}
+	case contentTypeJSON:
+		raw.isJSON = true
+		var envelope struct {
+			Version string          `json:"version"`
+			Data    json.RawMessage `json:"data"`
+		}
+		err = json.Unmarshal(body, &envelope)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode %s: %s", path, err)
+		}
+		raw.version, err = parseVersion(envelope.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected consensus version of %s: %s", path, err)
+		}
+		raw.data = envelope.Data
+	default:
+		return nil, fmt.Errorf("unhandled content type %s of %s", mediaType, path)
+	}
+	return raw, nil
+}
+
+func (s *APIClient) get(address string, path string, accept string) (*nethttp.Response, error) {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+	req, err := nethttp.NewRequestWithContext(s.ctx, nethttp.MethodGet, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call GET endpoint")
+	}
+	return resp, nil
+}
+
+func parseVersion(version string) (spec.DataVersion, error) {
+	var dataVersion spec.DataVersion
+	err := dataVersion.UnmarshalJSON([]byte(strconv.Quote(strings.ToLower(version))))
+	return dataVersion, err
+}
+
+func observeDownload(object string, raw *rawResponse, decodeTime time.Duration) {
+	DownloadSize.WithLabelValues(object, raw.encoding()).Observe(float64(raw.size))
+	DecodeTime.WithLabelValues(object, raw.encoding()).Observe(decodeTime.Seconds())
+}
+
+// downloadBeaconState requests the state as SSZ, or JSON when the node does not support it
+func (s *APIClient) downloadBeaconState(bnApi *http.Service, slot phase0.Slot) (*spec.VersionedBeaconState, error) {
+	raw, err := s.requestRaw(bnApi.Address(), fmt.Sprintf("/eth/v2/debug/beacon/states/%d", slot))
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	state, err := local_spec.DecodeBeaconState(raw.version, raw.data, raw.isJSON)
+	if err != nil {
+		return nil, err
+	}
+	observeDownload("state", raw, time.Since(startTime))
+	log.Debugf("state at slot %d: %d bytes of %s decoded in %f seconds", slot, raw.size, raw.encoding(), time.Since(startTime).Seconds())
+	return state, nil
+}
+
+// downloadSignedBeaconBlock requests the block as SSZ, or JSON when the node does not support it
+func (s *APIClient) downloadSignedBeaconBlock(bnApi *http.Service, slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, error) {
+	raw, err := s.requestRaw(bnApi.Address(), fmt.Sprintf("/eth/v2/beacon/blocks/%d", slot))
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	block, err := local_spec.DecodeSignedBeaconBlock(raw.version, raw.data, raw.isJSON)
+	if err != nil {
+		return nil, err
+	}
+	observeDownload("block", raw, time.Since(startTime))
+	return block, nil
+}
+
+func GetDownloadPrometheusMetrics() *metrics.MetricsModule {
+	metricsMod := metrics.NewMetricsModule(
+		downloadModName,
+		downloadModDetails,
+	)
+
+	initFn := func() error {
+		prometheus.MustRegister(DownloadSize)
+		prometheus.MustRegister(DecodeTime)
+		return nil
+	}
+	// histograms are observed on every download
+	updateFn := func() (interface{}, error) {
+		return nil, nil
+	}
+
+	indvMetr, err := metrics.NewIndvMetrics(
+		"download_sizes",
+		initFn,
+		updateFn,
+	)
+	if err != nil {
+		log.Error(errors.Wrap(err, "unable to init download_sizes"))
+		return metricsMod
+	}
+	metricsMod.AddIndvMetric(indvMetr)
+	return metricsMod
+}
@@ -0,0 +1,75 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestRaw(t *testing.T) {
+	block := &phase0.SignedBeaconBlock{
+		Message: &phase0.BeaconBlock{
+			Slot:          10,
+			ProposerIndex: 42,
+			Body: &phase0.BeaconBlockBody{
+				ETH1Data:          &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+				ProposerSlashings: []*phase0.ProposerSlashing{},
+				AttesterSlashings: []*phase0.AttesterSlashing{},
+				Attestations:      []*phase0.Attestation{},
+				Deposits:          []*phase0.Deposit{},
+				VoluntaryExits:    []*phase0.SignedVoluntaryExit{},
+			},
+		},
+	}
+	sszBytes, err := block.MarshalSSZ()
+	assert.Nil(t, err)
+	jsonBytes, err := block.MarshalJSON()
+	assert.Nil(t, err)
+
+	supportsSSZ := true
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eth/v2/beacon/blocks/10" {
+			http.Error(w, `{"code":404,"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		if strings.HasPrefix(r.Header.Get("Accept"), contentTypeSSZ) {
+			if !supportsSSZ {
+				http.Error(w, "", http.StatusNotAcceptable)
+				return
+			}
+			w.Header().Set("Content-Type", contentTypeSSZ)
+			w.Header().Set("Eth-Consensus-Version", "phase0")
+			w.Write(sszBytes)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, `{"version":"phase0","data":%s}`, jsonBytes)
+	}))
+	defer node.Close()
+
+	cli := &APIClient{ctx: context.Background(), httpClient: node.Client()}
+
+	for _, ssz := range []bool{true, false} {
+		supportsSSZ = ssz
+		raw, err := cli.requestRaw(node.URL, "/eth/v2/beacon/blocks/10")
+		assert.Nil(t, err)
+		assert.Equal(t, !ssz, raw.isJSON)
+		assert.Equal(t, spec.DataVersionPhase0, raw.version)
+
+		decoded, err := local_spec.DecodeSignedBeaconBlock(raw.version, raw.data, raw.isJSON)
+		assert.Nil(t, err)
+		assert.Equal(t, phase0.ValidatorIndex(42), decoded.Phase0.Message.ProposerIndex)
+	}
+
+	// a missing block keeps the status in the error
+	_, err = cli.requestRaw(node.URL, "/eth/v2/beacon/blocks/11")
+	assert.True(t, response404(err.Error()))
+}
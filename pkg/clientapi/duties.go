@@ -4,14 +4,21 @@ import (
 	"fmt"
 
 	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
 func (s *APIClient) NewEpochData(slot phase0.Slot) spec.EpochDuties {
 
-	epochCommittees, err := s.Api.BeaconCommittees(s.ctx, &api.BeaconCommitteesOpts{
-		State: fmt.Sprintf("%d", slot),
+	var epochCommittees *api.Response[[]*apiv1.BeaconCommittee]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		epochCommittees, reqErr = bnApi.BeaconCommittees(s.ctx, &api.BeaconCommitteesOpts{
+			State: fmt.Sprintf("%d", slot),
+		})
+		return reqErr
 	})
 
 	if err != nil {
@@ -35,8 +42,13 @@ func (s *APIClient) NewEpochData(slot phase0.Slot) spec.EpochDuties {
 		}
 	}
 
-	proposerDuties, err := s.Api.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
-		Epoch: phase0.Epoch(slot / spec.SlotsPerEpoch),
+	var proposerDuties *api.Response[[]*apiv1.ProposerDuty]
+	err = s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		proposerDuties, reqErr = bnApi.ProposerDuties(s.ctx, &api.ProposerDutiesOpts{
+			Epoch: s.ChainSpec.EpochAtSlot(slot),
+		})
+		return reqErr
 	})
 
 	if err != nil {
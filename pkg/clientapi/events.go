@@ -0,0 +1,17 @@
+package clientapi
+
+import (
+	"context"
+
+	client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/http"
+)
+
+// SubscribeEvents sends the events of the given topics to handler until ctx is done.
+// They are received from the healthiest beacon node, and from the next healthiest one
+// whenever it becomes unhealthy
+func (s *APIClient) SubscribeEvents(ctx context.Context, topics []string, handler client.EventHandlerFunc) error {
+	return s.pool.subscribe(ctx, func(ctx context.Context, api *http.Service) error {
+		return api.Events(ctx, topics, handler)
+	})
+}
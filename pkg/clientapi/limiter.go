@@ -0,0 +1,206 @@
+package clientapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/migalabs/goteth/pkg/metrics"
+	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	backoffFactor     = 0.5  // rate kept after an overloaded or slow answer
+	rampUpSteps       = 20.0 // fast answers needed to go from the floor back to the configured rate
+	minRateDivisor    = 16.0 // the adaptive rate never goes below the configured one divided by this
+	slowLatencyFactor = 3.0  // an answer is slow when it takes this times the average latency
+	latencyDecay      = 0.9
+
+	limiterModName = "rate_limits"
+
+	RequestRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: limiterModName,
+		Name:      "requests_per_second",
+		Help:      "Current requests per second allowed to each endpoint, 0 for unlimited",
+	}, []string{"endpoint"})
+)
+
+// RateLimit configures the requests sent to one endpoint
+type RateLimit struct {
+	RPS         float64 // requests per second, 0 for unlimited
+	Concurrency int     // requests in flight at the same time
+}
+
+// rateLimiter is a token bucket in front of one endpoint, with a RoutineBook bounding the requests in flight.
+// In adaptive mode the rate backs off when the node answers 429/503 or slower than usual,
+// and ramps up back to the configured one while answers are fast
+type rateLimiter struct {
+	ctx  context.Context
+	name string
+	book *utils.RoutineBook
+
+	mu         sync.Mutex
+	adaptive   bool
+	maxRate    float64
+	rate       float64
+	tokens     float64
+	last       time.Time
+	avgLatency time.Duration
+}
+
+func newRateLimiter(ctx context.Context, name string, limit RateLimit, adaptive bool) *rateLimiter {
+	concurrency := limit.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &rateLimiter{
+		ctx:      ctx,
+		name:     name,
+		book:     utils.NewRoutineBook(concurrency, name),
+		adaptive: adaptive,
+		maxRate:  limit.RPS,
+		rate:     limit.RPS,
+		tokens:   1,
+		last:     time.Now(),
+	}
+}
+
+// acquire waits for a free page in the book, held while the whole request is processed
+func (l *rateLimiter) acquire(key string) {
+	l.book.Acquire(key)
+}
+
+func (l *rateLimiter) release(key string) {
+	l.book.FreePage(key)
+}
+
+// do waits for a token before sending the request and, in adaptive mode,
+// adjusts the rate with how the node answered
+func (l *rateLimiter) do(request func() error) error {
+	for {
+		wait := l.takeToken()
+		if wait == 0 {
+			break
+		}
+		select {
+		case <-l.ctx.Done():
+			return l.ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	startTime := time.Now()
+	err := request()
+	l.adapt(time.Since(startTime), err)
+	return err
+}
+
+// takeToken returns 0 if a token was taken, or how long to wait for the next one
+func (l *rateLimiter) takeToken() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.last = now
+	burst := l.rate
+	if burst < 1 {
+		burst = 1
+	}
+	if l.tokens > burst {
+		l.tokens = burst
+	}
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// adapt backs off on overloaded or slow answers and ramps up on fast ones
+func (l *rateLimiter) adapt(latency time.Duration, err error) {
+	if !l.adaptive || l.maxRate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slow := l.avgLatency > 0 && float64(latency) > slowLatencyFactor*float64(l.avgLatency)
+	switch {
+	case overloaded(err) || (err == nil && slow):
+		l.rate *= backoffFactor
+		if minRate := l.maxRate / minRateDivisor; l.rate < minRate {
+			l.rate = minRate
+		}
+		log.Debugf("%s: backing off to %.2f requests per second", l.name, l.rate)
+	case err == nil:
+		l.rate += l.maxRate / rampUpSteps
+		if l.rate > l.maxRate {
+			l.rate = l.maxRate
+		}
+	}
+	if err == nil {
+		if l.avgLatency == 0 {
+			l.avgLatency = latency
+		} else {
+			l.avgLatency = time.Duration(latencyDecay*float64(l.avgLatency) + (1-latencyDecay)*float64(latency))
+		}
+	}
+}
+
+func (l *rateLimiter) currentRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// overloaded returns whether the node answered 429 Too Many Requests or 503 Service Unavailable
+func overloaded(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 429 || apiErr.StatusCode == 503
+	}
+	msg := err.Error()
+	for _, answer := range []string{"status 429", "status 503", "Too Many Requests", "Service Unavailable"} {
+		if strings.Contains(msg, answer) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *rateLimiter) GetPrometheusMetrics() *metrics.MetricsModule {
+	metricsMod := l.book.GetPrometheusMetrics()
+	indvMetr, err := metrics.NewIndvMetrics(
+		l.name+"_rate",
+		func() error {
+			// every limiter shares the gauge vector
+			err := prometheus.Register(RequestRate)
+			var registered prometheus.AlreadyRegisteredError
+			if errors.As(err, &registered) {
+				return nil
+			}
+			return err
+		},
+		func() (interface{}, error) {
+			rate := l.currentRate()
+			RequestRate.WithLabelValues(l.name).Set(rate)
+			return rate, nil
+		},
+	)
+	if err != nil {
+		log.Errorf("unable to init %s_rate: %s", l.name, err)
+		return metricsMod
+	}
+	metricsMod.AddIndvMetric(indvMetr)
+	return metricsMod
+}
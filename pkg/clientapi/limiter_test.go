@@ -0,0 +1,54 @@
+package clientapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterTokens(t *testing.T) {
+	limiter := newRateLimiter(context.Background(), "test", RateLimit{RPS: 20, Concurrency: 1}, false)
+
+	// the bucket starts with one token, the next ones come every 50ms
+	startTime := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, limiter.do(func() error { return nil }))
+	}
+	assert.GreaterOrEqual(t, time.Since(startTime), 90*time.Millisecond)
+
+	unlimited := newRateLimiter(context.Background(), "test", RateLimit{}, false)
+	assert.Equal(t, time.Duration(0), unlimited.takeToken())
+}
+
+func TestRateLimiterAdaptive(t *testing.T) {
+	limiter := newRateLimiter(context.Background(), "test", RateLimit{RPS: 16, Concurrency: 1}, true)
+
+	limiter.adapt(10*time.Millisecond, &api.Error{StatusCode: 429})
+	assert.Equal(t, 8.0, limiter.currentRate())
+	limiter.adapt(10*time.Millisecond, fmt.Errorf("GET /eth/v2/beacon/blocks/10 failed with status 503: busy"))
+	assert.Equal(t, 4.0, limiter.currentRate())
+
+	// other errors do not change the rate
+	limiter.adapt(10*time.Millisecond, fmt.Errorf("connection refused"))
+	assert.Equal(t, 4.0, limiter.currentRate())
+
+	// fast answers ramp up, slow ones back off
+	limiter.adapt(10*time.Millisecond, nil)
+	assert.Equal(t, 4.8, limiter.currentRate())
+	limiter.adapt(100*time.Millisecond, nil)
+	assert.Equal(t, 2.4, limiter.currentRate())
+
+	// never below the floor nor above the configured rate
+	for i := 0; i < 10; i++ {
+		limiter.adapt(10*time.Millisecond, &api.Error{StatusCode: 503})
+	}
+	assert.Equal(t, 1.0, limiter.currentRate())
+	for i := 0; i < 100; i++ {
+		limiter.adapt(time.Millisecond, nil)
+	}
+	assert.Equal(t, 16.0, limiter.currentRate())
+}
@@ -12,24 +12,26 @@ import (
 
 func (s *APIClient) RequestBlockRewards(slot phase0.Slot) (spec.BlockRewards, error) {
 
-	uri := s.Api.Address() + "/eth/v1/beacon/rewards/blocks/" + fmt.Sprintf("%d", slot)
+	uri := s.pool.best().address + "/eth/v1/beacon/rewards/blocks/" + fmt.Sprintf("%d", slot)
+	var rewards spec.BlockRewards
+
 	resp, err := http.Get(uri)
 	if err != nil {
-		log.Fatalln(err)
+		return rewards, err
 	}
+	defer resp.Body.Close()
 	//We Read the response body on the line below.
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Fatalln(err)
+		return rewards, err
 	}
 
-	var rewards spec.BlockRewards
 	err = json.Unmarshal(body, &rewards)
 
 	if err != nil {
-		log.Fatalf("error parsing block rewards response: %s", err)
+		return rewards, fmt.Errorf("error parsing block rewards response: %s", err)
 	}
 
-	return rewards, err
+	return rewards, nil
 
 }
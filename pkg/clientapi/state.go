@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/attestantio/go-eth2-client/api"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	local_spec "github.com/migalabs/goteth/pkg/spec"
@@ -20,24 +22,41 @@ var (
 func (s *APIClient) RequestBeaconState(slot phase0.Slot) (*local_spec.AgnosticState, error) {
 
 	routineKey := fmt.Sprintf("%s%d", stateKeyTag, slot)
-	s.statesBook.Acquire(routineKey)
-	defer s.statesBook.FreePage(routineKey)
+	s.statesLimiter.acquire(routineKey)
+	defer s.statesLimiter.release(routineKey)
 
 	startTime := time.Now()
 
+	if s.era != nil && s.era.HasState(slot) {
+		resultState, err := s.era.State(slot, s.NewEpochData(slot))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read Beacon State at slot %d from the era files: %s", slot, err.Error())
+		}
+		log.Infof("state at slot %d read from era files in %f seconds", slot, time.Since(startTime).Seconds())
+		resultState.StateRoot, err = s.RequestStateRoot(slot)
+		if err != nil {
+			return nil, err
+		}
+		return resultState, nil
+	}
+
 	err := errors.New("first attempt")
-	var newState *api.Response[*spec.VersionedBeaconState]
+	var newState *spec.VersionedBeaconState
 
 	attempts := 0
 	for err != nil && attempts < maxRetries {
 
-		newState, err = s.Api.BeaconState(s.ctx, &api.BeaconStateOpts{
-			State: fmt.Sprintf("%d", slot),
+		err = s.pool.withFailover(func(bnApi *http.Service) error {
+			return s.statesLimiter.do(func() error {
+				var reqErr error
+				newState, reqErr = s.downloadBeaconState(bnApi, slot)
+				if reqErr == nil && newState == nil {
+					reqErr = fmt.Errorf("nil State")
+				}
+				return reqErr
+			})
 		})
 
-		if newState == nil {
-			return nil, fmt.Errorf("unable to retrieve Beacon State from the beacon node, closing requester routine. nil State")
-		}
 		if errors.Is(err, context.DeadlineExceeded) {
 			ticker := time.NewTicker(utils.RoutineFlushTimeout)
 			log.Warnf("retrying request: %s", routineKey)
@@ -54,46 +73,64 @@ func (s *APIClient) RequestBeaconState(slot phase0.Slot) (*local_spec.AgnosticSt
 	}
 
 	log.Infof("state at slot %d downloaded in %f seconds", slot, time.Since(startTime).Seconds())
-	resultState, err := local_spec.GetCustomState(*newState.Data, s.NewEpochData(slot))
+	resultState, err := local_spec.GetCustomState(*newState, s.NewEpochData(slot), s.ChainSpec)
 	if err != nil {
 		// close the channel (to tell other routines to stop processing and end)
 		return nil, fmt.Errorf("unable to open beacon state, closing requester routine. %s", err.Error())
 	}
 	// We have used HashTreeRoot method to hash the downloaded state, but it does not work ok
 	// meantime, we use this
-	resultState.StateRoot = s.RequestStateRoot(slot)
+	resultState.StateRoot, err = s.RequestStateRoot(slot)
+	if err != nil {
+		return nil, err
+	}
 
 	return &resultState, nil
 }
 
-func (s *APIClient) RequestStateRoot(slot phase0.Slot) phase0.Root {
+func (s *APIClient) RequestStateRoot(slot phase0.Slot) (phase0.Root, error) {
 
-	root, err := s.Api.BeaconStateRoot(s.ctx, &api.BeaconStateRootOpts{
-		State: fmt.Sprintf("%d", slot),
+	var root *api.Response[*phase0.Root]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		root, reqErr = bnApi.BeaconStateRoot(s.ctx, &api.BeaconStateRootOpts{
+			State: fmt.Sprintf("%d", slot),
+		})
+		return reqErr
 	})
 	if err != nil {
-		log.Panicf("could not download the state root at %d: %s", slot, err)
+		return phase0.Root{}, fmt.Errorf("could not download the state root at %d: %s", slot, err)
 	}
 
-	return *root.Data
+	return *root.Data, nil
 }
 
 // Finalized Checkpoints happen at the beginning of an epoch
 // This method returns the finalized slot at the end of an epoch
 // Usually, it is the slot before the finalized one
-func (s *APIClient) GetFinalizedEndSlotStateRoot() (phase0.Slot, phase0.Root) {
+func (s *APIClient) GetFinalizedEndSlotStateRoot() (phase0.Slot, phase0.Root, error) {
 
-	currentFinalized, err := s.Api.Finality(s.ctx, &api.FinalityOpts{
-		State: "head",
-	})
+	currentFinalized, err := s.requestFinality()
 
 	if err != nil {
-		log.Panicf("could not determine the current finalized checkpoint")
+		return 0, phase0.Root{}, fmt.Errorf("could not determine the current finalized checkpoint: %s", err)
 	}
 
-	finalizedSlot := phase0.Slot(currentFinalized.Data.Finalized.Epoch*local_spec.SlotsPerEpoch - 1)
+	finalizedSlot := s.ChainSpec.EpochStartSlot(currentFinalized.Data.Finalized.Epoch) - 1
 
-	root := s.RequestStateRoot(finalizedSlot)
+	root, err := s.RequestStateRoot(finalizedSlot)
 
-	return finalizedSlot, root
+	return finalizedSlot, root, err
+}
+
+func (s *APIClient) requestFinality() (*api.Response[*apiv1.Finality], error) {
+	var finality *api.Response[*apiv1.Finality]
+	err := s.pool.withFailover(func(bnApi *http.Service) error {
+		var reqErr error
+		finality, reqErr = bnApi.Finality(s.ctx, &api.FinalityOpts{
+			State: "head",
+		})
+		return reqErr
+	})
+	return finality, err
 }
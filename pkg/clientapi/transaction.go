@@ -3,6 +3,7 @@ package clientapi
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
@@ -87,7 +88,14 @@ func (client *APIClient) GetBlockTransactions(block spec.AgnosticBlock) ([]spec.
 	var err error
 
 	if client.ELApi != nil {
-		receipts, err = client.ELApi.BlockReceipts(client.ctx, rpc.BlockNumberOrHashWithNumber(blockNumber))
+		routineKey := fmt.Sprintf("receipts=%d", blockNumber)
+		client.elLimiter.acquire(routineKey)
+		err = client.elLimiter.do(func() error {
+			var reqErr error
+			receipts, reqErr = client.ELApi.BlockReceipts(client.ctx, rpc.BlockNumberOrHashWithNumber(blockNumber))
+			return reqErr
+		})
+		client.elLimiter.release(routineKey)
 	}
 
 	if err != nil {
@@ -17,6 +17,21 @@ type AnalyzerConfig struct {
 	DbWorkerNum    int         `json:"db-worker-num"`
 	Metrics        string      `json:"metrics"`
 	PrometheusPort int         `json:"prometheus-port"`
+	WorkerID       string      `json:"worker-id"`
+	ChunkEpochs    int         `json:"chunk-epochs"`
+	LeaseTimeout   int         `json:"lease-timeout"`
+	CacheDir       string      `json:"cache-dir"`
+	CacheMemStates int         `json:"cache-mem-states"`
+	CacheMemBlocks int         `json:"cache-mem-blocks"`
+	EraDir         string      `json:"era-dir"`
+
+	StatesRPS         float64 `json:"states-rps"`
+	StatesConcurrency int     `json:"states-concurrency"`
+	BlocksRPS         float64 `json:"blocks-rps"`
+	BlocksConcurrency int     `json:"blocks-concurrency"`
+	ElRPS             float64 `json:"el-rps"`
+	ElConcurrency     int     `json:"el-concurrency"`
+	AdaptiveRateLimit bool    `json:"adaptive-rate-limit"`
 }
 
 // TODO: read from config-file
@@ -34,6 +49,21 @@ func NewAnalyzerConfig() *AnalyzerConfig {
 		DbWorkerNum:    DefaultDbWorkerNum,
 		Metrics:        DefaultMetrics,
 		PrometheusPort: DefaultPrometheusPort,
+		WorkerID:       DefaultWorkerID(),
+		ChunkEpochs:    DefaultChunkEpochs,
+		LeaseTimeout:   DefaultLeaseTimeout,
+		CacheDir:       DefaultCacheDir,
+		CacheMemStates: DefaultCacheMemStates,
+		CacheMemBlocks: DefaultCacheMemBlocks,
+		EraDir:         DefaultEraDir,
+
+		StatesRPS:         DefaultStatesRPS,
+		StatesConcurrency: DefaultStatesConcurrency,
+		BlocksRPS:         DefaultBlocksRPS,
+		BlocksConcurrency: DefaultBlocksConcurrency,
+		ElRPS:             DefaultElRPS,
+		ElConcurrency:     DefaultElConcurrency,
+		AdaptiveRateLimit: DefaultAdaptiveRateLimit,
 	}
 }
 
@@ -83,4 +113,54 @@ func (c *AnalyzerConfig) Apply(ctx *cli.Context) {
 	if ctx.IsSet("prometheus-port") {
 		c.PrometheusPort = ctx.Int("prometheus-port")
 	}
+	// worker id
+	if ctx.IsSet("worker-id") {
+		c.WorkerID = ctx.String("worker-id")
+	}
+	// chunk epochs
+	if ctx.IsSet("chunk-epochs") {
+		c.ChunkEpochs = ctx.Int("chunk-epochs")
+	}
+	// lease timeout
+	if ctx.IsSet("lease-timeout") {
+		c.LeaseTimeout = ctx.Int("lease-timeout")
+	}
+	// cache dir
+	if ctx.IsSet("cache-dir") {
+		c.CacheDir = ctx.String("cache-dir")
+	}
+	// states kept in memory
+	if ctx.IsSet("cache-mem-states") {
+		c.CacheMemStates = ctx.Int("cache-mem-states")
+	}
+	// blocks kept in memory
+	if ctx.IsSet("cache-mem-blocks") {
+		c.CacheMemBlocks = ctx.Int("cache-mem-blocks")
+	}
+	// era files
+	if ctx.IsSet("era-dir") {
+		c.EraDir = ctx.String("era-dir")
+	}
+	// rate limits
+	if ctx.IsSet("states-rps") {
+		c.StatesRPS = ctx.Float64("states-rps")
+	}
+	if ctx.IsSet("states-concurrency") {
+		c.StatesConcurrency = ctx.Int("states-concurrency")
+	}
+	if ctx.IsSet("blocks-rps") {
+		c.BlocksRPS = ctx.Float64("blocks-rps")
+	}
+	if ctx.IsSet("blocks-concurrency") {
+		c.BlocksConcurrency = ctx.Int("blocks-concurrency")
+	}
+	if ctx.IsSet("el-rps") {
+		c.ElRPS = ctx.Float64("el-rps")
+	}
+	if ctx.IsSet("el-concurrency") {
+		c.ElConcurrency = ctx.Int("el-concurrency")
+	}
+	if ctx.IsSet("adaptive-rate-limit") {
+		c.AdaptiveRateLimit = ctx.Bool("adaptive-rate-limit")
+	}
 }
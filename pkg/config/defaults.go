@@ -1,5 +1,10 @@
 package config
 
+import (
+	"fmt"
+	"os"
+)
+
 var (
 	DefaultLogLevel              string = "info"
 	DefaultInitSlot              int    = 0
@@ -13,4 +18,30 @@ var (
 	DefaultMetrics               string = "epoch,block"
 	DefaultPrometheusPort        int    = 9080
 	DefaultValidatorWindowEpochs int    = 100
+	DefaultChunkEpochs           int    = 100
+	DefaultLeaseTimeout          int    = 300 // seconds
+	DefaultCacheDir              string = ""  // empty keeps the whole cache in memory
+	DefaultCacheMemStates        int    = 4
+	DefaultCacheMemBlocks        int    = 320
+	DefaultEraDir                string = "" // empty downloads everything from the beacon node
+)
+
+// rate limits of the requests to the nodes
+var (
+	DefaultStatesRPS         float64 = 1
+	DefaultStatesConcurrency int     = 1
+	DefaultBlocksRPS         float64 = 10
+	DefaultBlocksConcurrency int     = 1
+	DefaultElRPS             float64 = 0 // unlimited
+	DefaultElConcurrency     int     = 3
+	DefaultAdaptiveRateLimit bool    = false
 )
+
+// DefaultWorkerID identifies the worker by its hostname and process id
+func DefaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "goteth"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	attDutiesTable       = "t_attestation_duties"
+	insertAttDutiesQuery = `
+	INSERT INTO %s (
+		f_val_idx,
+		f_epoch,
+		f_duty_slot,
+		f_committee_index,
+		f_inclusion_slot,
+		f_inclusion_block_root,
+		f_head_root,
+		f_target_root,
+		f_source_root,
+		f_correct_head,
+		f_correct_target,
+		f_correct_source)
+		VALUES`
+
+	deleteAttDutiesQuery = `
+		DELETE FROM %s
+		WHERE f_epoch = $1;`
+)
+
+func attDutiesInput(duties []spec.AttestationDuty) proto.Input {
+	// one object per column
+	var (
+		f_val_idx              proto.ColUInt64
+		f_epoch                proto.ColUInt64
+		f_duty_slot            proto.ColUInt64
+		f_committee_index      proto.ColUInt64
+		f_inclusion_slot       proto.ColUInt64
+		f_inclusion_block_root proto.ColStr
+		f_head_root            proto.ColStr
+		f_target_root          proto.ColStr
+		f_source_root          proto.ColStr
+		f_correct_head         proto.ColBool
+		f_correct_target       proto.ColBool
+		f_correct_source       proto.ColBool
+	)
+
+	for _, duty := range duties {
+
+		f_val_idx.Append(uint64(duty.ValidatorIndex))
+		f_epoch.Append(uint64(duty.Epoch))
+		f_duty_slot.Append(uint64(duty.DutySlot))
+		f_committee_index.Append(uint64(duty.CommitteeIndex))
+		f_inclusion_slot.Append(uint64(duty.InclusionSlot))
+		f_inclusion_block_root.Append(duty.InclusionRoot.String())
+		f_head_root.Append(duty.HeadRoot.String())
+		f_target_root.Append(duty.TargetRoot.String())
+		f_source_root.Append(duty.SourceRoot.String())
+		f_correct_head.Append(duty.CorrectHead)
+		f_correct_target.Append(duty.CorrectTarget)
+		f_correct_source.Append(duty.CorrectSource)
+	}
+
+	return proto.Input{
+
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_epoch", Data: f_epoch},
+		{Name: "f_duty_slot", Data: f_duty_slot},
+		{Name: "f_committee_index", Data: f_committee_index},
+		{Name: "f_inclusion_slot", Data: f_inclusion_slot},
+		{Name: "f_inclusion_block_root", Data: f_inclusion_block_root},
+		{Name: "f_head_root", Data: f_head_root},
+		{Name: "f_target_root", Data: f_target_root},
+		{Name: "f_source_root", Data: f_source_root},
+		{Name: "f_correct_head", Data: f_correct_head},
+		{Name: "f_correct_target", Data: f_correct_target},
+		{Name: "f_correct_source", Data: f_correct_source},
+	}
+}
+
+func (p *DBService) PersistAttestationDuties(data []spec.AttestationDuty) error {
+	persistObj := PersistableObject[spec.AttestationDuty]{
+		input: attDutiesInput,
+		table: attDutiesTable,
+		query: insertAttDutiesQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting attestation duties: %s", err.Error())
+	}
+	return err
+}
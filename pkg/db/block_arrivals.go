@@ -0,0 +1,69 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	blockArrivalsTable       = "t_block_arrivals"
+	insertBlockArrivalsQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_block_root,
+		f_arrival_timestamp,
+		f_arrival_latency_ms,
+		f_late,
+		f_after_att_deadline)
+		VALUES`
+)
+
+func blockArrivalsInput(arrivals []spec.BlockArrival) proto.Input {
+	// one object per column
+	var (
+		f_slot               proto.ColUInt64
+		f_block_root         proto.ColStr
+		f_arrival_timestamp  proto.ColUInt64
+		f_arrival_latency_ms proto.ColInt64
+		f_late               proto.ColBool
+		f_after_att_deadline proto.ColBool
+	)
+
+	for _, arrival := range arrivals {
+
+		f_slot.Append(uint64(arrival.Slot))
+		f_block_root.Append(arrival.Root.String())
+		f_arrival_timestamp.Append(uint64(arrival.ArrivalTimestamp))
+		f_arrival_latency_ms.Append(arrival.Latency.Milliseconds())
+		f_late.Append(arrival.Late)
+		f_after_att_deadline.Append(arrival.AfterAttestationDeadline)
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_block_root", Data: f_block_root},
+		{Name: "f_arrival_timestamp", Data: f_arrival_timestamp},
+		{Name: "f_arrival_latency_ms", Data: f_arrival_latency_ms},
+		{Name: "f_late", Data: f_late},
+		{Name: "f_after_att_deadline", Data: f_after_att_deadline},
+	}
+}
+
+func (p *DBService) PersistBlockArrivals(data []spec.BlockArrival) error {
+	persistObj := PersistableObject[spec.BlockArrival]{
+		input: blockArrivalsInput,
+		table: blockArrivalsTable,
+		query: insertBlockArrivalsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting block arrivals: %s", err.Error())
+	}
+	return err
+}
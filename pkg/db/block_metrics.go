@@ -37,7 +37,18 @@ var (
 		f_snappy_size_bytes,
 		f_compression_time_ms,
 		f_decompression_time_ms,
-		f_payload_size_bytes)
+		f_payload_size_bytes,
+		f_attestations_redundant,
+		f_attestations_subset,
+		f_unique_new_votes,
+		f_att_reward_captured,
+		f_att_reward_available,
+		f_packing_efficiency,
+		f_bls_to_execution_changes,
+		f_head_vote_misses,
+		f_el_blob_gas_used,
+		f_el_blob_base_fee,
+		f_el_blob_fees)
 		VALUES`
 	selectLastSlotQuery = `
 		SELECT f_slot
@@ -73,16 +84,29 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		f_el_block_hash         proto.ColStr
 		f_el_transactions       proto.ColUInt64
 		f_el_block_number       proto.ColUInt64
+		f_el_blob_gas_used      proto.ColUInt64
+		f_el_blob_base_fee      proto.ColUInt64
+		f_el_blob_fees          proto.ColUInt64
 		f_payload_size_bytes    proto.ColUInt64
 		f_ssz_size_bytes        proto.ColFloat32
 		f_snappy_size_bytes     proto.ColFloat32
 		f_compression_time_ms   proto.ColFloat32
 		f_decompression_time_ms proto.ColFloat32
+
+		f_attestations_redundant proto.ColUInt64
+		f_attestations_subset    proto.ColUInt64
+		f_unique_new_votes       proto.ColUInt64
+		f_att_reward_captured    proto.ColUInt64
+		f_att_reward_available   proto.ColUInt64
+		f_packing_efficiency     proto.ColFloat32
+
+		f_bls_to_execution_changes proto.ColUInt64
+		f_head_vote_misses         proto.ColUInt64
 	)
 
 	for _, block := range blocks {
 		f_timestamp.Append(uint64(block.ExecutionPayload.Timestamp))
-		f_epoch.Append(uint64(block.Slot / spec.SlotsPerEpoch))
+		f_epoch.Append(uint64(block.Epoch))
 		f_slot.Append(uint64(block.Slot))
 
 		graffiti := strings.ToValidUTF8(string(block.Graffiti[:]), "?")
@@ -97,6 +121,7 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		f_attester_slashings.Append(uint64(len(block.AttesterSlashings)))
 		f_voluntary_exits.Append(uint64(len(block.VoluntaryExits)))
 		f_sync_bits.Append(uint64(block.SyncAggregate.SyncCommitteeBits.Count()))
+		f_bls_to_execution_changes.Append(uint64(len(block.BLSToExecutionChanges)))
 
 		// Execution Payload
 		f_el_fee_recp.Append(block.ExecutionPayload.FeeRecipient.String())
@@ -106,6 +131,9 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		f_el_block_hash.Append(block.ExecutionPayload.BlockHash.String())
 		f_el_transactions.Append(uint64(len(block.ExecutionPayload.Transactions)))
 		f_el_block_number.Append(uint64(block.ExecutionPayload.BlockNumber))
+		f_el_blob_gas_used.Append(block.ExecutionPayload.BlobGasUsed)
+		f_el_blob_base_fee.Append(block.ExecutionPayload.BlobBaseFee())
+		f_el_blob_fees.Append(block.ExecutionPayload.BlobFees())
 
 		// Size stats
 		f_payload_size_bytes.Append(uint64(block.ExecutionPayload.PayloadSize))
@@ -114,6 +142,15 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		f_compression_time_ms.Append(float32(utils.DurationToFloat64Millis(block.CompressionTime)))
 		f_decompression_time_ms.Append(float32(utils.DurationToFloat64Millis(block.DecompressionTime)))
 
+		// Attestation packing, zero until the epoch after the block is processed
+		f_attestations_redundant.Append(block.Packing.RedundantAggregates)
+		f_attestations_subset.Append(block.Packing.SubsetAggregates)
+		f_unique_new_votes.Append(block.Packing.UniqueNewVotes)
+		f_att_reward_captured.Append(uint64(block.Packing.CapturedReward))
+		f_att_reward_available.Append(uint64(block.Packing.AvailableReward))
+		f_packing_efficiency.Append(float32(block.Packing.Efficiency()))
+		f_head_vote_misses.Append(block.HeadVoteMisses)
+
 	}
 
 	return proto.Input{
@@ -141,6 +178,17 @@ func blocksInput(blocks []spec.AgnosticBlock) proto.Input {
 		{Name: "f_compression_time_ms", Data: f_compression_time_ms},
 		{Name: "f_decompression_time_ms", Data: f_decompression_time_ms},
 		{Name: "f_payload_size_bytes", Data: f_payload_size_bytes},
+		{Name: "f_attestations_redundant", Data: f_attestations_redundant},
+		{Name: "f_attestations_subset", Data: f_attestations_subset},
+		{Name: "f_unique_new_votes", Data: f_unique_new_votes},
+		{Name: "f_att_reward_captured", Data: f_att_reward_captured},
+		{Name: "f_att_reward_available", Data: f_att_reward_available},
+		{Name: "f_packing_efficiency", Data: f_packing_efficiency},
+		{Name: "f_bls_to_execution_changes", Data: f_bls_to_execution_changes},
+		{Name: "f_head_vote_misses", Data: f_head_vote_misses},
+		{Name: "f_el_blob_gas_used", Data: f_el_blob_gas_used},
+		{Name: "f_el_blob_base_fee", Data: f_el_blob_base_fee},
+		{Name: "f_el_blob_fees", Data: f_el_blob_fees},
 	}
 }
 
@@ -171,6 +219,14 @@ func (s *DBService) DeleteBlockMetrics(slot phase0.Slot) error {
 	if err != nil {
 		return err
 	}
+	err = s.Delete(DeletableObject{
+		query: deleteBLSToExecutionChangesQuery,
+		table: blsToExecutionChangesTable,
+		args:  []any{slot},
+	})
+	if err != nil {
+		return err
+	}
 	err = s.Delete(DeletableObject{
 		query: deleteBlobsQuery,
 		table: blobsTable,
@@ -179,6 +235,18 @@ func (s *DBService) DeleteBlockMetrics(slot phase0.Slot) error {
 	if err != nil {
 		return err
 	}
+	err = s.DeleteExecutionRequests(slot)
+	if err != nil {
+		return err
+	}
+
+	// the slot is no longer processed
+	for _, metric := range []string{LedgerBlock, LedgerTransactions} {
+		err = s.DeleteLedgerEntry(metric, uint64(slot))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	chainSpecTable       = "t_chain_spec"
+	insertChainSpecQuery = `
+	INSERT INTO %s (
+		f_config_name,
+		f_seconds_per_slot,
+		f_slots_per_epoch,
+		f_slots_per_historical_root,
+		f_sync_committee_size,
+		f_epochs_per_sync_committee_period,
+		f_max_effective_balance,
+		f_effective_balance_increment,
+		f_base_reward_factor,
+		f_proposer_reward_quotient,
+		f_whistleblower_reward_quotient,
+		f_min_attestation_inclusion_delay)
+		VALUES`
+
+	selectChainSpecQuery = `
+	SELECT
+		f_config_name,
+		f_seconds_per_slot,
+		f_slots_per_epoch,
+		f_slots_per_historical_root,
+		f_sync_committee_size,
+		f_epochs_per_sync_committee_period,
+		f_max_effective_balance,
+		f_effective_balance_increment,
+		f_base_reward_factor,
+		f_proposer_reward_quotient,
+		f_whistleblower_reward_quotient,
+		f_min_attestation_inclusion_delay
+	FROM %s
+	LIMIT 1;
+`
+)
+
+func chainSpecInput(chainSpecs []spec.ChainSpec) proto.Input {
+	// one object per column
+	var (
+		f_config_name                      proto.ColStr
+		f_seconds_per_slot                 proto.ColUInt64
+		f_slots_per_epoch                  proto.ColUInt64
+		f_slots_per_historical_root        proto.ColUInt64
+		f_sync_committee_size              proto.ColUInt64
+		f_epochs_per_sync_committee_period proto.ColUInt64
+		f_max_effective_balance            proto.ColUInt64
+		f_effective_balance_increment      proto.ColUInt64
+		f_base_reward_factor               proto.ColUInt64
+		f_proposer_reward_quotient         proto.ColUInt64
+		f_whistleblower_reward_quotient    proto.ColUInt64
+		f_min_attestation_inclusion_delay  proto.ColUInt64
+	)
+
+	for _, chainSpec := range chainSpecs {
+		f_config_name.Append(chainSpec.ConfigName)
+		f_seconds_per_slot.Append(chainSpec.SecondsPerSlot)
+		f_slots_per_epoch.Append(chainSpec.SlotsPerEpoch)
+		f_slots_per_historical_root.Append(chainSpec.SlotsPerHistoricalRoot)
+		f_sync_committee_size.Append(chainSpec.SyncCommitteeSize)
+		f_epochs_per_sync_committee_period.Append(chainSpec.EpochsPerSyncCommitteePeriod)
+		f_max_effective_balance.Append(uint64(chainSpec.MaxEffectiveBalance))
+		f_effective_balance_increment.Append(uint64(chainSpec.EffectiveBalanceIncrement))
+		f_base_reward_factor.Append(chainSpec.BaseRewardFactor)
+		f_proposer_reward_quotient.Append(chainSpec.ProposerRewardQuotient)
+		f_whistleblower_reward_quotient.Append(chainSpec.WhistleblowerRewardQuotientPhase0)
+		f_min_attestation_inclusion_delay.Append(chainSpec.MinAttestationInclusionDelay)
+	}
+
+	return proto.Input{
+		{Name: "f_config_name", Data: f_config_name},
+		{Name: "f_seconds_per_slot", Data: f_seconds_per_slot},
+		{Name: "f_slots_per_epoch", Data: f_slots_per_epoch},
+		{Name: "f_slots_per_historical_root", Data: f_slots_per_historical_root},
+		{Name: "f_sync_committee_size", Data: f_sync_committee_size},
+		{Name: "f_epochs_per_sync_committee_period", Data: f_epochs_per_sync_committee_period},
+		{Name: "f_max_effective_balance", Data: f_max_effective_balance},
+		{Name: "f_effective_balance_increment", Data: f_effective_balance_increment},
+		{Name: "f_base_reward_factor", Data: f_base_reward_factor},
+		{Name: "f_proposer_reward_quotient", Data: f_proposer_reward_quotient},
+		{Name: "f_whistleblower_reward_quotient", Data: f_whistleblower_reward_quotient},
+		{Name: "f_min_attestation_inclusion_delay", Data: f_min_attestation_inclusion_delay},
+	}
+}
+
+// RetrieveChainSpec returns the chain spec stored in the database, nil if there is none.
+// The reward weights and fork epochs are not stored, the mainnet ones are returned
+func (p *DBService) RetrieveChainSpec() (*spec.ChainSpec, error) {
+
+	var dest []struct {
+		F_config_name                      string `ch:"f_config_name"`
+		F_seconds_per_slot                 uint64 `ch:"f_seconds_per_slot"`
+		F_slots_per_epoch                  uint64 `ch:"f_slots_per_epoch"`
+		F_slots_per_historical_root        uint64 `ch:"f_slots_per_historical_root"`
+		F_sync_committee_size              uint64 `ch:"f_sync_committee_size"`
+		F_epochs_per_sync_committee_period uint64 `ch:"f_epochs_per_sync_committee_period"`
+		F_max_effective_balance            uint64 `ch:"f_max_effective_balance"`
+		F_effective_balance_increment      uint64 `ch:"f_effective_balance_increment"`
+		F_base_reward_factor               uint64 `ch:"f_base_reward_factor"`
+		F_proposer_reward_quotient         uint64 `ch:"f_proposer_reward_quotient"`
+		F_whistleblower_reward_quotient    uint64 `ch:"f_whistleblower_reward_quotient"`
+		F_min_attestation_inclusion_delay  uint64 `ch:"f_min_attestation_inclusion_delay"`
+	}
+
+	err := p.highSelect(
+		fmt.Sprintf(selectChainSpecQuery, chainSpecTable),
+		&dest)
+
+	if err != nil || len(dest) == 0 {
+		return nil, err
+	}
+
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.ConfigName = dest[0].F_config_name
+	chainSpec.SecondsPerSlot = dest[0].F_seconds_per_slot
+	chainSpec.SlotsPerEpoch = dest[0].F_slots_per_epoch
+	chainSpec.SlotsPerHistoricalRoot = dest[0].F_slots_per_historical_root
+	chainSpec.SyncCommitteeSize = dest[0].F_sync_committee_size
+	chainSpec.EpochsPerSyncCommitteePeriod = dest[0].F_epochs_per_sync_committee_period
+	chainSpec.MaxEffectiveBalance = phase0.Gwei(dest[0].F_max_effective_balance)
+	chainSpec.EffectiveBalanceIncrement = phase0.Gwei(dest[0].F_effective_balance_increment)
+	chainSpec.BaseRewardFactor = dest[0].F_base_reward_factor
+	chainSpec.ProposerRewardQuotient = dest[0].F_proposer_reward_quotient
+	chainSpec.WhistleblowerRewardQuotientPhase0 = dest[0].F_whistleblower_reward_quotient
+	chainSpec.MinAttestationInclusionDelay = dest[0].F_min_attestation_inclusion_delay
+	return chainSpec, nil
+}
+
+// InitChainSpec stores the chain spec the first time, and warns if the stored one is different
+func (p *DBService) InitChainSpec(apiChainSpec *spec.ChainSpec) error {
+	p.chainSpec = apiChainSpec
+
+	dbChainSpec, err := p.RetrieveChainSpec()
+	if err != nil {
+		log.Errorf("could not get chain spec from database: %s", err)
+		return err
+	}
+
+	if dbChainSpec == nil { // table is empty, probably first time use
+		insertChainSpec := PersistableObject[spec.ChainSpec]{
+			input: chainSpecInput,
+			table: chainSpecTable,
+			query: insertChainSpecQuery,
+		}
+		insertChainSpec.Append(*apiChainSpec)
+		err := p.Persist(insertChainSpec.ExportPersist())
+		if err != nil {
+			log.Errorf("could not persist chain spec into the db: %s", err)
+			return err
+		}
+		return nil
+	}
+
+	if dbChainSpec.ConfigName != apiChainSpec.ConfigName ||
+		dbChainSpec.SlotsPerEpoch != apiChainSpec.SlotsPerEpoch ||
+		dbChainSpec.SecondsPerSlot != apiChainSpec.SecondsPerSlot {
+		log.Errorf("the chain spec in the database (%s) does not match the API (%s), is the beacon node in the correct network?",
+			dbChainSpec.ConfigName, apiChainSpec.ConfigName)
+	}
+
+	return nil
+}
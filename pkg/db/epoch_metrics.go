@@ -30,7 +30,15 @@ var (
 		f_num_slashed_vals,
 		f_num_active_vals,
 		f_num_exited_vals,
-		f_num_in_activation_vals)
+		f_num_in_activation_vals,
+		f_justification_bits,
+		f_justified_epoch,
+		f_finalized_epoch,
+		f_epochs_since_finality,
+		f_inactivity_leak,
+		f_source_participation,
+		f_target_participation,
+		f_head_participation)
 		VALUES`
 
 	selectLastEpochQuery = `
@@ -39,6 +47,12 @@ var (
 		ORDER BY f_epoch DESC
 		LIMIT 1`
 
+	selectLastFinalityDistanceQuery = `
+		SELECT f_epochs_since_finality
+		FROM %s
+		ORDER BY f_epoch DESC
+		LIMIT 1`
+
 	deleteEpochsQuery = `
 		DELETE FROM %s
 		WHERE f_epoch = $1;
@@ -67,6 +81,14 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		f_num_active_vals                  proto.ColUInt64
 		f_num_exited_vals                  proto.ColUInt64
 		f_num_in_activation_vals           proto.ColUInt64
+		f_justification_bits               proto.ColUInt8
+		f_justified_epoch                  proto.ColUInt64
+		f_finalized_epoch                  proto.ColUInt64
+		f_epochs_since_finality            proto.ColUInt64
+		f_inactivity_leak                  proto.ColBool
+		f_source_participation             proto.ColFloat32
+		f_target_participation             proto.ColFloat32
+		f_head_participation               proto.ColFloat32
 	)
 
 	for _, epoch := range epochs {
@@ -89,6 +111,14 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		f_num_active_vals.Append(uint64(epoch.NumActiveVals))
 		f_num_exited_vals.Append(uint64(epoch.NumExitedVals))
 		f_num_in_activation_vals.Append(uint64(epoch.NumInActivationVals))
+		f_justification_bits.Append(epoch.JustificationBits)
+		f_justified_epoch.Append(uint64(epoch.JustifiedEpoch))
+		f_finalized_epoch.Append(uint64(epoch.FinalizedEpoch))
+		f_epochs_since_finality.Append(epoch.EpochsSinceFinality)
+		f_inactivity_leak.Append(epoch.InactivityLeak)
+		f_source_participation.Append(epoch.SourceParticipation)
+		f_target_participation.Append(epoch.TargetParticipation)
+		f_head_participation.Append(epoch.HeadParticipation)
 
 	}
 
@@ -112,9 +142,18 @@ func epochsInput(epochs []spec.Epoch) proto.Input {
 		{Name: "f_num_active_vals", Data: f_num_active_vals},
 		{Name: "f_num_exited_vals", Data: f_num_exited_vals},
 		{Name: "f_num_in_activation_vals", Data: f_num_in_activation_vals},
+		{Name: "f_justification_bits", Data: f_justification_bits},
+		{Name: "f_justified_epoch", Data: f_justified_epoch},
+		{Name: "f_finalized_epoch", Data: f_finalized_epoch},
+		{Name: "f_epochs_since_finality", Data: f_epochs_since_finality},
+		{Name: "f_inactivity_leak", Data: f_inactivity_leak},
+		{Name: "f_source_participation", Data: f_source_participation},
+		{Name: "f_target_participation", Data: f_target_participation},
+		{Name: "f_head_participation", Data: f_head_participation},
 	}
 }
 
+// PersistEpochs writes the epochs and then records them in the progress ledger
 func (p *DBService) PersistEpochs(data []spec.Epoch) error {
 	persistObj := PersistableObject[spec.Epoch]{
 		input: epochsInput,
@@ -122,15 +161,18 @@ func (p *DBService) PersistEpochs(data []spec.Epoch) error {
 		query: insertEpochQuery,
 	}
 
+	epochs := make([]uint64, 0, len(data))
 	for _, item := range data {
 		persistObj.Append(item)
+		epochs = append(epochs, uint64(item.Epoch))
 	}
 
 	err := p.Persist(persistObj.ExportPersist())
 	if err != nil {
 		log.Errorf("error persisting epoch: %s", err.Error())
+		return err
 	}
-	return err
+	return p.persistProgress(LedgerEpoch, epochs)
 }
 
 func (p *DBService) RetrieveLastEpoch() (phase0.Epoch, error) {
@@ -150,6 +192,23 @@ func (p *DBService) RetrieveLastEpoch() (phase0.Epoch, error) {
 
 }
 
+// RetrieveLastFinalityDistance returns the epochs since finality of the last epoch persisted
+func (p *DBService) RetrieveLastFinalityDistance() (uint64, error) {
+
+	var dest []struct {
+		F_epochs_since_finality uint64 `ch:"f_epochs_since_finality"`
+	}
+
+	err := p.highSelect(
+		fmt.Sprintf(selectLastFinalityDistanceQuery, epochsTable),
+		&dest)
+
+	if len(dest) > 0 {
+		return dest[0].F_epochs_since_finality, err
+	}
+	return 0, err
+}
+
 // delete metrics that use the state at epoch x
 func (s *DBService) DeleteStateMetrics(epoch phase0.Epoch) error {
 	var err error
@@ -177,7 +236,7 @@ func (s *DBService) DeleteStateMetrics(epoch phase0.Epoch) error {
 	err = s.Delete(DeletableObject{
 		query: deleteProposerDutiesQuery,
 		table: proposerDutiesTable,
-		args:  []any{epoch},
+		args:  []any{s.chainSpec.EpochStartSlot(epoch), s.chainSpec.EpochEndSlot(epoch)},
 	})
 	if err != nil {
 		return err
@@ -208,6 +267,56 @@ func (s *DBService) DeleteStateMetrics(epoch phase0.Epoch) error {
 	if err != nil {
 		return err
 	}
+	// attestation duties of prevState are written at nextState, like the rewards
+	for _, dutyEpoch := range []phase0.Epoch{epoch - 2, epoch - 1, epoch} {
+		if dutyEpoch > epoch {
+			continue // before genesis
+		}
+		err = s.Delete(DeletableObject{
+			query: deleteAttDutiesQuery,
+			table: attDutiesTable,
+			args:  []any{dutyEpoch},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// validator events are written at nextState comparing it with currentState
+	for _, e := range []phase0.Epoch{epoch, epoch + 1} {
+		err = s.Delete(DeletableObject{
+			query: deleteValEventsQuery,
+			table: valEventsTable,
+			args:  []any{e},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// sync committee participation is written with the rewards, using the blocks of nextState
+	err = s.Delete(DeletableObject{
+		query: deleteSyncParticipationQuery,
+		table: syncParticipationTable,
+		args:  []any{s.chainSpec.EpochStartSlot(epoch), s.chainSpec.EpochEndSlot(epoch)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the ledger follows the epochs written above
+	for _, e := range []phase0.Epoch{epoch - 1, epoch} {
+		err = s.DeleteLedgerEntry(LedgerEpoch, uint64(e))
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range []phase0.Epoch{epoch, epoch + 1, epoch + 2} {
+		err = s.DeleteLedgerEntry(LedgerRewards, uint64(e))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 
 }
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	depositRequestsTable       = "t_deposit_requests"
+	insertDepositRequestsQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_index,
+		f_pubkey,
+		f_withdrawal_credentials,
+		f_amount,
+		f_signature)
+		VALUES`
+
+	withdrawalRequestsTable       = "t_withdrawal_requests"
+	insertWithdrawalRequestsQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_source_address,
+		f_validator_pubkey,
+		f_amount)
+		VALUES`
+
+	consolidationRequestsTable       = "t_consolidation_requests"
+	insertConsolidationRequestsQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_source_address,
+		f_source_pubkey,
+		f_target_pubkey)
+		VALUES`
+
+	// the three tables are keyed by slot
+	deleteExecutionRequestsQuery = `
+		DELETE FROM %s
+		WHERE f_slot = $1;`
+)
+
+func depositRequestsInput(requests []spec.DepositRequest) proto.Input {
+	// one object per column
+	var (
+		f_slot                   proto.ColUInt64
+		f_index                  proto.ColUInt64
+		f_pubkey                 proto.ColStr
+		f_withdrawal_credentials proto.ColStr
+		f_amount                 proto.ColUInt64
+		f_signature              proto.ColStr
+	)
+
+	for _, request := range requests {
+
+		f_slot.Append(uint64(request.Slot))
+		f_index.Append(request.Index)
+		f_pubkey.Append(request.Pubkey.String())
+		f_withdrawal_credentials.Append(fmt.Sprintf("%#x", request.WithdrawalCredentials))
+		f_amount.Append(uint64(request.Amount))
+		f_signature.Append(request.Signature.String())
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_index", Data: f_index},
+		{Name: "f_pubkey", Data: f_pubkey},
+		{Name: "f_withdrawal_credentials", Data: f_withdrawal_credentials},
+		{Name: "f_amount", Data: f_amount},
+		{Name: "f_signature", Data: f_signature},
+	}
+}
+
+func withdrawalRequestsInput(requests []spec.WithdrawalRequest) proto.Input {
+	// one object per column
+	var (
+		f_slot             proto.ColUInt64
+		f_source_address   proto.ColStr
+		f_validator_pubkey proto.ColStr
+		f_amount           proto.ColUInt64
+	)
+
+	for _, request := range requests {
+
+		f_slot.Append(uint64(request.Slot))
+		f_source_address.Append(request.SourceAddress.String())
+		f_validator_pubkey.Append(request.ValidatorPubkey.String())
+		f_amount.Append(uint64(request.Amount))
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_source_address", Data: f_source_address},
+		{Name: "f_validator_pubkey", Data: f_validator_pubkey},
+		{Name: "f_amount", Data: f_amount},
+	}
+}
+
+func consolidationRequestsInput(requests []spec.ConsolidationRequest) proto.Input {
+	// one object per column
+	var (
+		f_slot           proto.ColUInt64
+		f_source_address proto.ColStr
+		f_source_pubkey  proto.ColStr
+		f_target_pubkey  proto.ColStr
+	)
+
+	for _, request := range requests {
+
+		f_slot.Append(uint64(request.Slot))
+		f_source_address.Append(request.SourceAddress.String())
+		f_source_pubkey.Append(request.SourcePubkey.String())
+		f_target_pubkey.Append(request.TargetPubkey.String())
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_source_address", Data: f_source_address},
+		{Name: "f_source_pubkey", Data: f_source_pubkey},
+		{Name: "f_target_pubkey", Data: f_target_pubkey},
+	}
+}
+
+func (p *DBService) PersistDepositRequests(data []spec.DepositRequest) error {
+	persistObj := PersistableObject[spec.DepositRequest]{
+		input: depositRequestsInput,
+		table: depositRequestsTable,
+		query: insertDepositRequestsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting deposit requests: %s", err.Error())
+	}
+	return err
+}
+
+func (p *DBService) PersistWithdrawalRequests(data []spec.WithdrawalRequest) error {
+	persistObj := PersistableObject[spec.WithdrawalRequest]{
+		input: withdrawalRequestsInput,
+		table: withdrawalRequestsTable,
+		query: insertWithdrawalRequestsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting withdrawal requests: %s", err.Error())
+	}
+	return err
+}
+
+func (p *DBService) PersistConsolidationRequests(data []spec.ConsolidationRequest) error {
+	persistObj := PersistableObject[spec.ConsolidationRequest]{
+		input: consolidationRequestsInput,
+		table: consolidationRequestsTable,
+		query: insertConsolidationRequestsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting consolidation requests: %s", err.Error())
+	}
+	return err
+}
+
+// DeleteExecutionRequests removes the requests of the three tables included at the slot
+func (p *DBService) DeleteExecutionRequests(slot phase0.Slot) error {
+	for _, table := range []string{depositRequestsTable, withdrawalRequestsTable, consolidationRequestsTable} {
+		err := p.Delete(DeletableObject{
+			query: deleteExecutionRequestsQuery,
+			table: table,
+			args:  []any{slot},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	forkOrphansTable       = "t_fork_orphans"
+	insertForkOrphansQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_block_root,
+		f_parent_root,
+		f_proposer_index,
+		f_depth,
+		f_split_slot)
+		VALUES`
+)
+
+func forkOrphansInput(orphans []spec.ForkOrphan) proto.Input {
+	// one object per column
+	var (
+		f_slot           proto.ColUInt64
+		f_block_root     proto.ColStr
+		f_parent_root    proto.ColStr
+		f_proposer_index proto.ColUInt64
+		f_depth          proto.ColUInt64
+		f_split_slot     proto.ColUInt64
+	)
+
+	for _, orphan := range orphans {
+
+		f_slot.Append(uint64(orphan.Slot))
+		f_block_root.Append(orphan.Root.String())
+		f_parent_root.Append(orphan.ParentRoot.String())
+		f_proposer_index.Append(uint64(orphan.ProposerIndex))
+		f_depth.Append(orphan.Depth)
+		f_split_slot.Append(uint64(orphan.SplitSlot))
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_block_root", Data: f_block_root},
+		{Name: "f_parent_root", Data: f_parent_root},
+		{Name: "f_proposer_index", Data: f_proposer_index},
+		{Name: "f_depth", Data: f_depth},
+		{Name: "f_split_slot", Data: f_split_slot},
+	}
+}
+
+func (p *DBService) PersistForkOrphans(data []spec.ForkOrphan) error {
+	persistObj := PersistableObject[spec.ForkOrphan]{
+		input: forkOrphansInput,
+		table: forkOrphansTable,
+		query: insertForkOrphansQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting fork orphans: %s", err.Error())
+	}
+	return err
+}
@@ -88,6 +88,9 @@ func ParseChUrlIntoOptionsHighLevel(url string) clickhouse.Options {
 }
 
 func (p *DBService) Delete(obj DeletableObject) error {
+	if !p.hasServer() {
+		return nil // files are append only
+	}
 
 	var err error
 	startTime := time.Now()
@@ -104,6 +107,9 @@ func (p *DBService) Delete(obj DeletableObject) error {
 }
 
 func (p *DBService) highSelect(query string, dest interface{}) error {
+	if !p.hasServer() {
+		return nil // nothing stored to read from
+	}
 	startTime := time.Now()
 	p.highMu.Lock()
 	err := p.highLevelClient.Select(p.ctx, dest, query)
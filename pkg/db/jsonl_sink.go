@@ -0,0 +1,134 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+var (
+	stdoutPath = "-"
+)
+
+// jsonlSink writes every row as a JSON object with one key per column.
+// Each table goes to <dir>/<table>.jsonl, or everything to stdout
+// with an extra "table" key when dir is "-"
+type jsonlSink struct {
+	dir     string
+	stdout  *bufio.Writer
+	files   map[string]*os.File
+	writers map[string]*bufio.Writer
+}
+
+// NewJSONLSink parses a file:///path url, use file://- to write to stdout
+func NewJSONLSink(url string) (*jsonlSink, error) {
+	dir := strings.TrimPrefix(url, fileUrlPrefix)
+	if dir == "" {
+		return nil, fmt.Errorf("no path given in %s", url)
+	}
+
+	sink := &jsonlSink{
+		dir:     dir,
+		files:   make(map[string]*os.File),
+		writers: make(map[string]*bufio.Writer),
+	}
+	if dir == stdoutPath {
+		sink.stdout = bufio.NewWriter(os.Stdout)
+		return sink, nil
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *jsonlSink) writer(table string) (*bufio.Writer, error) {
+	if s.stdout != nil {
+		return s.stdout, nil
+	}
+	if writer, ok := s.writers[table]; ok {
+		return writer, nil
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, table+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.files[table] = file
+	s.writers[table] = bufio.NewWriter(file)
+	return s.writers[table], nil
+}
+
+func (s *jsonlSink) Write(ctx context.Context, query string, table string, input proto.Input, rows int) error {
+	writer, err := s.writer(table)
+	if err != nil {
+		return err
+	}
+
+	var line bytes.Buffer
+	for i := 0; i < rows; i++ {
+		line.Reset()
+		line.WriteByte('{')
+		if s.stdout != nil {
+			fmt.Fprintf(&line, "\"table\":%q,", table)
+		}
+		for j, column := range input {
+			value, err := columnRow(column.Data, i)
+			if err != nil {
+				return fmt.Errorf("column %s of %s: %s", column.Name, table, err)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("column %s of %s: %s", column.Name, table, err)
+			}
+			if j > 0 {
+				line.WriteByte(',')
+			}
+			fmt.Fprintf(&line, "%q:", column.Name)
+			line.Write(encoded)
+		}
+		line.WriteString("}\n")
+
+		_, err = writer.Write(line.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+	// rows are persisted once Persist returns
+	return writer.Flush()
+}
+
+func (s *jsonlSink) Close() error {
+	if s.stdout != nil {
+		return s.stdout.Flush()
+	}
+	for table, file := range s.files {
+		err := s.writers[table].Flush()
+		if err != nil {
+			return err
+		}
+		err = file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnRow reads the i-th value of any ch-go column, they all implement Row(i int) T
+func columnRow(column proto.ColInput, i int) (any, error) {
+	method := reflect.ValueOf(column).MethodByName("Row")
+	if !method.IsValid() {
+		return nil, fmt.Errorf("unsupported column type %T", column)
+	}
+	return method.Call([]reflect.Value{reflect.ValueOf(i)})[0].Interface(), nil
+}
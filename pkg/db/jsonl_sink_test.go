@@ -0,0 +1,56 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+
+	dbClient, err := New(context.Background(), "file://"+dir)
+	assert.Nil(t, err)
+	assert.Nil(t, dbClient.Connect())
+
+	err = dbClient.PersistWithdrawals([]spec.Withdrawal{
+		{Slot: 100, Index: 1, ValidatorIndex: 20, Amount: 300},
+		{Slot: 100, Index: 2, ValidatorIndex: 21, Amount: 400},
+	})
+	assert.Nil(t, err)
+	err = dbClient.PersistBlockRewards([]BlockReward{{Slot: 100, Relays: []string{"relay"}}})
+	assert.Nil(t, err)
+
+	// reads and deletes are no-ops without a server
+	assert.Nil(t, dbClient.DeleteBlockMetrics(phase0.Slot(100)))
+	slot, err := dbClient.RetrieveLastSlot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(0), slot)
+	dbClient.Finish()
+
+	file, err := os.Open(filepath.Join(dir, withdrawalsTable+".jsonl"))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	rows := make([]map[string]any, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		row := make(map[string]any)
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, float64(21), rows[1]["f_val_idx"])
+	assert.Equal(t, float64(400), rows[1]["f_amount"])
+
+	content, err := os.ReadFile(filepath.Join(dir, blockRewardsTable+".jsonl"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"f_relays":["relay"]`)
+}
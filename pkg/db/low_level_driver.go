@@ -16,6 +16,7 @@ func (s *DBService) ConnectLowLevel() error {
 	lowLevelConn, err := ch.Dial(ctx, opts)
 	if err == nil {
 		s.lowLevelClient = lowLevelConn
+		s.sink = &clickhouseSink{client: lowLevelConn}
 		err = s.makeMigrations()
 	}
 
@@ -63,10 +64,7 @@ func (p *DBService) Persist(
 	startTime := time.Now()
 
 	p.lowMu.Lock()
-	err := p.lowLevelClient.Do(p.ctx, ch.Query{
-		Body:  query,
-		Input: input,
-	})
+	err := p.sink.Write(p.ctx, query, table, input, rows)
 	p.lowMu.Unlock()
 	elapsedTime := time.Since(startTime)
 
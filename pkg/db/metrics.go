@@ -11,6 +11,8 @@ type DBMetrics struct {
 	ValidatorRewards bool
 	APIRewards       bool
 	Transactions     bool
+	AttDuties        bool
+	Processors       []string // custom processors, resolved by the analyzer
 }
 
 func NewMetrics(input string) (DBMetrics, error) {
@@ -30,11 +32,18 @@ func NewMetrics(input string) (DBMetrics, error) {
 			dbMetrics.Block = true
 		case "api_rewards":
 			dbMetrics.APIRewards = true
+		case "attestations":
+			dbMetrics.AttDuties = true
+			dbMetrics.Epoch = true
+			dbMetrics.Block = true
 		case "transactions":
 			dbMetrics.Transactions = true
 			dbMetrics.Block = true
-		default:
+		case "":
 			return DBMetrics{}, fmt.Errorf("could not parse metric: %s", item)
+		default:
+			// might be a custom processor, the analyzer checks it was registered
+			dbMetrics.Processors = append(dbMetrics.Processors, item)
 		}
 	}
 	return dbMetrics, nil
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_progress_ledger;
\ No newline at end of file
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS t_progress_ledger(
+	f_metric TEXT,
+	f_index UInt64,
+	f_timestamp UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_metric, f_index);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_work_leases;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS t_work_leases(
+	f_init_epoch UInt64,
+	f_final_epoch UInt64,
+	f_worker_id TEXT,
+	f_status TEXT,
+	f_heartbeat UInt64,
+	f_version UInt64)
+	ENGINE = ReplacingMergeTree(f_version)
+	ORDER BY (f_init_epoch);
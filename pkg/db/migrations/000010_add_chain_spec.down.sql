@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_chain_spec;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS t_chain_spec(
+	f_config_name TEXT,
+	f_seconds_per_slot UInt64,
+	f_slots_per_epoch UInt64,
+	f_slots_per_historical_root UInt64,
+	f_sync_committee_size UInt64,
+	f_epochs_per_sync_committee_period UInt64,
+	f_max_effective_balance UInt64,
+	f_effective_balance_increment UInt64,
+	f_base_reward_factor UInt64,
+	f_proposer_reward_quotient UInt64,
+	f_whistleblower_reward_quotient UInt64,
+	f_min_attestation_inclusion_delay UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_config_name);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS t_sync_committee_participation(
+	f_slot UInt64,
+	f_val_idx UInt64,
+	f_signed BOOL,
+	f_missed_block BOOL,
+	f_reward UInt64,
+	f_penalty UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_val_idx);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_attestation_duties;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS t_attestation_duties(
+	f_val_idx UInt64,
+	f_epoch UInt64,
+	f_duty_slot UInt64,
+	f_committee_index UInt64,
+	f_inclusion_slot UInt64,
+	f_inclusion_block_root TEXT,
+	f_head_root TEXT,
+	f_target_root TEXT,
+	f_source_root TEXT,
+	f_correct_head BOOL,
+	f_correct_target BOOL,
+	f_correct_source BOOL)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_epoch, f_val_idx);
@@ -0,0 +1,6 @@
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_attestations_redundant;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_attestations_subset;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_unique_new_votes;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_att_reward_captured;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_att_reward_available;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_packing_efficiency;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_attestations_redundant UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_attestations_subset UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_unique_new_votes UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_att_reward_captured UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_att_reward_available UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_packing_efficiency Float32 DEFAULT 0;
@@ -0,0 +1,7 @@
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_flags_reward;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_flags_penalty;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_inactivity_penalty;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_slashing_penalty;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_correlation_penalty;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_sync_reward;
+ALTER TABLE t_validator_rewards_summary DROP COLUMN IF EXISTS f_sync_penalty;
\ No newline at end of file
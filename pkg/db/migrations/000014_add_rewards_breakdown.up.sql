@@ -0,0 +1,7 @@
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_flags_reward UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_flags_penalty UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_inactivity_penalty UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_slashing_penalty UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_correlation_penalty UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_sync_reward UInt64 DEFAULT 0;
+ALTER TABLE t_validator_rewards_summary ADD COLUMN IF NOT EXISTS f_sync_penalty UInt64 DEFAULT 0;
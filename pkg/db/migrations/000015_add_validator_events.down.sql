@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_validator_events;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS t_validator_events(
+	f_val_idx UInt64,
+	f_epoch UInt64,
+	f_slot UInt64,
+	f_event TEXT,
+	f_effective_epoch UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_val_idx, f_epoch, f_event);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS t_bls_to_execution_changes;
+
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_bls_to_execution_changes;
\ No newline at end of file
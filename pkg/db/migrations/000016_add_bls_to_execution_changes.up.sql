@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS t_bls_to_execution_changes(
+	f_slot UInt64,
+	f_val_idx UInt64,
+	f_from_bls_pubkey TEXT,
+	f_to_execution_address TEXT)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_val_idx);
+
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_bls_to_execution_changes UInt64 DEFAULT 0;
@@ -0,0 +1,8 @@
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_justification_bits;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_justified_epoch;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_finalized_epoch;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_epochs_since_finality;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_inactivity_leak;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_source_participation;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_target_participation;
+ALTER TABLE t_epoch_metrics_summary DROP COLUMN IF EXISTS f_head_participation;
\ No newline at end of file
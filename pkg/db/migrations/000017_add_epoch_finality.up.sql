@@ -0,0 +1,8 @@
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_justification_bits UInt8 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_justified_epoch UInt64 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_finalized_epoch UInt64 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_epochs_since_finality UInt64 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_inactivity_leak BOOL DEFAULT false;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_source_participation Float32 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_target_participation Float32 DEFAULT 0;
+ALTER TABLE t_epoch_metrics_summary ADD COLUMN IF NOT EXISTS f_head_participation Float32 DEFAULT 0;
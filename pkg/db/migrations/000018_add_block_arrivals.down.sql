@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS t_block_arrivals;
+
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_head_vote_misses;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS t_block_arrivals(
+	f_slot UInt64,
+	f_block_root TEXT,
+	f_arrival_timestamp UInt64,
+	f_arrival_latency_ms Int64,
+	f_late BOOL,
+	f_after_att_deadline BOOL)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_block_root);
+
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_head_vote_misses UInt64 DEFAULT 0;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS t_fork_orphans;
\ No newline at end of file
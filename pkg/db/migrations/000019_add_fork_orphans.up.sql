@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS t_fork_orphans(
+	f_slot UInt64,
+	f_block_root TEXT,
+	f_parent_root TEXT,
+	f_proposer_index UInt64,
+	f_depth UInt64,
+	f_split_slot UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_block_root);
@@ -0,0 +1,3 @@
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_el_blob_gas_used;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_el_blob_base_fee;
+ALTER TABLE t_block_metrics DROP COLUMN IF EXISTS f_el_blob_fees;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_el_blob_gas_used UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_el_blob_base_fee UInt64 DEFAULT 0;
+ALTER TABLE t_block_metrics ADD COLUMN IF NOT EXISTS f_el_blob_fees UInt64 DEFAULT 0;
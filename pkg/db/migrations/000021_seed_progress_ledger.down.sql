@@ -0,0 +1 @@
+ALTER TABLE t_progress_ledger DELETE WHERE f_timestamp = 0;
\ No newline at end of file
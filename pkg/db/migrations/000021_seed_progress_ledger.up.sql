@@ -0,0 +1,21 @@
+INSERT INTO t_progress_ledger (f_metric, f_index, f_timestamp)
+	SELECT DISTINCT 'block', f_slot, 0
+	FROM t_block_metrics;
+
+INSERT INTO t_progress_ledger (f_metric, f_index, f_timestamp)
+	SELECT DISTINCT 'epoch', f_epoch, 0
+	FROM t_epoch_metrics_summary;
+
+INSERT INTO t_progress_ledger (f_metric, f_index, f_timestamp)
+	SELECT DISTINCT 'rewards', f_epoch, 0
+	FROM t_validator_rewards_summary;
+
+INSERT INTO t_progress_ledger (f_metric, f_index, f_timestamp)
+	SELECT DISTINCT 'transactions', f_slot, 0
+	FROM t_transactions;
+
+-- slots without transactions have nothing to download
+INSERT INTO t_progress_ledger (f_metric, f_index, f_timestamp)
+	SELECT DISTINCT 'transactions', f_slot, 0
+	FROM t_block_metrics
+	WHERE f_el_transactions = 0;
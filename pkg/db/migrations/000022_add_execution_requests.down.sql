@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS t_deposit_requests;
+DROP TABLE IF EXISTS t_withdrawal_requests;
+DROP TABLE IF EXISTS t_consolidation_requests;
\ No newline at end of file
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS t_deposit_requests(
+	f_slot UInt64,
+	f_index UInt64,
+	f_pubkey TEXT,
+	f_withdrawal_credentials TEXT,
+	f_amount UInt64,
+	f_signature TEXT)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_index);
+
+CREATE TABLE IF NOT EXISTS t_withdrawal_requests(
+	f_slot UInt64,
+	f_source_address TEXT,
+	f_validator_pubkey TEXT,
+	f_amount UInt64)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_source_address, f_validator_pubkey, f_amount);
+
+CREATE TABLE IF NOT EXISTS t_consolidation_requests(
+	f_slot UInt64,
+	f_source_address TEXT,
+	f_source_pubkey TEXT,
+	f_target_pubkey TEXT)
+	ENGINE = ReplacingMergeTree()
+	ORDER BY (f_slot, f_source_pubkey, f_target_pubkey);
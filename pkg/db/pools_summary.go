@@ -30,19 +30,23 @@ var (
 				ON t_validator_rewards_summary.f_val_idx = t_eth2_pubkeys.f_val_idx
 			LEFT JOIN t_proposer_duties 
 				ON t_validator_rewards_summary.f_val_idx = t_proposer_duties.f_val_idx 
-				AND t_validator_rewards_summary.f_epoch = toUInt64(t_proposer_duties.f_proposer_slot/32)
+				AND t_validator_rewards_summary.f_epoch = toUInt64(t_proposer_duties.f_proposer_slot/$2)
 			WHERE f_epoch = $1 AND f_status = 1 AND f_pool_name != ''
 			GROUP BY t_eth2_pubkeys.f_pool_name, f_epoch`
 )
 
 func (p *DBService) InsertPoolSummary(epoch phase0.Epoch) error {
+	if !p.hasServer() {
+		log.Debugf("no database server: pool summaries for epoch %d are not aggregated", epoch)
+		return nil
+	}
 
 	query := fmt.Sprintf(insertPoolSummary, poolsTables)
 	var err error
 	startTime := time.Now()
 
 	p.highMu.Lock()
-	err = p.highLevelClient.Exec(p.ctx, query, epoch)
+	err = p.highLevelClient.Exec(p.ctx, query, epoch, p.chainSpec.SlotsPerEpoch)
 	p.highMu.Unlock()
 
 	if err == nil {
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+// Metric sets tracked in the progress ledger.
+// block and transactions are indexed by slot, epoch and rewards by epoch
+const (
+	LedgerBlock        = "block"
+	LedgerEpoch        = "epoch"
+	LedgerRewards      = "rewards"
+	LedgerTransactions = "transactions"
+)
+
+var (
+	progressLedgerTable       = "t_progress_ledger"
+	insertProgressLedgerQuery = `
+	INSERT INTO %s (
+		f_metric,
+		f_index,
+		f_timestamp)
+		VALUES`
+
+	selectProgressLedgerQuery = `
+		SELECT DISTINCT f_index
+		FROM %s
+		WHERE f_metric = '%s' AND f_index >= %d AND f_index <= %d
+		ORDER BY f_index`
+
+	deleteProgressLedgerQuery = `
+		DELETE FROM %s
+		WHERE f_metric = $1 AND f_index = $2;`
+)
+
+// LedgerEntry marks the given slot or epoch as fully processed for a metric set
+type LedgerEntry struct {
+	Metric    string
+	Index     uint64 // slot or epoch, depending on the metric set
+	Timestamp int64  // unix time when the entry was processed
+}
+
+func NewLedgerEntry(metric string, index uint64) LedgerEntry {
+	return LedgerEntry{
+		Metric:    metric,
+		Index:     index,
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// LedgerGap is a range of slots or epochs (both inclusive) missing in the ledger
+type LedgerGap struct {
+	Metric string
+	From   uint64
+	To     uint64
+}
+
+func progressLedgerInput(entries []LedgerEntry) proto.Input {
+	// one object per column
+	var (
+		f_metric    proto.ColStr
+		f_index     proto.ColUInt64
+		f_timestamp proto.ColUInt64
+	)
+
+	for _, entry := range entries {
+
+		f_metric.Append(entry.Metric)
+		f_index.Append(entry.Index)
+		f_timestamp.Append(uint64(entry.Timestamp))
+	}
+
+	return proto.Input{
+
+		{Name: "f_metric", Data: f_metric},
+		{Name: "f_index", Data: f_index},
+		{Name: "f_timestamp", Data: f_timestamp},
+	}
+}
+
+func (p *DBService) PersistLedgerEntries(data []LedgerEntry) error {
+	persistObj := PersistableObject[LedgerEntry]{
+		input: progressLedgerInput,
+		table: progressLedgerTable,
+		query: insertProgressLedgerQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting progress ledger: %s", err.Error())
+	}
+	return err
+}
+
+// persistProgress marks every index as processed for the metric set, in a single insert
+func (p *DBService) persistProgress(metric string, indexes []uint64) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+	entries := make([]LedgerEntry, 0, len(indexes))
+	for _, index := range indexes {
+		entries = append(entries, NewLedgerEntry(metric, index))
+	}
+	err := p.PersistLedgerEntries(entries)
+	if err != nil {
+		return fmt.Errorf("could not record %s in the progress ledger: %s", metric, err)
+	}
+	return nil
+}
+
+func (s *DBService) DeleteLedgerEntry(metric string, index uint64) error {
+	return s.Delete(DeletableObject{
+		query: deleteProgressLedgerQuery,
+		table: progressLedgerTable,
+		args:  []any{metric, index},
+	})
+}
+
+// RetrieveLedgerGaps returns the ranges in [from, to] that are not recorded
+// as processed for the given metric set
+func (p *DBService) RetrieveLedgerGaps(metric string, from uint64, to uint64) ([]LedgerGap, error) {
+
+	var dest []struct {
+		F_index uint64 `ch:"f_index"`
+	}
+
+	err := p.highSelect(
+		fmt.Sprintf(selectProgressLedgerQuery, progressLedgerTable, metric, from, to),
+		&dest)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := make([]uint64, 0, len(dest))
+	for _, item := range dest {
+		processed = append(processed, item.F_index)
+	}
+
+	return FindLedgerGaps(metric, processed, from, to), nil
+}
+
+// FindLedgerGaps returns the ranges in [from, to] not covered by processed.
+// processed must be sorted in ascending order
+func FindLedgerGaps(metric string, processed []uint64, from uint64, to uint64) []LedgerGap {
+	gaps := make([]LedgerGap, 0)
+	if from > to {
+		return gaps
+	}
+
+	next := from // next index we expect to find processed
+	for _, index := range processed {
+		if index < next {
+			continue
+		}
+		if index > to {
+			break
+		}
+		if index > next {
+			gaps = append(gaps, LedgerGap{Metric: metric, From: next, To: index - 1})
+		}
+		if index == to {
+			return gaps
+		}
+		next = index + 1
+	}
+	gaps = append(gaps, LedgerGap{Metric: metric, From: next, To: to})
+
+	return gaps
+}
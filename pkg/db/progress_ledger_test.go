@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindLedgerGaps(t *testing.T) {
+
+	// nothing processed
+	gaps := FindLedgerGaps(LedgerBlock, []uint64{}, 10, 20)
+	assert.Equal(t, []LedgerGap{{Metric: LedgerBlock, From: 10, To: 20}}, gaps)
+
+	// everything processed
+	gaps = FindLedgerGaps(LedgerBlock, []uint64{10, 11, 12}, 10, 12)
+	assert.Equal(t, 0, len(gaps))
+
+	// holes at the beginning, the middle and the end
+	gaps = FindLedgerGaps(LedgerEpoch, []uint64{3, 4, 7, 8}, 1, 10)
+	assert.Equal(t, []LedgerGap{
+		{Metric: LedgerEpoch, From: 1, To: 2},
+		{Metric: LedgerEpoch, From: 5, To: 6},
+		{Metric: LedgerEpoch, From: 9, To: 10},
+	}, gaps)
+
+	// entries outside the range are ignored
+	gaps = FindLedgerGaps(LedgerRewards, []uint64{0, 5, 12}, 4, 10)
+	assert.Equal(t, []LedgerGap{
+		{Metric: LedgerRewards, From: 4, To: 4},
+		{Metric: LedgerRewards, From: 6, To: 10},
+	}, gaps)
+}
+
+func TestPersistBlocksLeavesProgress(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+
+	dbClient, err := New(context.Background(), "file://"+dir)
+	assert.Nil(t, err)
+	assert.Nil(t, dbClient.Connect())
+
+	// the blocks are written without touching the ledger,
+	// only the slots recorded afterwards are marked as processed
+	syncAggregate := &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()}
+	err = dbClient.PersistBlocks([]spec.AgnosticBlock{
+		{Slot: 100, SyncAggregate: syncAggregate},
+		{Slot: 101, SyncAggregate: syncAggregate},
+	})
+	assert.Nil(t, err)
+	err = dbClient.PersistLedgerEntries([]LedgerEntry{NewLedgerEntry(LedgerBlock, 100)})
+	assert.Nil(t, err)
+	dbClient.Finish()
+
+	content, err := os.ReadFile(filepath.Join(dir, progressLedgerTable+".jsonl"))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, 1, len(lines))
+	assert.Contains(t, lines[0], `"f_metric":"block"`)
+	assert.Contains(t, lines[0], `"f_index":100`)
+}
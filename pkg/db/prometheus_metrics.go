@@ -17,6 +17,12 @@ var (
 		Name:      "last_processed_epoch",
 		Help:      "Last epoch processed with metrics",
 	})
+	FinalityDistance = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: strings.ToLower(utils.CliName),
+		Subsystem: modName,
+		Name:      "finality_distance",
+		Help:      "Epochs since finality of the last epoch processed with metrics",
+	})
 	// List of metrics that we are going to export
 	LastProcessedSlot = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: strings.ToLower(utils.CliName),
@@ -75,22 +81,34 @@ var (
 func (r *DBService) initMonitorMetrics() {
 
 	tablesArr := []string{
+		attDutiesTable,
 		blobsTable,
 		blobEventsTable,
+		blockArrivalsTable,
 		blockRewardsTable,
 		blocksTable,
+		blsToExecutionChangesTable,
+		chainSpecTable,
+		consolidationRequestsTable,
+		depositRequestsTable,
 		epochsTable,
 		finalizedTable,
+		forkOrphansTable,
 		genesisTable,
 		headEventsTable,
 		orphansTable,
 		poolsTables,
+		progressLedgerTable,
 		proposerDutiesTable,
 		reorgsTable,
+		syncParticipationTable,
 		transactionsTable,
+		valEventsTable,
 		valLastStatusTable,
 		valRewardsTable,
-		withdrawalsTable}
+		withdrawalRequestsTable,
+		withdrawalsTable,
+		workLeasesTable}
 
 	for _, tableName := range tablesArr {
 		r.monitorMetrics[tableName] = &DBMonitorMetrics{}
@@ -108,6 +126,7 @@ func (r *DBService) GetPrometheusMetrics() *metrics.MetricsModule {
 
 	metricsMod.AddIndvMetric(r.lastProcessedSlotMetric())
 	metricsMod.AddIndvMetric(r.lastProcessedEpochMetric())
+	metricsMod.AddIndvMetric(r.finalityDistanceMetric())
 	return metricsMod
 }
 
@@ -175,6 +194,30 @@ func (r *DBService) lastProcessedEpochMetric() *metrics.IndvMetrics {
 	return lastEpoch
 }
 
+func (r *DBService) finalityDistanceMetric() *metrics.IndvMetrics {
+	initFn := func() error {
+		prometheus.MustRegister(FinalityDistance)
+		return nil
+	}
+	updateFn := func() (interface{}, error) {
+		distance, err := r.RetrieveLastFinalityDistance()
+		if err != nil {
+			return nil, err
+		}
+		FinalityDistance.Set(float64(distance))
+		return distance, nil
+	}
+	finalityDistance, err := metrics.NewIndvMetrics(
+		"finality_distance",
+		initFn,
+		updateFn,
+	)
+	if err != nil {
+		return nil
+	}
+	return finalityDistance
+}
+
 func (r *DBService) lastProcessedSlotMetric() *metrics.IndvMetrics {
 	initFn := func() error {
 		prometheus.MustRegister(LastProcessedSlot)
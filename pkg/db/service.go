@@ -33,9 +33,11 @@ type DBService struct {
 	connectionUrl string // the url might not be necessary (better to remove it?¿)
 
 	lowLevelClient  *ch.Client  // for bulk loads, mainly insert
-	highLevelClient driver.Conn // for side tasks, like Select and Delete
+	highLevelClient driver.Conn // for side tasks, like Select and Delete, nil when writing to files
+	sink            Sink        // where persisted rows go: the low level client or JSON lines files
 
 	monitorMetrics map[string]*DBMonitorMetrics // map table and metrics
+	chainSpec      *spec.ChainSpec              // set by InitChainSpec, used to turn epochs into slot ranges
 	lowMu          sync.Mutex
 	highMu         sync.Mutex
 	metricsMu      sync.RWMutex
@@ -47,6 +49,7 @@ func New(ctx context.Context, url string, options ...DBServiceOption) (*DBServic
 		ctx:            ctx,
 		connectionUrl:  url,
 		monitorMetrics: make(map[string]*DBMonitorMetrics),
+		chainSpec:      spec.MainnetChainSpec(),
 	}
 
 	pService.initMonitorMetrics()
@@ -62,6 +65,16 @@ func New(ctx context.Context, url string, options ...DBServiceOption) (*DBServic
 }
 
 func (s *DBService) Connect() error {
+	if IsFileUrl(s.connectionUrl) {
+		sink, err := NewJSONLSink(s.connectionUrl)
+		if err != nil {
+			return err
+		}
+		s.sink = sink
+		log.Infof("no database server: persisting rows as JSON lines to %s", s.connectionUrl)
+		return nil
+	}
+
 	err := s.ConnectLowLevel()
 	if err != nil {
 		return err
@@ -84,13 +97,26 @@ func WithUrl(url string) DBServiceOption {
 
 func (p *DBService) Finish() {
 
-	p.lowLevelClient.Close()
-	p.highLevelClient.Close()
+	if p.sink != nil {
+		err := p.sink.Close()
+		if err != nil {
+			log.Errorf("could not close the sink: %s", err)
+		}
+	}
+	if p.highLevelClient != nil {
+		p.highLevelClient.Close()
+	}
 	log.Infof("Routines finished...")
 	log.Infof("closing connection to database server...")
 	log.Infof("connection to database server closed...")
 }
 
+// hasServer returns whether there is a database to read from or delete in,
+// without one only persisted rows are written to the sink
+func (p *DBService) hasServer() bool {
+	return p.highLevelClient != nil
+}
+
 type DBMonitorMetrics struct {
 	Rows           uint64        // how many rows were persisted in the last copy
 	PersistTime    time.Duration // how much time to persist the last copy
@@ -145,7 +171,19 @@ type PersistableObject[
 		HeadEvent |
 		spec.AgnosticBlobSidecar |
 		spec.BlobSideCarEventWraper |
-		BlockReward] struct {
+		BlockReward |
+		LedgerEntry |
+		WorkLease |
+		spec.ChainSpec |
+		spec.DepositRequest |
+		spec.WithdrawalRequest |
+		spec.ConsolidationRequest |
+		spec.SyncCommitteeParticipation |
+		spec.AttestationDuty |
+		spec.ValidatorEvent |
+		spec.BLSToExecutionChange |
+		spec.BlockArrival |
+		spec.ForkOrphan] struct {
 	table string
 	query string
 	data  []T
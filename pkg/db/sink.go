@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+var (
+	fileUrlPrefix = "file://"
+)
+
+// Sink receives the rows of every Persist call
+type Sink interface {
+	Write(ctx context.Context, query string, table string, input proto.Input, rows int) error
+	Close() error
+}
+
+// IsFileUrl returns whether the url selects the JSON lines sink instead of a ClickHouse server
+func IsFileUrl(url string) bool {
+	return strings.HasPrefix(url, fileUrlPrefix)
+}
+
+// clickhouseSink inserts the rows using the low level client
+type clickhouseSink struct {
+	client *ch.Client
+}
+
+func (s *clickhouseSink) Write(ctx context.Context, query string, table string, input proto.Input, rows int) error {
+	return s.client.Do(ctx, ch.Query{
+		Body:  query,
+		Input: input,
+	})
+}
+
+func (s *clickhouseSink) Close() error {
+	return s.client.Close()
+}
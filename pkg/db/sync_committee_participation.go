@@ -0,0 +1,73 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	syncParticipationTable       = "t_sync_committee_participation"
+	insertSyncParticipationQuery = `
+	INSERT INTO %s (
+		f_slot,
+		f_val_idx,
+		f_signed,
+		f_missed_block,
+		f_reward,
+		f_penalty)
+		VALUES`
+
+	deleteSyncParticipationQuery = `
+		DELETE FROM %s
+		WHERE f_slot >= $1 AND f_slot <= $2;`
+)
+
+func syncParticipationInput(participations []spec.SyncCommitteeParticipation) proto.Input {
+	// one object per column
+	var (
+		f_slot         proto.ColUInt64
+		f_val_idx      proto.ColUInt64
+		f_signed       proto.ColBool
+		f_missed_block proto.ColBool
+		f_reward       proto.ColUInt64
+		f_penalty      proto.ColUInt64
+	)
+
+	for _, participation := range participations {
+
+		f_slot.Append(uint64(participation.Slot))
+		f_val_idx.Append(uint64(participation.ValidatorIndex))
+		f_signed.Append(participation.Signed)
+		f_missed_block.Append(participation.MissedBlock)
+		f_reward.Append(uint64(participation.Reward))
+		f_penalty.Append(uint64(participation.Penalty))
+	}
+
+	return proto.Input{
+
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_signed", Data: f_signed},
+		{Name: "f_missed_block", Data: f_missed_block},
+		{Name: "f_reward", Data: f_reward},
+		{Name: "f_penalty", Data: f_penalty},
+	}
+}
+
+func (p *DBService) PersistSyncParticipation(data []spec.SyncCommitteeParticipation) error {
+	persistObj := PersistableObject[spec.SyncCommitteeParticipation]{
+		input: syncParticipationInput,
+		table: syncParticipationTable,
+		query: insertSyncParticipationQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting sync committee participation: %s", err.Error())
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	valEventsTable       = "t_validator_events"
+	insertValEventsQuery = `
+	INSERT INTO %s (
+		f_val_idx,
+		f_epoch,
+		f_slot,
+		f_event,
+		f_effective_epoch)
+		VALUES`
+
+	deleteValEventsQuery = `
+		DELETE FROM %s
+		WHERE f_epoch = $1;`
+)
+
+func valEventsInput(events []spec.ValidatorEvent) proto.Input {
+	// one object per column
+	var (
+		f_val_idx         proto.ColUInt64
+		f_epoch           proto.ColUInt64
+		f_slot            proto.ColUInt64
+		f_event           proto.ColStr
+		f_effective_epoch proto.ColUInt64
+	)
+
+	for _, event := range events {
+
+		f_val_idx.Append(uint64(event.ValidatorIndex))
+		f_epoch.Append(uint64(event.Epoch))
+		f_slot.Append(uint64(event.Slot))
+		f_event.Append(string(event.Event))
+		f_effective_epoch.Append(uint64(event.EffectiveEpoch))
+	}
+
+	return proto.Input{
+
+		{Name: "f_val_idx", Data: f_val_idx},
+		{Name: "f_epoch", Data: f_epoch},
+		{Name: "f_slot", Data: f_slot},
+		{Name: "f_event", Data: f_event},
+		{Name: "f_effective_epoch", Data: f_effective_epoch},
+	}
+}
+
+func (p *DBService) PersistValidatorEvents(data []spec.ValidatorEvent) error {
+	persistObj := PersistableObject[spec.ValidatorEvent]{
+		input: valEventsInput,
+		table: valEventsTable,
+		query: insertValEventsQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting validator events: %s", err.Error())
+	}
+	return err
+}
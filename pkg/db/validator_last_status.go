@@ -26,7 +26,7 @@ var (
 		WHERE f_epoch < $1`
 )
 
-func valStatusInput(validatorStatuses []spec.ValidatorLastStatus) proto.Input {
+func valStatusInput(validatorStatuses []spec.ValidatorLastStatus, chainSpec *spec.ChainSpec) proto.Input {
 	// one object per column
 	var (
 		f_val_idx          proto.ColUInt64
@@ -44,7 +44,7 @@ func valStatusInput(validatorStatuses []spec.ValidatorLastStatus) proto.Input {
 
 		f_val_idx.Append(uint64(status.ValIdx))
 		f_epoch.Append(uint64(status.Epoch))
-		f_balance_eth.Append(status.BalanceToEth())
+		f_balance_eth.Append(status.BalanceToEth(chainSpec))
 		f_status.Append(uint8(status.CurrentStatus))
 		f_slashed.Append(status.Slashed)
 		f_activation_epoch.Append(uint64(status.ActivationEpoch))
@@ -69,7 +69,9 @@ func valStatusInput(validatorStatuses []spec.ValidatorLastStatus) proto.Input {
 
 func (p *DBService) PersistValLastStatus(data []spec.ValidatorLastStatus) error {
 	persistObj := PersistableObject[spec.ValidatorLastStatus]{
-		input: valStatusInput,
+		input: func(statuses []spec.ValidatorLastStatus) proto.Input {
+			return valStatusInput(statuses, p.chainSpec)
+		},
 		table: valLastStatusTable,
 		query: insertValidatorLastStatusesQuery,
 	}
@@ -27,7 +27,14 @@ var (
 		f_status,
 		f_block_api_reward,
 		f_block_experimental_reward,
-		f_inclusion_delay) VALUES`
+		f_inclusion_delay,
+		f_flags_reward,
+		f_flags_penalty,
+		f_inactivity_penalty,
+		f_slashing_penalty,
+		f_correlation_penalty,
+		f_sync_reward,
+		f_sync_penalty) VALUES`
 
 	deleteValidatorRewardsInEpochQuery = `
 		DELETE FROM %s
@@ -40,7 +47,7 @@ var (
 	`
 )
 
-func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
+func rewardsInput(vals []spec.ValidatorRewards, chainSpec *spec.ChainSpec) proto.Input {
 	// one object per column
 	var (
 		f_val_idx                   proto.ColUInt64
@@ -60,12 +67,19 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		f_block_api_reward          proto.ColUInt64
 		f_block_experimental_reward proto.ColUInt64
 		f_inclusion_delay           proto.ColUInt8
+		f_flags_reward              proto.ColUInt64
+		f_flags_penalty             proto.ColUInt64
+		f_inactivity_penalty        proto.ColUInt64
+		f_slashing_penalty          proto.ColUInt64
+		f_correlation_penalty       proto.ColUInt64
+		f_sync_reward               proto.ColUInt64
+		f_sync_penalty              proto.ColUInt64
 	)
 
 	for _, val := range vals {
 		f_val_idx.Append(uint64(val.ValidatorIndex))
 		f_epoch.Append(uint64(val.Epoch))
-		f_balance_eth.Append(float32(val.BalanceToEth()))
+		f_balance_eth.Append(val.BalanceToEth(chainSpec))
 		f_reward.Append(int64(val.Reward))
 		f_max_reward.Append(uint64(val.MaxReward))
 		f_max_att_reward.Append(uint64(val.AttestationReward))
@@ -80,6 +94,13 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		f_block_api_reward.Append(uint64(val.ProposerApiReward))
 		f_block_experimental_reward.Append(uint64(val.ProposerManualReward))
 		f_inclusion_delay.Append(uint8(val.InclusionDelay))
+		f_flags_reward.Append(uint64(val.FlagsReward))
+		f_flags_penalty.Append(uint64(val.FlagsPenalty))
+		f_inactivity_penalty.Append(uint64(val.InactivityPenalty))
+		f_slashing_penalty.Append(uint64(val.SlashingPenalty))
+		f_correlation_penalty.Append(uint64(val.CorrelationPenalty))
+		f_sync_reward.Append(uint64(val.SyncReward))
+		f_sync_penalty.Append(uint64(val.SyncPenalty))
 	}
 
 	return proto.Input{
@@ -100,12 +121,21 @@ func rewardsInput(vals []spec.ValidatorRewards) proto.Input {
 		{Name: "f_block_api_reward", Data: f_block_api_reward},
 		{Name: "f_block_experimental_reward", Data: f_block_experimental_reward},
 		{Name: "f_inclusion_delay", Data: f_inclusion_delay},
+		{Name: "f_flags_reward", Data: f_flags_reward},
+		{Name: "f_flags_penalty", Data: f_flags_penalty},
+		{Name: "f_inactivity_penalty", Data: f_inactivity_penalty},
+		{Name: "f_slashing_penalty", Data: f_slashing_penalty},
+		{Name: "f_correlation_penalty", Data: f_correlation_penalty},
+		{Name: "f_sync_reward", Data: f_sync_reward},
+		{Name: "f_sync_penalty", Data: f_sync_penalty},
 	}
 }
 
 func (p *DBService) PersistValidatorRewards(data []spec.ValidatorRewards) error {
 	persistObj := PersistableObject[spec.ValidatorRewards]{
-		input: rewardsInput,
+		input: func(vals []spec.ValidatorRewards) proto.Input {
+			return rewardsInput(vals, p.chainSpec)
+		},
 		table: valRewardsTable,
 		query: insertValidatorRewardsQuery,
 	}
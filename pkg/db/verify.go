@@ -0,0 +1,250 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	selectEpochRowQuery = `
+		SELECT
+			f_epoch,
+			f_slot,
+			f_num_att,
+			f_num_att_vals,
+			f_num_vals,
+			f_total_balance_eth,
+			f_att_effective_balance_eth,
+			f_source_att_effective_balance_eth,
+			f_target_att_effective_balance_eth,
+			f_head_att_effective_balance_eth,
+			f_total_effective_balance_eth,
+			f_missing_source,
+			f_missing_target,
+			f_missing_head,
+			f_timestamp,
+			f_num_slashed_vals,
+			f_num_active_vals,
+			f_num_exited_vals,
+			f_num_in_activation_vals,
+			f_justification_bits,
+			f_justified_epoch,
+			f_finalized_epoch,
+			f_epochs_since_finality,
+			f_inactivity_leak,
+			f_source_participation,
+			f_target_participation,
+			f_head_participation
+		FROM %s FINAL
+		WHERE f_epoch = %d`
+
+	selectValidatorRewardsRowsQuery = `
+		SELECT
+			f_val_idx,
+			f_epoch,
+			f_balance_eth,
+			f_reward,
+			f_max_reward,
+			f_max_att_reward,
+			f_max_sync_reward,
+			f_att_slot,
+			f_base_reward,
+			f_in_sync_committee,
+			f_missing_source,
+			f_missing_target,
+			f_missing_head,
+			f_status,
+			f_block_api_reward,
+			f_block_experimental_reward,
+			f_inclusion_delay,
+			f_flags_reward,
+			f_flags_penalty,
+			f_inactivity_penalty,
+			f_slashing_penalty,
+			f_correlation_penalty,
+			f_sync_reward,
+			f_sync_penalty
+		FROM %s FINAL
+		WHERE f_epoch = %d`
+)
+
+// EpochRow is a t_epoch_metrics_summary row as it is stored
+type EpochRow struct {
+	F_epoch                            uint64  `ch:"f_epoch"`
+	F_slot                             uint64  `ch:"f_slot"`
+	F_num_att                          uint64  `ch:"f_num_att"`
+	F_num_att_vals                     uint64  `ch:"f_num_att_vals"`
+	F_num_vals                         uint64  `ch:"f_num_vals"`
+	F_total_balance_eth                float32 `ch:"f_total_balance_eth"`
+	F_att_effective_balance_eth        uint64  `ch:"f_att_effective_balance_eth"`
+	F_source_att_effective_balance_eth uint64  `ch:"f_source_att_effective_balance_eth"`
+	F_target_att_effective_balance_eth uint64  `ch:"f_target_att_effective_balance_eth"`
+	F_head_att_effective_balance_eth   uint64  `ch:"f_head_att_effective_balance_eth"`
+	F_total_effective_balance_eth      uint64  `ch:"f_total_effective_balance_eth"`
+	F_missing_source                   uint64  `ch:"f_missing_source"`
+	F_missing_target                   uint64  `ch:"f_missing_target"`
+	F_missing_head                     uint64  `ch:"f_missing_head"`
+	F_timestamp                        uint64  `ch:"f_timestamp"`
+	F_num_slashed_vals                 uint64  `ch:"f_num_slashed_vals"`
+	F_num_active_vals                  uint64  `ch:"f_num_active_vals"`
+	F_num_exited_vals                  uint64  `ch:"f_num_exited_vals"`
+	F_num_in_activation_vals           uint64  `ch:"f_num_in_activation_vals"`
+	F_justification_bits               uint8   `ch:"f_justification_bits"`
+	F_justified_epoch                  uint64  `ch:"f_justified_epoch"`
+	F_finalized_epoch                  uint64  `ch:"f_finalized_epoch"`
+	F_epochs_since_finality            uint64  `ch:"f_epochs_since_finality"`
+	F_inactivity_leak                  bool    `ch:"f_inactivity_leak"`
+	F_source_participation             float32 `ch:"f_source_participation"`
+	F_target_participation             float32 `ch:"f_target_participation"`
+	F_head_participation               float32 `ch:"f_head_participation"`
+}
+
+// NewEpochRow converts the epoch the same way epochsInput does
+func NewEpochRow(epoch spec.Epoch) EpochRow {
+	return EpochRow{
+		F_epoch:                            uint64(epoch.Epoch),
+		F_slot:                             uint64(epoch.Slot),
+		F_num_att:                          uint64(epoch.NumAttestations),
+		F_num_att_vals:                     uint64(epoch.NumAttValidators),
+		F_num_vals:                         uint64(epoch.NumValidators),
+		F_total_balance_eth:                float32(epoch.TotalBalance),
+		F_att_effective_balance_eth:        uint64(epoch.AttEffectiveBalance),
+		F_source_att_effective_balance_eth: uint64(epoch.SourceAttEffectiveBalance),
+		F_target_att_effective_balance_eth: uint64(epoch.TargetAttEffectiveBalance),
+		F_head_att_effective_balance_eth:   uint64(epoch.HeadAttEffectiveBalance),
+		F_total_effective_balance_eth:      uint64(epoch.TotalEffectiveBalance),
+		F_missing_source:                   uint64(epoch.MissingSource),
+		F_missing_target:                   uint64(epoch.MissingTarget),
+		F_missing_head:                     uint64(epoch.MissingHead),
+		F_timestamp:                        uint64(epoch.Timestamp),
+		F_num_slashed_vals:                 uint64(epoch.NumSlashedVals),
+		F_num_active_vals:                  uint64(epoch.NumActiveVals),
+		F_num_exited_vals:                  uint64(epoch.NumExitedVals),
+		F_num_in_activation_vals:           uint64(epoch.NumInActivationVals),
+		F_justification_bits:               epoch.JustificationBits,
+		F_justified_epoch:                  uint64(epoch.JustifiedEpoch),
+		F_finalized_epoch:                  uint64(epoch.FinalizedEpoch),
+		F_epochs_since_finality:            epoch.EpochsSinceFinality,
+		F_inactivity_leak:                  epoch.InactivityLeak,
+		F_source_participation:             epoch.SourceParticipation,
+		F_target_participation:             epoch.TargetParticipation,
+		F_head_participation:               epoch.HeadParticipation,
+	}
+}
+
+// ValidatorRewardsRow is a t_validator_rewards_summary row as it is stored
+type ValidatorRewardsRow struct {
+	F_val_idx                   uint64  `ch:"f_val_idx"`
+	F_epoch                     uint64  `ch:"f_epoch"`
+	F_balance_eth               float32 `ch:"f_balance_eth"`
+	F_reward                    int64   `ch:"f_reward"`
+	F_max_reward                uint64  `ch:"f_max_reward"`
+	F_max_att_reward            uint64  `ch:"f_max_att_reward"`
+	F_max_sync_reward           uint64  `ch:"f_max_sync_reward"`
+	F_att_slot                  uint64  `ch:"f_att_slot"`
+	F_base_reward               uint64  `ch:"f_base_reward"`
+	F_in_sync_committee         bool    `ch:"f_in_sync_committee"`
+	F_missing_source            bool    `ch:"f_missing_source"`
+	F_missing_target            bool    `ch:"f_missing_target"`
+	F_missing_head              bool    `ch:"f_missing_head"`
+	F_status                    uint8   `ch:"f_status"`
+	F_block_api_reward          uint64  `ch:"f_block_api_reward"`
+	F_block_experimental_reward uint64  `ch:"f_block_experimental_reward"`
+	F_inclusion_delay           uint8   `ch:"f_inclusion_delay"`
+	F_flags_reward              uint64  `ch:"f_flags_reward"`
+	F_flags_penalty             uint64  `ch:"f_flags_penalty"`
+	F_inactivity_penalty        uint64  `ch:"f_inactivity_penalty"`
+	F_slashing_penalty          uint64  `ch:"f_slashing_penalty"`
+	F_correlation_penalty       uint64  `ch:"f_correlation_penalty"`
+	F_sync_reward               uint64  `ch:"f_sync_reward"`
+	F_sync_penalty              uint64  `ch:"f_sync_penalty"`
+}
+
+// NewValidatorRewardsRow converts the rewards the same way rewardsInput does
+func NewValidatorRewardsRow(val spec.ValidatorRewards, chainSpec *spec.ChainSpec) ValidatorRewardsRow {
+	return ValidatorRewardsRow{
+		F_val_idx:                   uint64(val.ValidatorIndex),
+		F_epoch:                     uint64(val.Epoch),
+		F_balance_eth:               val.BalanceToEth(chainSpec),
+		F_reward:                    int64(val.Reward),
+		F_max_reward:                uint64(val.MaxReward),
+		F_max_att_reward:            uint64(val.AttestationReward),
+		F_max_sync_reward:           uint64(val.SyncCommitteeReward),
+		F_att_slot:                  uint64(val.AttSlot),
+		F_base_reward:               uint64(val.BaseReward),
+		F_in_sync_committee:         val.InSyncCommittee,
+		F_missing_source:            val.MissingSource,
+		F_missing_target:            val.MissingTarget,
+		F_missing_head:              val.MissingHead,
+		F_status:                    uint8(val.Status),
+		F_block_api_reward:          uint64(val.ProposerApiReward),
+		F_block_experimental_reward: uint64(val.ProposerManualReward),
+		F_inclusion_delay:           uint8(val.InclusionDelay),
+		F_flags_reward:              uint64(val.FlagsReward),
+		F_flags_penalty:             uint64(val.FlagsPenalty),
+		F_inactivity_penalty:        uint64(val.InactivityPenalty),
+		F_slashing_penalty:          uint64(val.SlashingPenalty),
+		F_correlation_penalty:       uint64(val.CorrelationPenalty),
+		F_sync_reward:               uint64(val.SyncReward),
+		F_sync_penalty:              uint64(val.SyncPenalty),
+	}
+}
+
+// RetrieveEpochRow returns the stored epoch metrics, if any
+func (p *DBService) RetrieveEpochRow(epoch phase0.Epoch) (EpochRow, bool, error) {
+
+	var dest []EpochRow
+
+	err := p.highSelect(
+		fmt.Sprintf(selectEpochRowQuery, epochsTable, epoch),
+		&dest)
+
+	if len(dest) > 0 {
+		return dest[0], true, err
+	}
+	return EpochRow{}, false, err
+}
+
+// RetrieveValidatorRewardsRows returns the stored validator rewards at the epoch
+func (p *DBService) RetrieveValidatorRewardsRows(epoch phase0.Epoch) ([]ValidatorRewardsRow, error) {
+
+	var dest []ValidatorRewardsRow
+
+	err := p.highSelect(
+		fmt.Sprintf(selectValidatorRewardsRowsQuery, valRewardsTable, epoch),
+		&dest)
+
+	return dest, err
+}
+
+// FieldDiff is a column whose stored value differs from the computed one
+type FieldDiff struct {
+	Column   string
+	Stored   any
+	Computed any
+}
+
+// DiffRows compares two rows of the same table column by column
+func DiffRows[T EpochRow | ValidatorRewardsRow](stored T, computed T) []FieldDiff {
+	diffs := make([]FieldDiff, 0)
+
+	storedValue := reflect.ValueOf(stored)
+	computedValue := reflect.ValueOf(computed)
+	rowType := storedValue.Type()
+
+	for i := 0; i < rowType.NumField(); i++ {
+		storedField := storedValue.Field(i).Interface()
+		computedField := computedValue.Field(i).Interface()
+		if storedField != computedField {
+			diffs = append(diffs, FieldDiff{
+				Column:   rowType.Field(i).Tag.Get("ch"),
+				Stored:   storedField,
+				Computed: computedField,
+			})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRows(t *testing.T) {
+	computed := NewValidatorRewardsRow(spec.ValidatorRewards{
+		ValidatorIndex: 10,
+		Epoch:          200,
+		Reward:         -300,
+		MaxReward:      1000,
+		MissingHead:    true,
+		FlagsReward:    800,
+		SyncPenalty:    40,
+	}, spec.MainnetChainSpec())
+
+	stored := computed
+	assert.Equal(t, 0, len(DiffRows(stored, computed)))
+
+	stored.F_reward = 500
+	stored.F_missing_head = false
+	stored.F_sync_penalty = 0
+	diffs := DiffRows(stored, computed)
+	assert.Equal(t, []FieldDiff{
+		{Column: "f_reward", Stored: int64(500), Computed: int64(-300)},
+		{Column: "f_missing_head", Stored: false, Computed: true},
+		{Column: "f_sync_penalty", Stored: uint64(0), Computed: uint64(40)},
+	}, diffs)
+
+	epoch := NewEpochRow(spec.Epoch{Epoch: 10, NumAttestations: 128})
+	storedEpoch := epoch
+	storedEpoch.F_num_att = 127
+	assert.Equal(t, []FieldDiff{
+		{Column: "f_num_att", Stored: uint64(127), Computed: uint64(128)},
+	}, DiffRows(storedEpoch, epoch))
+}
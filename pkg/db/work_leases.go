@@ -0,0 +1,196 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go/proto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Lease status, the latest version of each chunk wins
+const (
+	LeaseClaimed = "claimed"
+	LeaseDone    = "done"
+)
+
+var (
+	workLeasesTable       = "t_work_leases"
+	insertWorkLeasesQuery = `
+	INSERT INTO %s (
+		f_init_epoch,
+		f_final_epoch,
+		f_worker_id,
+		f_status,
+		f_heartbeat,
+		f_version)
+		VALUES`
+
+	selectWorkLeasesQuery = `
+		SELECT f_init_epoch, f_final_epoch, f_worker_id, f_status, f_heartbeat, f_version
+		FROM %s FINAL
+		WHERE f_init_epoch >= %d AND f_init_epoch <= %d
+		ORDER BY f_init_epoch`
+
+	selectWorkLeaseQuery = `
+		SELECT f_init_epoch, f_final_epoch, f_worker_id, f_status, f_heartbeat, f_version
+		FROM %s FINAL
+		WHERE f_init_epoch = %d`
+
+	// the new row is only written if the latest one is still the given version of the worker
+	updateWorkLeaseQuery = `
+	INSERT INTO %s (
+		f_init_epoch,
+		f_final_epoch,
+		f_worker_id,
+		f_status,
+		f_heartbeat,
+		f_version)
+		SELECT f_init_epoch, f_final_epoch, f_worker_id, $1, $2, $3
+		FROM %s FINAL
+		WHERE f_init_epoch = $4 AND f_worker_id = $5 AND f_version = $6`
+)
+
+// WorkLease represents the ownership of an epoch range (both inclusive) by a worker
+type WorkLease struct {
+	InitEpoch  phase0.Epoch
+	FinalEpoch phase0.Epoch
+	WorkerID   string
+	Status     string
+	Heartbeat  time.Time
+	Version    uint64 // of the row the lease was read from
+}
+
+// Expired returns whether the worker stopped sending heartbeats for longer than timeout
+func (l WorkLease) Expired(timeout time.Duration) bool {
+	return l.Status == LeaseClaimed && time.Since(l.Heartbeat) > timeout
+}
+
+func workLeasesInput(leases []WorkLease) proto.Input {
+	// one object per column
+	var (
+		f_init_epoch  proto.ColUInt64
+		f_final_epoch proto.ColUInt64
+		f_worker_id   proto.ColStr
+		f_status      proto.ColStr
+		f_heartbeat   proto.ColUInt64
+		f_version     proto.ColUInt64
+	)
+
+	for _, lease := range leases {
+
+		f_init_epoch.Append(uint64(lease.InitEpoch))
+		f_final_epoch.Append(uint64(lease.FinalEpoch))
+		f_worker_id.Append(lease.WorkerID)
+		f_status.Append(lease.Status)
+		f_heartbeat.Append(uint64(lease.Heartbeat.Unix()))
+		f_version.Append(uint64(time.Now().UnixNano()))
+	}
+
+	return proto.Input{
+
+		{Name: "f_init_epoch", Data: f_init_epoch},
+		{Name: "f_final_epoch", Data: f_final_epoch},
+		{Name: "f_worker_id", Data: f_worker_id},
+		{Name: "f_status", Data: f_status},
+		{Name: "f_heartbeat", Data: f_heartbeat},
+		{Name: "f_version", Data: f_version},
+	}
+}
+
+func (p *DBService) PersistWorkLeases(data []WorkLease) error {
+	persistObj := PersistableObject[WorkLease]{
+		input: workLeasesInput,
+		table: workLeasesTable,
+		query: insertWorkLeasesQuery,
+	}
+
+	for _, item := range data {
+		persistObj.Append(item)
+	}
+
+	err := p.Persist(persistObj.ExportPersist())
+	if err != nil {
+		log.Errorf("error persisting work leases: %s", err.Error())
+	}
+	return err
+}
+
+type workLeaseRow struct {
+	F_init_epoch  uint64 `ch:"f_init_epoch"`
+	F_final_epoch uint64 `ch:"f_final_epoch"`
+	F_worker_id   string `ch:"f_worker_id"`
+	F_status      string `ch:"f_status"`
+	F_heartbeat   uint64 `ch:"f_heartbeat"`
+	F_version     uint64 `ch:"f_version"`
+}
+
+func (r workLeaseRow) lease() WorkLease {
+	return WorkLease{
+		InitEpoch:  phase0.Epoch(r.F_init_epoch),
+		FinalEpoch: phase0.Epoch(r.F_final_epoch),
+		WorkerID:   r.F_worker_id,
+		Status:     r.F_status,
+		Heartbeat:  time.Unix(int64(r.F_heartbeat), 0),
+		Version:    r.F_version,
+	}
+}
+
+// RetrieveWorkLeases returns the latest lease of every chunk starting between both epochs
+func (p *DBService) RetrieveWorkLeases(initEpoch phase0.Epoch, finalEpoch phase0.Epoch) ([]WorkLease, error) {
+
+	var dest []workLeaseRow
+
+	err := p.highSelect(
+		fmt.Sprintf(selectWorkLeasesQuery, workLeasesTable, initEpoch, finalEpoch),
+		&dest)
+
+	leases := make([]WorkLease, 0, len(dest))
+	for _, item := range dest {
+		leases = append(leases, item.lease())
+	}
+	return leases, err
+}
+
+// RetrieveWorkLease returns the latest lease of the chunk starting at initEpoch, if any
+func (p *DBService) RetrieveWorkLease(initEpoch phase0.Epoch) (WorkLease, bool, error) {
+
+	var dest []workLeaseRow
+
+	err := p.highSelect(
+		fmt.Sprintf(selectWorkLeaseQuery, workLeasesTable, initEpoch),
+		&dest)
+
+	if len(dest) > 0 {
+		return dest[0].lease(), true, err
+	}
+	return WorkLease{}, false, err
+}
+
+// UpdateWorkLease writes the lease with the given status and a new heartbeat, only if
+// its latest version is still lease.Version, and returns the latest lease afterwards.
+// The lease is kept if that latest version is the one written here
+func (p *DBService) UpdateWorkLease(lease WorkLease, status string) (WorkLease, bool, error) {
+	if !p.hasServer() {
+		return lease, false, nil // ownership can only be checked against the database
+	}
+
+	version := uint64(time.Now().UnixNano())
+	query := fmt.Sprintf(updateWorkLeaseQuery, workLeasesTable, workLeasesTable)
+
+	p.highMu.Lock()
+	err := p.highLevelClient.Exec(p.ctx, query,
+		status, uint64(time.Now().Unix()), version,
+		uint64(lease.InitEpoch), lease.WorkerID, lease.Version)
+	p.highMu.Unlock()
+	if err != nil {
+		return lease, false, err
+	}
+
+	// another worker could have written the chunk between the select and the insert
+	latest, found, err := p.RetrieveWorkLease(lease.InitEpoch)
+	if err != nil || !found {
+		return lease, false, err
+	}
+	return latest, latest.WorkerID == lease.WorkerID && latest.Version == version, nil
+}
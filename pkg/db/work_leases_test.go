@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkLeaseExpired(t *testing.T) {
+	timeout := time.Minute
+
+	tests := []struct {
+		name      string
+		status    string
+		heartbeat time.Duration // before now
+		expired   bool
+	}{
+		{"fresh heartbeat", LeaseClaimed, 0, false},
+		{"just before the timeout", LeaseClaimed, timeout - time.Second, false},
+		{"just after the timeout", LeaseClaimed, timeout + time.Second, true},
+		{"long gone worker", LeaseClaimed, 10 * timeout, true},
+		{"done chunks never expire", LeaseDone, 10 * timeout, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lease := WorkLease{Status: test.status, Heartbeat: time.Now().Add(-test.heartbeat)}
+			assert.Equal(t, test.expired, lease.Expired(timeout))
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package era
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+)
+
+var (
+	eraExtension = ".era"
+)
+
+// Archive is a directory of ERA files named <network>-<era number>-<short root>.era.
+// Files are only opened while a block or state is read from them
+type Archive struct {
+	dir       string
+	network   string
+	schedule  ForkSchedule
+	chainSpec *local_spec.ChainSpec
+	paths     map[uint64]string // era number -> file
+}
+
+// NewArchive lists the ERA files in dir. The fork schedule is read from the chain spec,
+// with a nil one the mainnet preset and the schedule of the network in the file names are used
+func NewArchive(dir string, chainSpec *local_spec.ChainSpec) (*Archive, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+eraExtension))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no era files found in %s", dir)
+	}
+
+	archive := &Archive{
+		dir:       dir,
+		chainSpec: chainSpec,
+		paths:     make(map[uint64]string),
+	}
+	for _, path := range paths {
+		network, eraNumber, err := parseFileName(filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+		if archive.network != "" && archive.network != network {
+			return nil, fmt.Errorf("era files of different networks in %s: %s and %s", dir, archive.network, network)
+		}
+		archive.network = network
+		archive.paths[eraNumber] = path
+	}
+
+	if archive.chainSpec != nil {
+		archive.schedule = ForkSchedule(archive.chainSpec.ForkEpochs)
+	} else {
+		archive.chainSpec = local_spec.MainnetChainSpec()
+		known, ok := KnownForkSchedules[archive.network]
+		if !ok {
+			return nil, fmt.Errorf("unknown fork schedule for network %s", archive.network)
+		}
+		archive.schedule = known
+	}
+	log.Infof("found %d era files of %s in %s", len(archive.paths), archive.network, dir)
+	return archive, nil
+}
+
+// parseFileName reads <network>-<era number>-<short root>.era
+func parseFileName(name string) (string, uint64, error) {
+	parts := strings.Split(strings.TrimSuffix(name, eraExtension), "-")
+	if len(parts) < 3 {
+		return "", 0, fmt.Errorf("unexpected era file name %s", name)
+	}
+	eraNumber, err := strconv.ParseUint(parts[len(parts)-2], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected era number in %s: %s", name, err)
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), eraNumber, nil
+}
+
+// blocksEra is the era whose file contains the block at the slot
+func (a *Archive) blocksEra(slot phase0.Slot) uint64 {
+	return a.stateEra(slot) + 1
+}
+
+// stateEra is the era whose file ends at the slot
+func (a *Archive) stateEra(slot phase0.Slot) uint64 {
+	return uint64(slot) / a.chainSpec.SlotsPerHistoricalRoot
+}
+
+// Covers returns whether the block at the slot can be read from the archive
+func (a *Archive) Covers(slot phase0.Slot) bool {
+	_, ok := a.paths[a.blocksEra(slot)]
+	return ok
+}
+
+// HasState returns whether the state at the slot can be read from the archive.
+// There is only one state every SLOTS_PER_HISTORICAL_ROOT slots
+func (a *Archive) HasState(slot phase0.Slot) bool {
+	_, ok := a.paths[a.stateEra(slot)]
+	return ok && uint64(slot)%a.chainSpec.SlotsPerHistoricalRoot == 0
+}
+
+// Block returns the block at the slot, nil if the slot was missed
+func (a *Archive) Block(slot phase0.Slot) (*local_spec.AgnosticBlock, error) {
+	path, ok := a.paths[a.blocksEra(slot)]
+	if !ok {
+		return nil, fmt.Errorf("no era file contains slot %d", slot)
+	}
+	file, err := Open(path, a.schedule, a.chainSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	signedBlock, err := file.Block(slot)
+	if err != nil || signedBlock == nil {
+		return nil, err
+	}
+	block, err := local_spec.GetCustomBlock(*signedBlock, a.chainSpec)
+	if err != nil {
+		return nil, err
+	}
+	// the post state root of a proposed block is in the block itself
+	block.StateRoot, err = signedBlock.StateRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// State returns the state at the slot. The duties are not part of the file,
+// and the state root is left to the caller, as in the API client
+func (a *Archive) State(slot phase0.Slot, duties local_spec.EpochDuties) (*local_spec.AgnosticState, error) {
+	if !a.HasState(slot) {
+		return nil, fmt.Errorf("no era file contains the state at slot %d", slot)
+	}
+	file, err := Open(a.paths[a.stateEra(slot)], a.schedule, a.chainSpec)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	beaconState, err := file.State()
+	if err != nil {
+		return nil, err
+	}
+	state, err := local_spec.GetCustomState(*beaconState, duties, a.chainSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,112 @@
+package era
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// e2store records: https://github.com/status-im/nimbus-eth2/blob/stable/docs/e2store.md
+const (
+	headerSize = 8 // type (2 bytes), length (4 bytes, little endian), reserved (2 bytes)
+)
+
+type recordType [2]byte
+
+var (
+	typeVersion                     = recordType{0x65, 0x32}
+	typeCompressedSignedBeaconBlock = recordType{0x01, 0x00}
+	typeCompressedBeaconState       = recordType{0x02, 0x00}
+	typeSlotIndex                   = recordType{0x69, 0x32}
+)
+
+// readHeader returns the type and the data length of the record at offset
+func readHeader(r io.ReaderAt, offset int64) (recordType, uint32, error) {
+	header := make([]byte, headerSize)
+	_, err := r.ReadAt(header, offset)
+	if err != nil {
+		return recordType{}, 0, fmt.Errorf("could not read record header at %d: %s", offset, err)
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return recordType{}, 0, fmt.Errorf("record at %d has a non zero reserved field", offset)
+	}
+	return recordType{header[0], header[1]}, binary.LittleEndian.Uint32(header[2:6]), nil
+}
+
+// readRecord returns the data of the record at offset, which must be of the given type
+func readRecord(r io.ReaderAt, offset int64, expected recordType) ([]byte, error) {
+	typ, length, err := readHeader(r, offset)
+	if err != nil {
+		return nil, err
+	}
+	if typ != expected {
+		return nil, fmt.Errorf("record at %d has type %x, expected %x", offset, typ, expected)
+	}
+	data := make([]byte, length)
+	_, err = r.ReadAt(data, offset+headerSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not read record at %d: %s", offset, err)
+	}
+	return data, nil
+}
+
+// readCompressed returns the SSZ bytes of a snappy framed record
+func readCompressed(r io.ReaderAt, offset int64, expected recordType) ([]byte, error) {
+	typ, length, err := readHeader(r, offset)
+	if err != nil {
+		return nil, err
+	}
+	if typ != expected {
+		return nil, fmt.Errorf("record at %d has type %x, expected %x", offset, typ, expected)
+	}
+	section := io.NewSectionReader(r, offset+headerSize, int64(length))
+	return io.ReadAll(snappy.NewReader(section))
+}
+
+// slotIndex maps the slots following startSlot to absolute record offsets, 0 when the slot is empty
+type slotIndex struct {
+	startSlot uint64
+	offsets   []int64
+	position  int64 // where the index record begins
+}
+
+// readSlotIndex reads the slot index record that ends right before end.
+// The record is: start slot | one offset per slot, relative to the record | count
+func readSlotIndex(r io.ReaderAt, end int64) (slotIndex, error) {
+	if end < headerSize+16 {
+		return slotIndex{}, fmt.Errorf("no room for a slot index before %d", end)
+	}
+	buf := make([]byte, 8)
+	_, err := r.ReadAt(buf, end-8)
+	if err != nil {
+		return slotIndex{}, fmt.Errorf("could not read slot index count: %s", err)
+	}
+	count := int64(binary.LittleEndian.Uint64(buf))
+	if count < 0 || count > (end-headerSize-16)/8 {
+		return slotIndex{}, fmt.Errorf("invalid slot index count %d", count)
+	}
+
+	position := end - (headerSize + 16 + 8*count)
+	data, err := readRecord(r, position, typeSlotIndex)
+	if err != nil {
+		return slotIndex{}, err
+	}
+	if int64(len(data)) != 16+8*count {
+		return slotIndex{}, fmt.Errorf("slot index at %d has length %d, expected %d", position, len(data), 16+8*count)
+	}
+
+	index := slotIndex{
+		startSlot: binary.LittleEndian.Uint64(data[0:8]),
+		offsets:   make([]int64, count),
+		position:  position,
+	}
+	for i := int64(0); i < count; i++ {
+		relative := int64(binary.LittleEndian.Uint64(data[8+8*i : 16+8*i]))
+		if relative != 0 {
+			index.offsets[i] = position + relative
+		}
+	}
+	return index, nil
+}
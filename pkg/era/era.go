@@ -0,0 +1,123 @@
+package era
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	moduleName = "era"
+	log        = logrus.WithField(
+		"module", moduleName)
+)
+
+// File is an ERA file: the blocks of SLOTS_PER_HISTORICAL_ROOT slots followed by the state at the end of them.
+// https://github.com/status-im/nimbus-eth2/blob/stable/docs/e2store.md#era-files
+type File struct {
+	path      string
+	file      *os.File
+	schedule  ForkSchedule
+	chainSpec *local_spec.ChainSpec
+	blocks    slotIndex // empty for the genesis era
+	state     slotIndex
+}
+
+// Open reads the slot indexes of the ERA file, the schedule and chain spec are used to decode its blocks and state
+func Open(path string, schedule ForkSchedule, chainSpec *local_spec.ChainSpec) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	eraFile := &File{
+		path:      path,
+		file:      file,
+		schedule:  schedule,
+		chainSpec: chainSpec,
+	}
+	err = eraFile.readIndexes()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not open era file %s: %s", path, err)
+	}
+	return eraFile, nil
+}
+
+func (f *File) readIndexes() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	typ, _, err := readHeader(f.file, 0)
+	if err != nil {
+		return err
+	}
+	if typ != typeVersion {
+		return fmt.Errorf("missing version record")
+	}
+
+	f.state, err = readSlotIndex(f.file, info.Size())
+	if err != nil {
+		return fmt.Errorf("state index: %s", err)
+	}
+	if len(f.state.offsets) != 1 || f.state.offsets[0] == 0 {
+		return fmt.Errorf("state index has to point to one state")
+	}
+
+	// the genesis era only contains the genesis state
+	if f.state.startSlot == 0 {
+		return nil
+	}
+	f.blocks, err = readSlotIndex(f.file, f.state.position)
+	if err != nil {
+		return fmt.Errorf("block index: %s", err)
+	}
+	if f.blocks.startSlot+uint64(len(f.blocks.offsets)) != f.state.startSlot {
+		return fmt.Errorf("blocks from slot %d do not end at the state slot %d", f.blocks.startSlot, f.state.startSlot)
+	}
+	return nil
+}
+
+// StateSlot returns the slot of the stored state
+func (f *File) StateSlot() phase0.Slot {
+	return phase0.Slot(f.state.startSlot)
+}
+
+// Covers returns whether the slot belongs to the blocks of this file
+func (f *File) Covers(slot phase0.Slot) bool {
+	return uint64(slot) >= f.blocks.startSlot && uint64(slot) < f.blocks.startSlot+uint64(len(f.blocks.offsets))
+}
+
+// Block returns the block proposed at the slot, nil if the slot was missed
+func (f *File) Block(slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, error) {
+	if !f.Covers(slot) {
+		return nil, fmt.Errorf("slot %d is not in era file %s", slot, f.path)
+	}
+	offset := f.blocks.offsets[uint64(slot)-f.blocks.startSlot]
+	if offset == 0 {
+		return nil, nil
+	}
+	sszBytes, err := readCompressed(f.file, offset, typeCompressedSignedBeaconBlock)
+	if err != nil {
+		return nil, err
+	}
+	return local_spec.DecodeSignedBeaconBlock(f.schedule.Version(f.chainSpec.EpochAtSlot(slot)), sszBytes, false)
+}
+
+// State returns the state at StateSlot
+func (f *File) State() (*spec.VersionedBeaconState, error) {
+	sszBytes, err := readCompressed(f.file, f.state.offsets[0], typeCompressedBeaconState)
+	if err != nil {
+		return nil, err
+	}
+	return local_spec.DecodeBeaconState(f.schedule.Version(f.chainSpec.EpochAtSlot(f.StateSlot())), sszBytes, false)
+}
+
+func (f *File) Close() error {
+	return f.file.Close()
+}
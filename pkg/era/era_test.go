@@ -0,0 +1,107 @@
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang/snappy"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func appendRecord(file []byte, typ recordType, data []byte) []byte {
+	header := make([]byte, headerSize)
+	copy(header, typ[:])
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(data)))
+	return append(append(file, header...), data...)
+}
+
+func appendCompressed(t *testing.T, file []byte, typ recordType, sszBytes []byte) []byte {
+	var compressed bytes.Buffer
+	writer := snappy.NewBufferedWriter(&compressed)
+	_, err := writer.Write(sszBytes)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	return appendRecord(file, typ, compressed.Bytes())
+}
+
+func appendSlotIndex(file []byte, startSlot uint64, offsets []int64) []byte {
+	position := int64(len(file))
+	data := make([]byte, 16+8*len(offsets))
+	binary.LittleEndian.PutUint64(data[0:8], startSlot)
+	for i, offset := range offsets {
+		if offset != 0 {
+			binary.LittleEndian.PutUint64(data[8+8*i:], uint64(offset-position))
+		}
+	}
+	binary.LittleEndian.PutUint64(data[len(data)-8:], uint64(len(offsets)))
+	return appendRecord(file, typeSlotIndex, data)
+}
+
+func TestEraFile(t *testing.T) {
+	chainSpec := local_spec.MainnetChainSpec()
+	historicalRoot := phase0.Slot(chainSpec.SlotsPerHistoricalRoot)
+	slot := historicalRoot + 5
+	block := &phase0.SignedBeaconBlock{
+		Message: &phase0.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: 42,
+			StateRoot:     phase0.Root{0x01},
+			Body: &phase0.BeaconBlockBody{
+				ETH1Data: &phase0.ETH1Data{DepositRoot: phase0.Root{}, BlockHash: make([]byte, 32)},
+			},
+		},
+	}
+	sszBytes, err := block.MarshalSSZ()
+	assert.Nil(t, err)
+
+	file := appendRecord(nil, typeVersion, nil)
+	blockOffset := int64(len(file))
+	file = appendCompressed(t, file, typeCompressedSignedBeaconBlock, sszBytes)
+	stateOffset := int64(len(file))
+	file = appendCompressed(t, file, typeCompressedBeaconState, []byte{0x00})
+
+	blockOffsets := make([]int64, historicalRoot)
+	blockOffsets[slot-historicalRoot] = blockOffset
+	file = appendSlotIndex(file, uint64(historicalRoot), blockOffsets)
+	file = appendSlotIndex(file, uint64(2*historicalRoot), []int64{stateOffset})
+
+	path := filepath.Join(t.TempDir(), "mainnet-00002-00000000.era")
+	assert.Nil(t, os.WriteFile(path, file, 0o644))
+
+	eraFile, err := Open(path, KnownForkSchedules["mainnet"], chainSpec)
+	assert.Nil(t, err)
+	defer eraFile.Close()
+
+	assert.Equal(t, 2*historicalRoot, eraFile.StateSlot())
+	assert.True(t, eraFile.Covers(slot))
+	assert.False(t, eraFile.Covers(2*historicalRoot))
+
+	read, err := eraFile.Block(slot)
+	assert.Nil(t, err)
+	assert.Equal(t, spec.DataVersionPhase0, read.Version)
+	assert.Equal(t, phase0.ValidatorIndex(42), read.Phase0.Message.ProposerIndex)
+
+	missed, err := eraFile.Block(slot + 1)
+	assert.Nil(t, err)
+	assert.Nil(t, missed)
+
+	archive, err := NewArchive(filepath.Dir(path), nil)
+	assert.Nil(t, err)
+	assert.True(t, archive.Covers(slot))
+	assert.False(t, archive.Covers(slot+historicalRoot))
+	assert.True(t, archive.HasState(2*historicalRoot))
+}
+
+func TestForkSchedule(t *testing.T) {
+	mainnet := KnownForkSchedules["mainnet"]
+	assert.Equal(t, spec.DataVersionPhase0, mainnet.Version(74239))
+	assert.Equal(t, spec.DataVersionAltair, mainnet.Version(74240))
+	assert.Equal(t, spec.DataVersionDeneb, mainnet.Version(300000))
+	assert.Equal(t, spec.DataVersionBellatrix, KnownForkSchedules["holesky"].Version(0))
+}
@@ -0,0 +1,49 @@
+package era
+
+import (
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ForkSchedule holds the activation epoch of every scheduled fork.
+// ERA files do not say which fork their blocks belong to, so it is needed to decode them
+type ForkSchedule map[spec.DataVersion]phase0.Epoch
+
+// KnownForkSchedules are used when no schedule is given, the network is read from the file name
+var KnownForkSchedules = map[string]ForkSchedule{
+	"mainnet": {
+		spec.DataVersionPhase0:    0,
+		spec.DataVersionAltair:    74240,
+		spec.DataVersionBellatrix: 144896,
+		spec.DataVersionCapella:   194048,
+		spec.DataVersionDeneb:     269568,
+		spec.DataVersionElectra:   364032,
+	},
+	"sepolia": {
+		spec.DataVersionPhase0:    0,
+		spec.DataVersionAltair:    50,
+		spec.DataVersionBellatrix: 100,
+		spec.DataVersionCapella:   56832,
+		spec.DataVersionDeneb:     132608,
+		spec.DataVersionElectra:   222464,
+	},
+	"holesky": {
+		spec.DataVersionPhase0:    0,
+		spec.DataVersionAltair:    0,
+		spec.DataVersionBellatrix: 0,
+		spec.DataVersionCapella:   256,
+		spec.DataVersionDeneb:     29696,
+		spec.DataVersionElectra:   115968,
+	},
+}
+
+// Version returns the fork active at the given epoch
+func (f ForkSchedule) Version(epoch phase0.Epoch) spec.DataVersion {
+	version := spec.DataVersionPhase0
+	for forkVersion, forkEpoch := range f {
+		if forkEpoch <= epoch && forkVersion > version {
+			version = forkVersion
+		}
+	}
+	return version
+}
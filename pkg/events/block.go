@@ -0,0 +1,40 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+func (e *Events) SubscribeToBlockEvents() error {
+	// the arrival latency is measured from the start of the slot
+	e.genesis = uint64(e.cli.RequestGenesis().Unix())
+
+	err := e.cli.SubscribeEvents(e.ctx, []string{"block"}, e.HandleBlockEvent) // every new block
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to block events: %s", err)
+	}
+	log.Infof("subscribed to block events")
+	return nil
+}
+
+func (e *Events) HandleBlockEvent(event *api.Event) {
+	timestamp := time.Now()
+	if event.Data == nil {
+		return
+	}
+	data := event.Data.(*api.BlockEvent) // cast to block event
+	arrival := spec.NewBlockArrival(e.cli.ChainSpec, e.genesis, data.Slot, data.Block, timestamp)
+	if arrival.Late {
+		log.WithField("routine", "block-event").Infof("late block at slot %d: received %s after the slot start",
+			data.Slot, arrival.Latency)
+	}
+
+	select { // the buffer covers the time the head routine is busy
+	case e.BlockChan <- arrival:
+	default:
+		log.WithField("routine", "block-event").Warnf("arrival buffer full, block at slot %d not recorded", data.Slot)
+	}
+}
@@ -4,7 +4,7 @@ import api "github.com/attestantio/go-eth2-client/api/v1"
 
 func (e *Events) SubscribeToReorgsEvents() {
 	// subscribe to head event
-	err := e.cli.Api.Events(e.ctx, []string{"chain_reorg"}, e.HandleReorgEvent) // every reorg
+	err := e.cli.SubscribeEvents(e.ctx, []string{"chain_reorg"}, e.HandleReorgEvent) // every reorg
 	if err != nil {
 		log.Panicf("failed to subscribe to chain_reorg events: %s", err)
 	}
@@ -14,11 +14,13 @@ var (
 	log = logrus.WithField(
 		"module", "Events",
 	)
+	blockArrivalBuffer = 64 // arrivals kept while the head routine is busy, two mainnet epochs
 )
 
 type Events struct {
 	ctx            context.Context
 	cli            *clientapi.APIClient
+	genesis        uint64 // unix time
 	SubscribedHead bool
 	HeadChan       chan db.HeadEvent
 
@@ -26,6 +28,7 @@ type Events struct {
 	FinalizedChan       chan api.FinalizedCheckpointEvent
 	ReorgChan           chan api.ChainReorgEvent
 	BlobSidecarChan     chan spec.BlobSideCarEventWraper
+	BlockChan           chan spec.BlockArrival
 }
 
 func NewEventsObj(iCtx context.Context, iCli *clientapi.APIClient) Events {
@@ -38,5 +41,6 @@ func NewEventsObj(iCtx context.Context, iCli *clientapi.APIClient) Events {
 		FinalizedChan:       make(chan api.FinalizedCheckpointEvent),
 		ReorgChan:           make(chan api.ChainReorgEvent),
 		BlobSidecarChan:     make(chan spec.BlobSideCarEventWraper),
+		BlockChan:           make(chan spec.BlockArrival, blockArrivalBuffer),
 	}
 }
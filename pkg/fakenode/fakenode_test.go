@@ -0,0 +1,86 @@
+package fakenode
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func upstreamNode() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			var call map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&call)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(call["id"]) + `,"result":"0x10"}`))
+		case r.URL.Path == "/eth/v1/beacon/headers/1":
+			http.Error(w, `{"code":404,"message":"not found"}`, http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Eth-Consensus-Version", "deneb")
+			w.Write([]byte(`{"data":{"root":"0x01"}}`))
+		}
+	}))
+}
+
+func get(t *testing.T, url string) (int, string, string) {
+	resp, err := http.Get(url)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Eth-Consensus-Version"), string(body)
+}
+
+func post(t *testing.T, url string, body string) string {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	return string(respBody)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	upstream := upstreamNode()
+	defer upstream.Close()
+
+	recorder, err := NewRecorder(upstream.URL, dir)
+	assert.Nil(t, err)
+	proxy := httptest.NewServer(recorder)
+
+	status, version, body := get(t, proxy.URL+"/eth/v1/beacon/states/head/root")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "deneb", version)
+	assert.Equal(t, `{"data":{"root":"0x01"}}`, body)
+	status, _, _ = get(t, proxy.URL+"/eth/v1/beacon/headers/1")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, `{"jsonrpc":"2.0","id":7,"result":"0x10"}`,
+		post(t, proxy.URL, `{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}`))
+	proxy.Close()
+
+	replay, err := NewReplayServer(dir)
+	assert.Nil(t, err)
+	defer replay.Close()
+
+	status, version, body = get(t, replay.URL+"/eth/v1/beacon/states/head/root")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "deneb", version)
+	assert.Equal(t, `{"data":{"root":"0x01"}}`, body)
+
+	// a recorded 404 is still a missing block
+	status, _, _ = get(t, replay.URL+"/eth/v1/beacon/headers/1")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// the answer carries the id of the new request
+	assert.Equal(t, `{"id":8,"jsonrpc":"2.0","result":"0x10"}`,
+		post(t, replay.URL, `{"jsonrpc":"2.0","id":8,"method":"eth_blockNumber","params":[]}`))
+
+	status, _, _ = get(t, replay.URL+"/eth/v1/beacon/states/finalized/root")
+	assert.Equal(t, http.StatusNotImplemented, status)
+}
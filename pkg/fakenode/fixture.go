@@ -0,0 +1,175 @@
+package fakenode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	moduleName = "fakenode"
+	log        = logrus.WithField(
+		"module", moduleName)
+
+	// subdirectories of a fixtures directory, one per node
+	BeaconFixtures    = "beacon"
+	ExecutionFixtures = "execution"
+
+	metaExtension = ".json"
+	bodyExtension = ".body"
+)
+
+// fixture is a recorded response. The metadata goes to <name>.json and the body,
+// which can be a whole SSZ state, to <name>.body
+type fixture struct {
+	Key         string            `json:"key"`
+	Method      string            `json:"method"`
+	URI         string            `json:"uri"`
+	Status      int               `json:"status"`
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers,omitempty"`     // Eth-Consensus-Version and similar
+	RequestIDs  []json.RawMessage `json:"request_ids,omitempty"` // JSON-RPC ids, in request order
+
+	body []byte
+}
+
+// consensus API headers the client reads from the response
+var replayedHeaders = []string{
+	"Eth-Consensus-Version",
+	"Eth-Execution-Payload-Blinded",
+	"Eth-Execution-Payload-Value",
+	"Eth-Consensus-Block-Value",
+}
+
+// requestKey identifies a request regardless of the JSON-RPC ids,
+// which change on every run. It also returns those ids in request order
+func requestKey(r *http.Request, body []byte) (string, []json.RawMessage) {
+	if r.Method != http.MethodPost {
+		return fmt.Sprintf("%s %s accept=%s", r.Method, r.URL.RequestURI(), r.Header.Get("Accept")), nil
+	}
+
+	var call any
+	err := json.Unmarshal(body, &call)
+	if err != nil {
+		return fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), body), nil
+	}
+	ids := make([]json.RawMessage, 0)
+	switch call := call.(type) {
+	case map[string]any:
+		ids = append(ids, stripID(call))
+	case []any: // batch
+		for _, item := range call {
+			if object, ok := item.(map[string]any); ok {
+				ids = append(ids, stripID(object))
+			}
+		}
+	}
+	normalized, _ := json.Marshal(call) // map keys are sorted
+	return fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), normalized), ids
+}
+
+func stripID(call map[string]any) json.RawMessage {
+	id, _ := json.Marshal(call["id"])
+	delete(call, "id")
+	return id
+}
+
+// fileName is derived from the key, so the same request always lands in the same file
+func fileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:16])
+}
+
+func (f *fixture) write(dir string) error {
+	name := filepath.Join(dir, fileName(f.Key))
+	meta, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the body goes first, a fixture is only loaded once its metadata exists
+	err = writeAtomic(name+bodyExtension, f.body)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(name+metaExtension, meta)
+}
+
+func writeAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadFixtures reads every fixture in dir by key
+func loadFixtures(dir string) (map[string]*fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+metaExtension))
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make(map[string]*fixture, len(paths))
+	for _, path := range paths {
+		meta, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f := &fixture{}
+		err = json.Unmarshal(meta, f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read fixture %s: %s", path, err)
+		}
+		f.body, err = os.ReadFile(strings.TrimSuffix(path, metaExtension) + bodyExtension)
+		if err != nil {
+			return nil, fmt.Errorf("could not read fixture body of %s: %s", path, err)
+		}
+		fixtures[f.Key] = f
+	}
+	return fixtures, nil
+}
+
+// responseBody returns the recorded body with the JSON-RPC ids of the new request
+func (f *fixture) responseBody(ids []json.RawMessage) []byte {
+	if len(f.RequestIDs) == 0 || len(ids) != len(f.RequestIDs) {
+		return f.body
+	}
+	newIDs := make(map[string]json.RawMessage, len(ids))
+	for i, id := range f.RequestIDs {
+		newIDs[string(id)] = ids[i]
+	}
+
+	replace := func(object map[string]json.RawMessage) {
+		if id, ok := newIDs[string(bytes.TrimSpace(object["id"]))]; ok {
+			object["id"] = id
+		}
+	}
+
+	var single map[string]json.RawMessage
+	if json.Unmarshal(f.body, &single) == nil {
+		replace(single)
+		body, err := json.Marshal(single)
+		if err == nil {
+			return body
+		}
+	}
+	var batch []map[string]json.RawMessage
+	if json.Unmarshal(f.body, &batch) == nil {
+		for _, object := range batch {
+			replace(object)
+		}
+		body, err := json.Marshal(batch)
+		if err == nil {
+			return body
+		}
+	}
+	return f.body
+}
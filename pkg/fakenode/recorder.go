@@ -0,0 +1,126 @@
+package fakenode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	eventsPath     = "/eth/v1/events"
+	requestTimeout = 5 * time.Minute // a whole state can take a while
+)
+
+// Recorder proxies every request to the upstream node, beacon API or execution JSON-RPC,
+// and saves each response as a fixture the Replayer can serve afterwards
+type Recorder struct {
+	upstream *url.URL
+	dir      string
+	client   *http.Client
+	events   *httputil.ReverseProxy
+}
+
+func NewRecorder(upstream string, dir string) (*Recorder, error) {
+	upstreamUrl, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	events := httputil.NewSingleHostReverseProxy(upstreamUrl)
+	events.FlushInterval = -1 // server sent events are forwarded as they come
+	return &Recorder{
+		upstream: upstreamUrl,
+		dir:      dir,
+		client:   &http.Client{Timeout: requestTimeout},
+		events:   events,
+	}, nil
+}
+
+func (p *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// a stream is not something to replay
+	if strings.HasPrefix(r.URL.Path, eventsPath) {
+		p.events.ServeHTTP(w, r)
+		return
+	}
+
+	f, err := p.forward(r)
+	if err != nil {
+		log.Errorf("could not forward %s %s: %s", r.Method, r.URL.RequestURI(), err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// server errors are not worth replaying
+	if f.Status < http.StatusInternalServerError {
+		err = f.write(p.dir)
+		if err != nil {
+			log.Errorf("could not save fixture of %s: %s", f.URI, err)
+		}
+	}
+
+	if f.ContentType != "" {
+		w.Header().Set("Content-Type", f.ContentType)
+	}
+	for header, value := range f.Headers {
+		w.Header().Set(header, value)
+	}
+	w.WriteHeader(f.Status)
+	w.Write(f.body)
+}
+
+func (p *Recorder) forward(r *http.Request) (*fixture, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	key, ids := requestKey(r, body)
+
+	target := *p.upstream
+	target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range []string{"Accept", "Content-Type"} {
+		if value := r.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the response: %s", err)
+	}
+
+	f := &fixture{
+		Key:         key,
+		Method:      r.Method,
+		URI:         r.URL.RequestURI(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Headers:     make(map[string]string),
+		RequestIDs:  ids,
+		body:        respBody,
+	}
+	for _, header := range replayedHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			f.Headers[header] = value
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,60 @@
+package fakenode
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// Replayer answers every request with the fixture recorded for it.
+// Requests that were not recorded get a 501, so they are not mistaken for a missing block
+type Replayer struct {
+	fixtures map[string]*fixture
+}
+
+func NewReplayer(dir string) (*Replayer, error) {
+	fixtures, err := loadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+	return &Replayer{
+		fixtures: fixtures,
+	}, nil
+}
+
+// NewReplayServer starts a fake node serving the fixtures in dir, to be closed by the caller
+func NewReplayServer(dir string) (*httptest.Server, error) {
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(replayer), nil
+}
+
+func (p *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, ids := requestKey(r, body)
+	f, ok := p.fixtures[key]
+	if !ok {
+		log.Warnf("no fixture recorded for %s %s", r.Method, r.URL.RequestURI())
+		http.Error(w, fmt.Sprintf("no fixture recorded for %s %s", r.Method, r.URL.RequestURI()), http.StatusNotImplemented)
+		return
+	}
+
+	if f.ContentType != "" {
+		w.Header().Set("Content-Type", f.ContentType)
+	}
+	for header, value := range f.Headers {
+		w.Header().Set(header, value)
+	}
+	w.WriteHeader(f.Status)
+	w.Write(f.responseBody(ids))
+}
@@ -0,0 +1,26 @@
+package spec
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// AttestationDuty is the attestation a validator had to cast in an epoch,
+// with the first block that included it and what it voted for
+type AttestationDuty struct {
+	ValidatorIndex phase0.ValidatorIndex
+	Epoch          phase0.Epoch
+	DutySlot       phase0.Slot
+	CommitteeIndex phase0.CommitteeIndex
+	InclusionSlot  phase0.Slot // 0 when the vote was not included
+	InclusionRoot  phase0.Root
+	HeadRoot       phase0.Root
+	TargetRoot     phase0.Root
+	SourceRoot     phase0.Root
+	CorrectHead    bool // the voted root matches the canonical one
+	CorrectTarget  bool
+	CorrectSource  bool
+}
+
+func (f AttestationDuty) Type() ModelType {
+	return AttestationDutyModel
+}
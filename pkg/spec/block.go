@@ -3,41 +3,50 @@ package spec
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
+	"math/big"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/utils"
+	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/sirupsen/logrus"
 )
 
 // This Wrapper is meant to include all common objects across Ethereum Hard Fork Specs
 type AgnosticBlock struct {
-	Slot              phase0.Slot
-	StateRoot         phase0.Root
-	Root              phase0.Root
-	ParentRoot        phase0.Root
-	ProposerIndex     phase0.ValidatorIndex
-	Graffiti          [32]byte
-	Proposed          bool
-	Attestations      []*phase0.Attestation
-	VotesIncluded     uint64
-	NewVotesIncluded  uint64
-	Deposits          []*phase0.Deposit
-	ProposerSlashings []*phase0.ProposerSlashing
-	AttesterSlashings []*phase0.AttesterSlashing
-	VoluntaryExits    []*phase0.SignedVoluntaryExit
-	SyncAggregate     *altair.SyncAggregate
-	ExecutionPayload  AgnosticExecutionPayload
-	Reward            BlockRewards
-	SSZsize           uint32
-	SnappySize        uint32
-	CompressionTime   time.Duration
-	DecompressionTime time.Duration
-	ManualReward      phase0.Gwei
+	Slot                  phase0.Slot
+	Epoch                 phase0.Epoch
+	StateRoot             phase0.Root
+	Root                  phase0.Root
+	ParentRoot            phase0.Root
+	ProposerIndex         phase0.ValidatorIndex
+	Graffiti              [32]byte
+	Proposed              bool
+	Attestations          []*AgnosticAttestation
+	VotesIncluded         uint64
+	NewVotesIncluded      uint64
+	Packing               BlockPacking // filled once the votes of later blocks are known
+	HeadVoteMisses        uint64       // votes of the slot for another head, filled with the packing
+	Deposits              []*phase0.Deposit
+	ProposerSlashings     []*phase0.ProposerSlashing
+	AttesterSlashings     []*phase0.AttesterSlashing
+	VoluntaryExits        []*phase0.SignedVoluntaryExit
+	SyncAggregate         *altair.SyncAggregate
+	BLSToExecutionChanges []*capella.SignedBLSToExecutionChange // from Capella
+	ExecutionPayload      AgnosticExecutionPayload
+	ExecutionRequests     ExecutionRequests // from Electra
+	Reward                BlockRewards
+	SSZsize               uint32
+	SnappySize            uint32
+	CompressionTime       time.Duration
+	DecompressionTime     time.Duration
+	ManualReward          phase0.Gwei
 }
 
 // This Wrapper is meant to include all common objects across Ethereum Hard Fork Specs
@@ -53,12 +62,81 @@ type AgnosticExecutionPayload struct {
 	BlockNumber          uint64
 	Withdrawals          []*capella.Withdrawal
 	PayloadSize          uint32
+	// from Deneb
+	BlobGasUsed               uint64
+	ExcessBlobGas             uint64
+	BlobBaseFeeUpdateFraction uint64      // 0 when the payload carries no blob gas, raised in Electra
+	ParentBeaconBlockRoot     phase0.Root // committed to the execution block header
+}
+
+// This Wrapper is meant to include all common objects across Ethereum Hard Fork Specs.
+// From Electra an aggregate spans the committees set in CommitteeBits (EIP-7549),
+// its AggregationBits are the concatenation of their members and Data.Index is 0
+type AgnosticAttestation struct {
+	AggregationBits bitfield.Bitlist
+	Data            *phase0.AttestationData
+	CommitteeBits   bitfield.Bitvector64 // from Electra, nil before
+}
+
+func NewPhase0Attestations(attestations []*phase0.Attestation) []*AgnosticAttestation {
+	result := make([]*AgnosticAttestation, 0, len(attestations))
+	for _, attestation := range attestations {
+		result = append(result, &AgnosticAttestation{
+			AggregationBits: attestation.AggregationBits,
+			Data:            attestation.Data,
+		})
+	}
+	return result
+}
+
+func NewElectraAttestations(attestations []*electra.Attestation) []*AgnosticAttestation {
+	result := make([]*AgnosticAttestation, 0, len(attestations))
+	for _, attestation := range attestations {
+		result = append(result, &AgnosticAttestation{
+			AggregationBits: attestation.AggregationBits,
+			Data:            attestation.Data,
+			CommitteeBits:   attestation.CommitteeBits,
+		})
+	}
+	return result
+}
+
+// NewElectraAttesterSlashings keeps the attesting indices of both votes,
+// the only change from phase0 is the size of the lists
+func NewElectraAttesterSlashings(attSlashings []*electra.AttesterSlashing) []*phase0.AttesterSlashing {
+	indexed := func(attestation *electra.IndexedAttestation) *phase0.IndexedAttestation {
+		return &phase0.IndexedAttestation{
+			AttestingIndices: attestation.AttestingIndices,
+			Data:             attestation.Data,
+			Signature:        attestation.Signature,
+		}
+	}
+	result := make([]*phase0.AttesterSlashing, 0, len(attSlashings))
+	for _, attSlashing := range attSlashings {
+		result = append(result, &phase0.AttesterSlashing{
+			Attestation1: indexed(attSlashing.Attestation1),
+			Attestation2: indexed(attSlashing.Attestation2),
+		})
+	}
+	return result
 }
 
 func (f AgnosticBlock) Type() ModelType {
 	return BlockModel
 }
 
+// SlashedValidators returns the validators slashed by the proposer and attester slashings of the block
+func (p AgnosticBlock) SlashedValidators() []phase0.ValidatorIndex {
+	slashedIdxs := make([]phase0.ValidatorIndex, 0)
+	for _, attSlashing := range p.AttesterSlashings {
+		slashedIdxs = append(slashedIdxs, SlashingIntersection(attSlashing.Attestation1.AttestingIndices, attSlashing.Attestation2.AttestingIndices)...)
+	}
+	for _, proposerSlashing := range p.ProposerSlashings {
+		slashedIdxs = append(slashedIdxs, proposerSlashing.SignedHeader1.Message.ProposerIndex)
+	}
+	return slashedIdxs
+}
+
 func (p AgnosticBlock) BlockGasFees() (uint64, uint64, error) {
 	reward := uint64(0)
 	burn := uint64(0)
@@ -79,21 +157,72 @@ func (p AgnosticBlock) BlockGasFees() (uint64, uint64, error) {
 
 }
 
-func GetCustomBlock(block spec.VersionedSignedBeaconBlock) (AgnosticBlock, error) {
+// BlobBaseFee returns the price of a unit of blob gas in the block, in wei, 0 before Deneb.
+// It saturates at the max uint64
+// https://eips.ethereum.org/EIPS/eip-4844#gas-accounting
+func (p AgnosticExecutionPayload) BlobBaseFee() uint64 {
+	return saturateUint64(p.blobBaseFee())
+}
+
+// BlobFees returns the wei burned for the blobs of the block, 0 before Deneb.
+// It saturates at the max uint64
+func (p AgnosticExecutionPayload) BlobFees() uint64 {
+	fees := new(big.Int).SetUint64(p.BlobGasUsed)
+	return saturateUint64(fees.Mul(fees, p.blobBaseFee()))
+}
+
+func (p AgnosticExecutionPayload) blobBaseFee() *big.Int {
+	if p.BlobBaseFeeUpdateFraction == 0 {
+		return new(big.Int)
+	}
+	return fakeExponential(MinBaseFeePerBlobGas, p.ExcessBlobGas, p.BlobBaseFeeUpdateFraction)
+}
+
+func saturateUint64(value *big.Int) uint64 {
+	if !value.IsUint64() {
+		return math.MaxUint64
+	}
+	return value.Uint64()
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using Taylor expansion.
+// The expansion stops once the result is above the max uint64, as it only grows from there
+// https://eips.ethereum.org/EIPS/eip-4844#helpers
+func fakeExponential(factor uint64, numerator uint64, denominator uint64) *big.Int {
+	bigNumerator := new(big.Int).SetUint64(numerator)
+	bigDenominator := new(big.Int).SetUint64(denominator)
+	limit := new(big.Int).Mul(new(big.Int).SetUint64(math.MaxUint64), bigDenominator)
+
+	output := new(big.Int)
+	numeratorAccum := new(big.Int).Mul(new(big.Int).SetUint64(factor), bigDenominator)
+	for i := int64(1); numeratorAccum.Sign() > 0 && output.Cmp(limit) <= 0; i++ {
+		output.Add(output, numeratorAccum)
+		numeratorAccum.Mul(numeratorAccum, bigNumerator)
+		numeratorAccum.Div(numeratorAccum, new(big.Int).Mul(bigDenominator, big.NewInt(i)))
+	}
+	return output.Div(output, bigDenominator)
+}
+
+func GetCustomBlock(block spec.VersionedSignedBeaconBlock, chainSpec *ChainSpec) (AgnosticBlock, error) {
+	var customBlock AgnosticBlock
 	switch block.Version {
 	case spec.DataVersionPhase0:
-		return NewPhase0Block(block), nil
+		customBlock = NewPhase0Block(block)
 	case spec.DataVersionAltair:
-		return NewAltairBlock(block), nil
+		customBlock = NewAltairBlock(block)
 	case spec.DataVersionBellatrix:
-		return NewBellatrixBlock(block), nil
+		customBlock = NewBellatrixBlock(block)
 	case spec.DataVersionCapella:
-		return NewCapellaBlock(block), nil
+		customBlock = NewCapellaBlock(block)
 	case spec.DataVersionDeneb:
-		return NewDenebBlock(block), nil
+		customBlock = NewDenebBlock(block)
+	case spec.DataVersionElectra:
+		customBlock = NewElectraBlock(block)
 	default:
 		return AgnosticBlock{}, fmt.Errorf("could not figure out the Beacon Block Fork Version: %s", block.Version)
 	}
+	customBlock.Epoch = chainSpec.EpochAtSlot(customBlock.Slot)
+	return customBlock, nil
 }
 
 func NewPhase0Block(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
@@ -115,7 +244,7 @@ func NewPhase0Block(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 		ProposerIndex:     block.Phase0.Message.ProposerIndex,
 		Graffiti:          block.Phase0.Message.Body.Graffiti,
 		Proposed:          true,
-		Attestations:      block.Phase0.Message.Body.Attestations,
+		Attestations:      NewPhase0Attestations(block.Phase0.Message.Body.Attestations),
 		Deposits:          block.Phase0.Message.Body.Deposits,
 		ProposerSlashings: block.Phase0.Message.Body.ProposerSlashings,
 		AttesterSlashings: block.Phase0.Message.Body.AttesterSlashings,
@@ -157,7 +286,7 @@ func NewAltairBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 		ProposerIndex:     block.Altair.Message.ProposerIndex,
 		Graffiti:          block.Altair.Message.Body.Graffiti,
 		Proposed:          true,
-		Attestations:      block.Altair.Message.Body.Attestations,
+		Attestations:      NewPhase0Attestations(block.Altair.Message.Body.Attestations),
 		Deposits:          block.Altair.Message.Body.Deposits,
 		ProposerSlashings: block.Altair.Message.Body.ProposerSlashings,
 		AttesterSlashings: block.Altair.Message.Body.AttesterSlashings,
@@ -199,7 +328,7 @@ func NewBellatrixBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 		ProposerIndex:     block.Bellatrix.Message.ProposerIndex,
 		Graffiti:          block.Bellatrix.Message.Body.Graffiti,
 		Proposed:          true,
-		Attestations:      block.Bellatrix.Message.Body.Attestations,
+		Attestations:      NewPhase0Attestations(block.Bellatrix.Message.Body.Attestations),
 		Deposits:          block.Bellatrix.Message.Body.Deposits,
 		ProposerSlashings: block.Bellatrix.Message.Body.ProposerSlashings,
 		AttesterSlashings: block.Bellatrix.Message.Body.AttesterSlashings,
@@ -235,18 +364,19 @@ func NewCapellaBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 		log.Fatalf("could not read root from block %d", block.Capella.Message.Slot)
 	}
 	return AgnosticBlock{
-		Slot:              block.Capella.Message.Slot,
-		Root:              root,
-		ParentRoot:        block.Capella.Message.ParentRoot,
-		ProposerIndex:     block.Capella.Message.ProposerIndex,
-		Graffiti:          block.Capella.Message.Body.Graffiti,
-		Proposed:          true,
-		Attestations:      block.Capella.Message.Body.Attestations,
-		Deposits:          block.Capella.Message.Body.Deposits,
-		ProposerSlashings: block.Capella.Message.Body.ProposerSlashings,
-		AttesterSlashings: block.Capella.Message.Body.AttesterSlashings,
-		VoluntaryExits:    block.Capella.Message.Body.VoluntaryExits,
-		SyncAggregate:     block.Capella.Message.Body.SyncAggregate,
+		Slot:                  block.Capella.Message.Slot,
+		Root:                  root,
+		ParentRoot:            block.Capella.Message.ParentRoot,
+		ProposerIndex:         block.Capella.Message.ProposerIndex,
+		Graffiti:              block.Capella.Message.Body.Graffiti,
+		Proposed:              true,
+		Attestations:          NewPhase0Attestations(block.Capella.Message.Body.Attestations),
+		Deposits:              block.Capella.Message.Body.Deposits,
+		ProposerSlashings:     block.Capella.Message.Body.ProposerSlashings,
+		AttesterSlashings:     block.Capella.Message.Body.AttesterSlashings,
+		VoluntaryExits:        block.Capella.Message.Body.VoluntaryExits,
+		SyncAggregate:         block.Capella.Message.Body.SyncAggregate,
+		BLSToExecutionChanges: block.Capella.Message.Body.BLSToExecutionChanges,
 		ExecutionPayload: AgnosticExecutionPayload{
 			FeeRecipient:  block.Capella.Message.Body.ExecutionPayload.FeeRecipient,
 			GasLimit:      block.Capella.Message.Body.ExecutionPayload.GasLimit,
@@ -277,18 +407,19 @@ func NewDenebBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 		log.Fatalf("could not read root from block %d", block.Deneb.Message.Slot)
 	}
 	return AgnosticBlock{
-		Slot:              block.Deneb.Message.Slot,
-		Root:              root,
-		ParentRoot:        block.Deneb.Message.ParentRoot,
-		ProposerIndex:     block.Deneb.Message.ProposerIndex,
-		Graffiti:          block.Deneb.Message.Body.Graffiti,
-		Proposed:          true,
-		Attestations:      block.Deneb.Message.Body.Attestations,
-		Deposits:          block.Deneb.Message.Body.Deposits,
-		ProposerSlashings: block.Deneb.Message.Body.ProposerSlashings,
-		AttesterSlashings: block.Deneb.Message.Body.AttesterSlashings,
-		VoluntaryExits:    block.Deneb.Message.Body.VoluntaryExits,
-		SyncAggregate:     block.Deneb.Message.Body.SyncAggregate,
+		Slot:                  block.Deneb.Message.Slot,
+		Root:                  root,
+		ParentRoot:            block.Deneb.Message.ParentRoot,
+		ProposerIndex:         block.Deneb.Message.ProposerIndex,
+		Graffiti:              block.Deneb.Message.Body.Graffiti,
+		Proposed:              true,
+		Attestations:          NewPhase0Attestations(block.Deneb.Message.Body.Attestations),
+		Deposits:              block.Deneb.Message.Body.Deposits,
+		ProposerSlashings:     block.Deneb.Message.Body.ProposerSlashings,
+		AttesterSlashings:     block.Deneb.Message.Body.AttesterSlashings,
+		VoluntaryExits:        block.Deneb.Message.Body.VoluntaryExits,
+		SyncAggregate:         block.Deneb.Message.Body.SyncAggregate,
+		BLSToExecutionChanges: block.Deneb.Message.Body.BLSToExecutionChanges,
 		ExecutionPayload: AgnosticExecutionPayload{
 			FeeRecipient:  block.Deneb.Message.Body.ExecutionPayload.FeeRecipient,
 			GasLimit:      block.Deneb.Message.Body.ExecutionPayload.GasLimit,
@@ -300,7 +431,61 @@ func NewDenebBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
 			BlockNumber:   block.Deneb.Message.Body.ExecutionPayload.BlockNumber,
 			Withdrawals:   block.Deneb.Message.Body.ExecutionPayload.Withdrawals,
 			PayloadSize:   uint32(0),
+
+			BlobGasUsed:               block.Deneb.Message.Body.ExecutionPayload.BlobGasUsed,
+			ExcessBlobGas:             block.Deneb.Message.Body.ExecutionPayload.ExcessBlobGas,
+			BlobBaseFeeUpdateFraction: BlobBaseFeeUpdateFraction,
+			ParentBeaconBlockRoot:     block.Deneb.Message.ParentRoot,
+		}, // snappy
+		SSZsize:           compressionMetrics.SSZsize,
+		SnappySize:        compressionMetrics.SnappySize,
+		CompressionTime:   compressionMetrics.CompressionTime,
+		DecompressionTime: compressionMetrics.DecompressionTime,
+	}
+}
+
+func NewElectraBlock(block spec.VersionedSignedBeaconBlock) AgnosticBlock {
+	// make the compression of the block
+	compressionMetrics, err := utils.CompressConsensusSignedBlock(block.Electra)
+	if err != nil {
+		logrus.Errorf("unable to compress electra block %d - %s", block.Electra.Message.Slot, err.Error())
+	}
+	root, err := block.Root()
+	if err != nil {
+		log.Fatalf("could not read root from block %d", block.Electra.Message.Slot)
+	}
+	return AgnosticBlock{
+		Slot:                  block.Electra.Message.Slot,
+		Root:                  root,
+		ParentRoot:            block.Electra.Message.ParentRoot,
+		ProposerIndex:         block.Electra.Message.ProposerIndex,
+		Graffiti:              block.Electra.Message.Body.Graffiti,
+		Proposed:              true,
+		Attestations:          NewElectraAttestations(block.Electra.Message.Body.Attestations),
+		Deposits:              block.Electra.Message.Body.Deposits,
+		ProposerSlashings:     block.Electra.Message.Body.ProposerSlashings,
+		AttesterSlashings:     NewElectraAttesterSlashings(block.Electra.Message.Body.AttesterSlashings),
+		VoluntaryExits:        block.Electra.Message.Body.VoluntaryExits,
+		SyncAggregate:         block.Electra.Message.Body.SyncAggregate,
+		BLSToExecutionChanges: block.Electra.Message.Body.BLSToExecutionChanges,
+		ExecutionPayload: AgnosticExecutionPayload{
+			FeeRecipient:  block.Electra.Message.Body.ExecutionPayload.FeeRecipient,
+			GasLimit:      block.Electra.Message.Body.ExecutionPayload.GasLimit,
+			GasUsed:       block.Electra.Message.Body.ExecutionPayload.GasUsed,
+			Timestamp:     block.Electra.Message.Body.ExecutionPayload.Timestamp,
+			BaseFeePerGas: block.Electra.Message.Body.ExecutionPayload.BaseFeePerGas.Uint64(),
+			BlockHash:     block.Electra.Message.Body.ExecutionPayload.BlockHash,
+			Transactions:  block.Electra.Message.Body.ExecutionPayload.Transactions,
+			BlockNumber:   block.Electra.Message.Body.ExecutionPayload.BlockNumber,
+			Withdrawals:   block.Electra.Message.Body.ExecutionPayload.Withdrawals,
+			PayloadSize:   uint32(0),
+
+			BlobGasUsed:               block.Electra.Message.Body.ExecutionPayload.BlobGasUsed,
+			ExcessBlobGas:             block.Electra.Message.Body.ExecutionPayload.ExcessBlobGas,
+			BlobBaseFeeUpdateFraction: BlobBaseFeeUpdateFractionElectra,
+			ParentBeaconBlockRoot:     block.Electra.Message.ParentRoot,
 		}, // snappy
+		ExecutionRequests: NewExecutionRequests(block.Electra.Message.Slot, block.Electra.Message.Body.ExecutionRequests),
 		SSZsize:           compressionMetrics.SSZsize,
 		SnappySize:        compressionMetrics.SnappySize,
 		CompressionTime:   compressionMetrics.CompressionTime,
@@ -0,0 +1,35 @@
+package spec
+
+import (
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// BlockArrival is the moment a block was received from the beacon node `block` event
+type BlockArrival struct {
+	Slot                     phase0.Slot
+	Root                     phase0.Root
+	ArrivalTimestamp         int64         // unix time in milliseconds
+	Latency                  time.Duration // since the start of the slot
+	Late                     bool          // arrived after LateBlockThreshold
+	AfterAttestationDeadline bool          // arrived after the attesters of the slot had to vote
+}
+
+func (f BlockArrival) Type() ModelType {
+	return BlockArrivalModel
+}
+
+// NewBlockArrival measures the latency of a block received at arrival, from the start of its slot
+func NewBlockArrival(chainSpec *ChainSpec, genesis uint64, slot phase0.Slot, root phase0.Root, arrival time.Time) BlockArrival {
+	slotStart := time.Unix(int64(chainSpec.SlotTime(genesis, slot)), 0)
+	latency := arrival.Sub(slotStart)
+	return BlockArrival{
+		Slot:                     slot,
+		Root:                     root,
+		ArrivalTimestamp:         arrival.UnixMilli(),
+		Latency:                  latency,
+		Late:                     latency > LateBlockThreshold,
+		AfterAttestationDeadline: latency > chainSpec.AttestationDeadline(),
+	}
+}
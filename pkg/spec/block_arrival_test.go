@@ -0,0 +1,30 @@
+package spec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBlockArrival(t *testing.T) {
+	chainSpec := MainnetChainSpec()
+	slotStart := time.Unix(MainnetGenesis+10*12, 0)
+
+	arrival := NewBlockArrival(chainSpec, MainnetGenesis, 10, phase0.Root{0x01}, slotStart.Add(1500*time.Millisecond))
+	assert.Equal(t, 1500*time.Millisecond, arrival.Latency)
+	assert.False(t, arrival.Late)
+	assert.False(t, arrival.AfterAttestationDeadline)
+
+	// attesters vote 4 seconds into the slot
+	arrival = NewBlockArrival(chainSpec, MainnetGenesis, 10, phase0.Root{0x01}, slotStart.Add(4100*time.Millisecond))
+	assert.True(t, arrival.Late)
+	assert.True(t, arrival.AfterAttestationDeadline)
+	assert.Equal(t, slotStart.Add(4100*time.Millisecond).UnixMilli(), arrival.ArrivalTimestamp)
+
+	chainSpec.SecondsPerSlot = 5
+	arrival = NewBlockArrival(chainSpec, MainnetGenesis, 10, phase0.Root{0x01}, time.Unix(MainnetGenesis+10*5, 0).Add(2*time.Second))
+	assert.False(t, arrival.Late)
+	assert.True(t, arrival.AfterAttestationDeadline)
+}
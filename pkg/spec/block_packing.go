@@ -0,0 +1,20 @@
+package spec
+
+import "github.com/attestantio/go-eth2-client/spec/phase0"
+
+// BlockPacking measures how well a proposer packed attestations in its block
+type BlockPacking struct {
+	RedundantAggregates uint64      // aggregates that did not add any new vote
+	SubsetAggregates    uint64      // aggregates contained in another one of the block with the same data
+	UniqueNewVotes      uint64      // votes included for the first time
+	CapturedReward      phase0.Gwei // proposer reward of the new votes
+	AvailableReward     phase0.Gwei // proposer reward of every vote the block could have included, as seen in later blocks
+}
+
+// Efficiency returns the share of the available attestation reward that was captured
+func (p BlockPacking) Efficiency() float64 {
+	if p.AvailableReward == 0 {
+		return 0
+	}
+	return float64(p.CapturedReward) / float64(p.AvailableReward)
+}
@@ -0,0 +1,75 @@
+package spec
+
+import (
+	"math"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/holiman/uint256"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobBaseFee(t *testing.T) {
+	// before Deneb there are no blob fees
+	payload := AgnosticExecutionPayload{}
+	assert.Equal(t, uint64(0), payload.BlobBaseFee())
+	assert.Equal(t, uint64(0), payload.BlobFees())
+
+	// no excess blob gas keeps the minimum price
+	payload = AgnosticExecutionPayload{BlobGasUsed: 3 * 131072, BlobBaseFeeUpdateFraction: BlobBaseFeeUpdateFraction}
+	assert.Equal(t, uint64(1), payload.BlobBaseFee())
+	assert.Equal(t, uint64(3*131072), payload.BlobFees())
+
+	// the price grows e times every update fraction of excess blob gas
+	payload.ExcessBlobGas = BlobBaseFeeUpdateFraction
+	assert.Equal(t, uint64(2), payload.BlobBaseFee())
+	payload.ExcessBlobGas = 10 * BlobBaseFeeUpdateFraction
+	assert.Equal(t, uint64(22026), payload.BlobBaseFee())
+	assert.Equal(t, uint64(22026*3*131072), payload.BlobFees())
+
+	// the fees overflow before the price does
+	payload.ExcessBlobGas = 40 * BlobBaseFeeUpdateFraction
+	assert.Less(t, payload.BlobBaseFee(), uint64(math.MaxUint64))
+	assert.Equal(t, uint64(math.MaxUint64), payload.BlobFees())
+
+	// prices out of range saturate
+	payload.ExcessBlobGas = math.MaxUint64
+	assert.Equal(t, uint64(math.MaxUint64), payload.BlobBaseFee())
+	assert.Equal(t, uint64(math.MaxUint64), payload.BlobFees())
+
+	// from Electra the price grows slower
+	payload.ExcessBlobGas = 10 * BlobBaseFeeUpdateFraction
+	payload.BlobBaseFeeUpdateFraction = BlobBaseFeeUpdateFractionElectra
+	assert.Equal(t, uint64(785), payload.BlobBaseFee())
+}
+
+func TestNewDenebBlock(t *testing.T) {
+	block := spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionDeneb,
+		Deneb: &deneb.SignedBeaconBlock{
+			Message: &deneb.BeaconBlock{
+				Slot:       100,
+				ParentRoot: phase0.Root{0x01},
+				Body: &deneb.BeaconBlockBody{
+					ETH1Data:      &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+					SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()},
+					ExecutionPayload: &deneb.ExecutionPayload{
+						BaseFeePerGas: uint256.NewInt(7),
+						BlobGasUsed:   131072,
+					},
+				},
+			},
+		},
+	}
+
+	customBlock := NewDenebBlock(block)
+	assert.Equal(t, phase0.Slot(100), customBlock.Slot)
+	// the execution block header commits to the parent of the beacon block
+	assert.Equal(t, phase0.Root{0x01}, customBlock.ExecutionPayload.ParentBeaconBlockRoot)
+	assert.Equal(t, uint64(7), customBlock.ExecutionPayload.BaseFeePerGas)
+	assert.Equal(t, uint64(131072), customBlock.ExecutionPayload.BlobFees())
+}
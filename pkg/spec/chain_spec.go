@@ -0,0 +1,296 @@
+package spec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ChainSpec holds the chain parameters that change between presets and networks,
+// as served by the beacon node at /eth/v1/config/spec
+type ChainSpec struct {
+	ConfigName                        string
+	SecondsPerSlot                    uint64
+	SlotsPerEpoch                     uint64
+	SlotsPerHistoricalRoot            uint64
+	SyncCommitteeSize                 uint64
+	EpochsPerSyncCommitteePeriod      uint64
+	MaxEffectiveBalance               phase0.Gwei
+	EffectiveBalanceIncrement         phase0.Gwei
+	BaseRewardFactor                  uint64
+	BaseRewardsPerEpoch               uint64 // phase0 only
+	ProposerRewardQuotient            uint64
+	WhistleblowerRewardQuotientPhase0 uint64
+	MinAttestationInclusionDelay      uint64
+
+	// reward weights, introduced in Altair
+	TimelySourceWeight uint64
+	TimelyTargetWeight uint64
+	TimelyHeadWeight   uint64
+	SyncRewardWeight   uint64
+	ProposerWeight     uint64
+	WeightDenominator  uint64
+
+	// penalties, the quotients and multipliers changed in Altair and Bellatrix
+	InactivityScoreBias                     uint64
+	MinEpochsToInactivityPenalty            uint64
+	EpochsPerSlashingsVector                uint64
+	InactivityPenaltyQuotientPhase0         uint64
+	MinSlashingPenaltyQuotientPhase0        uint64
+	ProportionalSlashingMultiplierPhase0    uint64
+	InactivityPenaltyQuotientAltair         uint64
+	InactivityPenaltyQuotientBellatrix      uint64
+	MinSlashingPenaltyQuotientAltair        uint64
+	MinSlashingPenaltyQuotientBellatrix     uint64
+	ProportionalSlashingMultiplierAltair    uint64
+	ProportionalSlashingMultiplierBellatrix uint64
+
+	// slashings, the quotients changed in Electra
+	MinSlashingPenaltyQuotientElectra  uint64
+	WhistleblowerRewardQuotientElectra uint64
+
+	ForkEpochs map[spec.DataVersion]phase0.Epoch // epoch at which every scheduled fork activates
+}
+
+// MainnetChainSpec returns the parameters of the mainnet preset,
+// used when the beacon node does not serve a value
+func MainnetChainSpec() *ChainSpec {
+	return &ChainSpec{
+		ConfigName:                        "mainnet",
+		SecondsPerSlot:                    12,
+		SlotsPerEpoch:                     32,
+		SlotsPerHistoricalRoot:            8192,
+		SyncCommitteeSize:                 512,
+		EpochsPerSyncCommitteePeriod:      256,
+		MaxEffectiveBalance:               32 * EffectiveBalanceInc,
+		EffectiveBalanceIncrement:         EffectiveBalanceInc,
+		BaseRewardFactor:                  64,
+		BaseRewardsPerEpoch:               4,
+		ProposerRewardQuotient:            8,
+		WhistleblowerRewardQuotientPhase0: 512,
+		MinAttestationInclusionDelay:      1,
+		TimelySourceWeight:                14,
+		TimelyTargetWeight:                26,
+		TimelyHeadWeight:                  14,
+		SyncRewardWeight:                  2,
+		ProposerWeight:                    8,
+		WeightDenominator:                 64,
+
+		InactivityScoreBias:                     4,
+		MinEpochsToInactivityPenalty:            4,
+		EpochsPerSlashingsVector:                8192,
+		InactivityPenaltyQuotientPhase0:         1 << 26,
+		MinSlashingPenaltyQuotientPhase0:        128,
+		ProportionalSlashingMultiplierPhase0:    1,
+		InactivityPenaltyQuotientAltair:         3 << 24,
+		InactivityPenaltyQuotientBellatrix:      1 << 24,
+		MinSlashingPenaltyQuotientAltair:        64,
+		MinSlashingPenaltyQuotientBellatrix:     32,
+		ProportionalSlashingMultiplierAltair:    2,
+		ProportionalSlashingMultiplierBellatrix: 3,
+
+		MinSlashingPenaltyQuotientElectra:  4096,
+		WhistleblowerRewardQuotientElectra: 4096,
+
+		ForkEpochs: map[spec.DataVersion]phase0.Epoch{spec.DataVersionPhase0: 0},
+	}
+}
+
+var forkEpochKeys = map[spec.DataVersion]string{
+	spec.DataVersionAltair:    "ALTAIR_FORK_EPOCH",
+	spec.DataVersionBellatrix: "BELLATRIX_FORK_EPOCH",
+	spec.DataVersionCapella:   "CAPELLA_FORK_EPOCH",
+	spec.DataVersionDeneb:     "DENEB_FORK_EPOCH",
+	spec.DataVersionElectra:   "ELECTRA_FORK_EPOCH",
+}
+
+// farFutureEpoch is how the config marks a fork that is not scheduled
+const farFutureEpoch = uint64(18446744073709551615)
+
+// ParseChainSpec reads the parameters from the config returned by the beacon node.
+// Preset values are mandatory, the reward weights default to the mainnet ones as not every client serves them
+func ParseChainSpec(config map[string]any) (*ChainSpec, error) {
+	chainSpec := MainnetChainSpec()
+
+	configName, ok := config["CONFIG_NAME"].(string)
+	if ok {
+		chainSpec.ConfigName = configName
+	}
+	// the client parses every SECONDS_PER_ value as a duration
+	secondsPerSlot, ok := config["SECONDS_PER_SLOT"].(time.Duration)
+	if !ok {
+		return nil, fmt.Errorf("missing SECONDS_PER_SLOT in the chain config")
+	}
+	chainSpec.SecondsPerSlot = uint64(secondsPerSlot / time.Second)
+
+	mandatory := map[string]*uint64{
+		"SLOTS_PER_EPOCH":           &chainSpec.SlotsPerEpoch,
+		"SLOTS_PER_HISTORICAL_ROOT": &chainSpec.SlotsPerHistoricalRoot,
+		"SYNC_COMMITTEE_SIZE":       &chainSpec.SyncCommitteeSize,
+		"BASE_REWARD_FACTOR":        &chainSpec.BaseRewardFactor,
+		"PROPOSER_REWARD_QUOTIENT":  &chainSpec.ProposerRewardQuotient,
+	}
+	optional := map[string]*uint64{
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": &chainSpec.EpochsPerSyncCommitteePeriod,
+		"BASE_REWARDS_PER_EPOCH":           &chainSpec.BaseRewardsPerEpoch,
+		"WHISTLEBLOWER_REWARD_QUOTIENT":    &chainSpec.WhistleblowerRewardQuotientPhase0,
+		"MIN_ATTESTATION_INCLUSION_DELAY":  &chainSpec.MinAttestationInclusionDelay,
+		"TIMELY_SOURCE_WEIGHT":             &chainSpec.TimelySourceWeight,
+		"TIMELY_TARGET_WEIGHT":             &chainSpec.TimelyTargetWeight,
+		"TIMELY_HEAD_WEIGHT":               &chainSpec.TimelyHeadWeight,
+		"SYNC_REWARD_WEIGHT":               &chainSpec.SyncRewardWeight,
+		"PROPOSER_WEIGHT":                  &chainSpec.ProposerWeight,
+		"WEIGHT_DENOMINATOR":               &chainSpec.WeightDenominator,
+
+		"INACTIVITY_SCORE_BIAS":                      &chainSpec.InactivityScoreBias,
+		"MIN_EPOCHS_TO_INACTIVITY_PENALTY":           &chainSpec.MinEpochsToInactivityPenalty,
+		"EPOCHS_PER_SLASHINGS_VECTOR":                &chainSpec.EpochsPerSlashingsVector,
+		"INACTIVITY_PENALTY_QUOTIENT":                &chainSpec.InactivityPenaltyQuotientPhase0,
+		"MIN_SLASHING_PENALTY_QUOTIENT":              &chainSpec.MinSlashingPenaltyQuotientPhase0,
+		"PROPORTIONAL_SLASHING_MULTIPLIER":           &chainSpec.ProportionalSlashingMultiplierPhase0,
+		"INACTIVITY_PENALTY_QUOTIENT_ALTAIR":         &chainSpec.InactivityPenaltyQuotientAltair,
+		"INACTIVITY_PENALTY_QUOTIENT_BELLATRIX":      &chainSpec.InactivityPenaltyQuotientBellatrix,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ALTAIR":       &chainSpec.MinSlashingPenaltyQuotientAltair,
+		"MIN_SLASHING_PENALTY_QUOTIENT_BELLATRIX":    &chainSpec.MinSlashingPenaltyQuotientBellatrix,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_ALTAIR":    &chainSpec.ProportionalSlashingMultiplierAltair,
+		"PROPORTIONAL_SLASHING_MULTIPLIER_BELLATRIX": &chainSpec.ProportionalSlashingMultiplierBellatrix,
+		"MIN_SLASHING_PENALTY_QUOTIENT_ELECTRA":      &chainSpec.MinSlashingPenaltyQuotientElectra,
+		"WHISTLEBLOWER_REWARD_QUOTIENT_ELECTRA":      &chainSpec.WhistleblowerRewardQuotientElectra,
+	}
+	for key, field := range mandatory {
+		value, ok := config[key].(uint64)
+		if !ok {
+			return nil, fmt.Errorf("missing %s in the chain config", key)
+		}
+		*field = value
+	}
+	for key, field := range optional {
+		value, ok := config[key].(uint64)
+		if ok {
+			*field = value
+		}
+	}
+
+	gweiValues := map[string]*phase0.Gwei{
+		"MAX_EFFECTIVE_BALANCE":       &chainSpec.MaxEffectiveBalance,
+		"EFFECTIVE_BALANCE_INCREMENT": &chainSpec.EffectiveBalanceIncrement,
+	}
+	for key, field := range gweiValues {
+		value, ok := config[key].(uint64)
+		if !ok {
+			return nil, fmt.Errorf("missing %s in the chain config", key)
+		}
+		*field = phase0.Gwei(value)
+	}
+	for version, key := range forkEpochKeys {
+		epoch, ok := config[key].(uint64)
+		if !ok || epoch == farFutureEpoch {
+			continue // not scheduled in this network
+		}
+		chainSpec.ForkEpochs[version] = phase0.Epoch(epoch)
+	}
+
+	if chainSpec.SlotsPerEpoch == 0 || chainSpec.SecondsPerSlot == 0 || chainSpec.SlotsPerHistoricalRoot == 0 {
+		return nil, fmt.Errorf("invalid chain config: %d slots per epoch, %d seconds per slot, %d slots per historical root",
+			chainSpec.SlotsPerEpoch, chainSpec.SecondsPerSlot, chainSpec.SlotsPerHistoricalRoot)
+	}
+	return chainSpec, nil
+}
+
+// EpochAtSlot returns the epoch the slot belongs to
+func (c *ChainSpec) EpochAtSlot(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(uint64(slot) / c.SlotsPerEpoch)
+}
+
+// EpochStartSlot returns the first slot of the epoch
+func (c *ChainSpec) EpochStartSlot(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(uint64(epoch) * c.SlotsPerEpoch)
+}
+
+// EpochEndSlot returns the last slot of the epoch
+func (c *ChainSpec) EpochEndSlot(epoch phase0.Epoch) phase0.Slot {
+	return c.EpochStartSlot(epoch+1) - 1
+}
+
+// FirstSlotInEpoch rounds the slot down to the start of its epoch
+func (c *ChainSpec) FirstSlotInEpoch(slot phase0.Slot) phase0.Slot {
+	return c.EpochStartSlot(c.EpochAtSlot(slot))
+}
+
+// IsEpochEnd returns whether the slot is the last one of its epoch
+func (c *ChainSpec) IsEpochEnd(slot phase0.Slot) bool {
+	return uint64(slot)%c.SlotsPerEpoch == c.SlotsPerEpoch-1
+}
+
+// Slots returns a number of epochs in slots
+func (c *ChainSpec) Slots(epochs uint64) phase0.Slot {
+	return phase0.Slot(epochs * c.SlotsPerEpoch)
+}
+
+// SlotTime returns the unix time at which the slot starts
+func (c *ChainSpec) SlotTime(genesis uint64, slot phase0.Slot) uint64 {
+	return genesis + uint64(slot)*c.SecondsPerSlot
+}
+
+// AttestationDeadline returns the time since the start of the slot at which its attesters vote
+func (c *ChainSpec) AttestationDeadline() time.Duration {
+	return time.Duration(c.SecondsPerSlot) * time.Second / IntervalsPerSlot
+}
+
+// ParticipatingFlagsWeight returns the weight of the source, target and head flags
+func (c *ChainSpec) ParticipatingFlagsWeight() [3]uint64 {
+	return [3]uint64{c.TimelySourceWeight, c.TimelyTargetWeight, c.TimelyHeadWeight}
+}
+
+// InactivityPenaltyQuotient returns the inactivity penalty quotient of the fork
+func (c *ChainSpec) InactivityPenaltyQuotient(version spec.DataVersion) uint64 {
+	if version < spec.DataVersionAltair {
+		return c.InactivityPenaltyQuotientPhase0
+	}
+	if version < spec.DataVersionBellatrix {
+		return c.InactivityPenaltyQuotientAltair
+	}
+	return c.InactivityPenaltyQuotientBellatrix
+}
+
+// MinSlashingPenaltyQuotient returns the initial slashing penalty quotient of the fork
+func (c *ChainSpec) MinSlashingPenaltyQuotient(version spec.DataVersion) uint64 {
+	if version < spec.DataVersionAltair {
+		return c.MinSlashingPenaltyQuotientPhase0
+	}
+	if version < spec.DataVersionBellatrix {
+		return c.MinSlashingPenaltyQuotientAltair
+	}
+	if version < spec.DataVersionElectra {
+		return c.MinSlashingPenaltyQuotientBellatrix
+	}
+	return c.MinSlashingPenaltyQuotientElectra
+}
+
+// WhistleblowerRewardQuotient returns the whistleblower reward quotient of the fork
+func (c *ChainSpec) WhistleblowerRewardQuotient(version spec.DataVersion) uint64 {
+	if version < spec.DataVersionElectra {
+		return c.WhistleblowerRewardQuotientPhase0
+	}
+	return c.WhistleblowerRewardQuotientElectra
+}
+
+// ProportionalSlashingMultiplier returns the correlation penalty multiplier of the fork
+func (c *ChainSpec) ProportionalSlashingMultiplier(version spec.DataVersion) uint64 {
+	if version < spec.DataVersionAltair {
+		return c.ProportionalSlashingMultiplierPhase0
+	}
+	if version < spec.DataVersionBellatrix {
+		return c.ProportionalSlashingMultiplierAltair
+	}
+	return c.ProportionalSlashingMultiplierBellatrix
+}
+
+// IsInInactivityLeak returns whether the finality delay, from the previous epoch
+// of the state to its finalized checkpoint, activates the inactivity leak
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#helpers
+func (c *ChainSpec) IsInInactivityLeak(previousEpoch phase0.Epoch, finalizedEpoch phase0.Epoch) bool {
+	return previousEpoch > finalizedEpoch && uint64(previousEpoch-finalizedEpoch) > c.MinEpochsToInactivityPenalty
+}
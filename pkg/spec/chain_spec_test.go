@@ -0,0 +1,90 @@
+package spec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChainSpec(t *testing.T) {
+	// gnosis like config, as parsed by the client
+	config := map[string]any{
+		"CONFIG_NAME":                 "gnosis",
+		"SECONDS_PER_SLOT":            5 * time.Second,
+		"SLOTS_PER_EPOCH":             uint64(16),
+		"SLOTS_PER_HISTORICAL_ROOT":   uint64(8192),
+		"SYNC_COMMITTEE_SIZE":         uint64(512),
+		"BASE_REWARD_FACTOR":          uint64(25),
+		"PROPOSER_REWARD_QUOTIENT":    uint64(8),
+		"MAX_EFFECTIVE_BALANCE":       uint64(32000000000),
+		"EFFECTIVE_BALANCE_INCREMENT": uint64(1000000000),
+		"ALTAIR_FORK_EPOCH":           uint64(512),
+		"DENEB_FORK_EPOCH":            uint64(18446744073709551615),
+	}
+
+	chainSpec, err := ParseChainSpec(config)
+	assert.Nil(t, err)
+	assert.Equal(t, "gnosis", chainSpec.ConfigName)
+	assert.Equal(t, uint64(5), chainSpec.SecondsPerSlot)
+	assert.Equal(t, uint64(16), chainSpec.SlotsPerEpoch)
+	assert.Equal(t, uint64(25), chainSpec.BaseRewardFactor)
+	assert.Equal(t, uint64(64), chainSpec.WeightDenominator) // not served, mainnet value
+	assert.Equal(t, phase0.Epoch(512), chainSpec.ForkEpochs[spec.DataVersionAltair])
+	_, scheduled := chainSpec.ForkEpochs[spec.DataVersionDeneb]
+	assert.False(t, scheduled)
+
+	delete(config, "SLOTS_PER_EPOCH")
+	_, err = ParseChainSpec(config)
+	assert.NotNil(t, err)
+}
+
+func TestChainSpecSlots(t *testing.T) {
+	chainSpec := MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 16
+	chainSpec.SecondsPerSlot = 5
+
+	assert.Equal(t, phase0.Epoch(2), chainSpec.EpochAtSlot(47))
+	assert.Equal(t, phase0.Slot(32), chainSpec.EpochStartSlot(2))
+	assert.Equal(t, phase0.Slot(47), chainSpec.EpochEndSlot(2))
+	assert.Equal(t, phase0.Slot(32), chainSpec.FirstSlotInEpoch(40))
+	assert.True(t, chainSpec.IsEpochEnd(47))
+	assert.False(t, chainSpec.IsEpochEnd(48))
+	assert.Equal(t, phase0.Slot(80), chainSpec.Slots(5))
+	assert.Equal(t, uint64(1000+50), chainSpec.SlotTime(1000, 10))
+}
+
+func TestChainSpecElectra(t *testing.T) {
+	chainSpec, err := ParseChainSpec(map[string]any{
+		"SECONDS_PER_SLOT":                      12 * time.Second,
+		"SLOTS_PER_EPOCH":                       uint64(32),
+		"SLOTS_PER_HISTORICAL_ROOT":             uint64(8192),
+		"SYNC_COMMITTEE_SIZE":                   uint64(512),
+		"BASE_REWARD_FACTOR":                    uint64(64),
+		"PROPOSER_REWARD_QUOTIENT":              uint64(8),
+		"MAX_EFFECTIVE_BALANCE":                 uint64(32000000000),
+		"EFFECTIVE_BALANCE_INCREMENT":           uint64(1000000000),
+		"ELECTRA_FORK_EPOCH":                    uint64(364032),
+		"MIN_SLASHING_PENALTY_QUOTIENT_ELECTRA": uint64(4096),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Epoch(364032), chainSpec.ForkEpochs[spec.DataVersionElectra])
+
+	// the quotients only change from Electra on
+	assert.Equal(t, uint64(32), chainSpec.MinSlashingPenaltyQuotient(spec.DataVersionDeneb))
+	assert.Equal(t, uint64(4096), chainSpec.MinSlashingPenaltyQuotient(spec.DataVersionElectra))
+	assert.Equal(t, uint64(512), chainSpec.WhistleblowerRewardQuotient(spec.DataVersionDeneb))
+	assert.Equal(t, uint64(4096), chainSpec.WhistleblowerRewardQuotient(spec.DataVersionElectra))
+}
+
+func TestChainSpecInactivityLeak(t *testing.T) {
+	chainSpec := MainnetChainSpec()
+
+	// the leak starts when the previous epoch is more than 4 epochs ahead of the finalized one
+	assert.False(t, chainSpec.IsInInactivityLeak(10, 8))
+	assert.False(t, chainSpec.IsInInactivityLeak(10, 6))
+	assert.True(t, chainSpec.IsInInactivityLeak(10, 5))
+	assert.False(t, chainSpec.IsInInactivityLeak(0, 0))
+}
@@ -1,5 +1,7 @@
 package spec
 
+import "time"
+
 const (
 	MainnetGenesis = 1606824023
 	SepoliaGenesis = 1655733600
@@ -8,43 +10,40 @@ const (
 
 /*
 Phase0
+
+The preset values that change between networks are in ChainSpec
 */
 
 const (
-	MaxEffectiveInc             = 32
-	BaseRewardFactor            = 64
-	BaseRewardPerEpoch          = 4
-	EffectiveBalanceInc         = 1000000000
-	SlotsPerEpoch               = 32
-	ProposerRewardQuotient      = 8
-	SlotsPerHistoricalRoot      = 8192
-	SlotSeconds                 = 12
-	EpochSlots                  = 32
-	WhistleBlowerRewardQuotient = 512
-	MinInclusionDelay           = 1
+	EffectiveBalanceInc = 1000000000 // 1 ETH in Gwei, the mainnet effective balance increment
 
 	AttSourceFlagIndex = 0
 	AttTargetFlagIndex = 1
 	AttHeadFlagIndex   = 2
+
+	IntervalsPerSlot   = 3               // attestations are due at the end of the first interval
+	LateBlockThreshold = 4 * time.Second // blocks received later are flagged as late
 )
 
 /*
-Altair
+Deneb
+
+https://eips.ethereum.org/EIPS/eip-4844#parameters
 */
+
 const (
-	// spec weight constants
-	TimelySourceWeight = 14
-	TimelyTargetWeight = 26
-	TimelyHeadWeight   = 14
-
-	SyncRewardWeight  = 2
-	ProposerWeight    = 8
-	WeightDenominator = 64
-	SyncCommitteeSize = 512
+	MinBaseFeePerBlobGas      = 1       // wei
+	BlobBaseFeeUpdateFraction = 3338477 // controls the maximum rate of change of the blob base fee
 )
 
-var (
-	ParticipatingFlagsWeight = [3]int{TimelySourceWeight, TimelyTargetWeight, TimelyHeadWeight}
+/*
+Electra
+
+https://eips.ethereum.org/EIPS/eip-7691#parameters
+*/
+
+const (
+	BlobBaseFeeUpdateFractionElectra = 5007716 // raised along with the blob target
 )
 
 type ModelType int8
@@ -68,6 +67,15 @@ const (
 	ReorgModel
 	FinalizedCheckpointModel
 	HeadEventModel
+	DepositRequestModel
+	WithdrawalRequestModel
+	ConsolidationRequestModel
+	SyncCommitteeParticipationModel
+	AttestationDutyModel
+	ValidatorEventModel
+	BLSToExecutionChangeModel
+	BlockArrivalModel
+	ForkOrphanModel
 )
 
 type ValidatorStatus int8
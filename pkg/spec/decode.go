@@ -0,0 +1,94 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+type sszUnmarshaler interface {
+	UnmarshalSSZ(buf []byte) error
+}
+
+// decode fills the object from SSZ bytes, or from the JSON "data" object when isJSON
+func decode(object any, data []byte, isJSON bool) error {
+	if isJSON {
+		return json.Unmarshal(data, object)
+	}
+	sszObject, ok := object.(sszUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%T cannot be decoded from ssz", object)
+	}
+	return sszObject.UnmarshalSSZ(data)
+}
+
+// DecodeSignedBeaconBlock reads a signed block of the given fork from SSZ, or from JSON when isJSON
+func DecodeSignedBeaconBlock(version spec.DataVersion, data []byte, isJSON bool) (*spec.VersionedSignedBeaconBlock, error) {
+	block := &spec.VersionedSignedBeaconBlock{Version: version}
+	var err error
+	switch version {
+	case spec.DataVersionPhase0:
+		block.Phase0 = &phase0.SignedBeaconBlock{}
+		err = decode(block.Phase0, data, isJSON)
+	case spec.DataVersionAltair:
+		block.Altair = &altair.SignedBeaconBlock{}
+		err = decode(block.Altair, data, isJSON)
+	case spec.DataVersionBellatrix:
+		block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		err = decode(block.Bellatrix, data, isJSON)
+	case spec.DataVersionCapella:
+		block.Capella = &capella.SignedBeaconBlock{}
+		err = decode(block.Capella, data, isJSON)
+	case spec.DataVersionDeneb:
+		block.Deneb = &deneb.SignedBeaconBlock{}
+		err = decode(block.Deneb, data, isJSON)
+	case spec.DataVersionElectra:
+		block.Electra = &electra.SignedBeaconBlock{}
+		err = decode(block.Electra, data, isJSON)
+	default:
+		return nil, fmt.Errorf("unsupported block version %s", version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s block: %s", version, err)
+	}
+	return block, nil
+}
+
+// DecodeBeaconState reads a state of the given fork from SSZ, or from JSON when isJSON
+func DecodeBeaconState(version spec.DataVersion, data []byte, isJSON bool) (*spec.VersionedBeaconState, error) {
+	state := &spec.VersionedBeaconState{Version: version}
+	var err error
+	switch version {
+	case spec.DataVersionPhase0:
+		state.Phase0 = &phase0.BeaconState{}
+		err = decode(state.Phase0, data, isJSON)
+	case spec.DataVersionAltair:
+		state.Altair = &altair.BeaconState{}
+		err = decode(state.Altair, data, isJSON)
+	case spec.DataVersionBellatrix:
+		state.Bellatrix = &bellatrix.BeaconState{}
+		err = decode(state.Bellatrix, data, isJSON)
+	case spec.DataVersionCapella:
+		state.Capella = &capella.BeaconState{}
+		err = decode(state.Capella, data, isJSON)
+	case spec.DataVersionDeneb:
+		state.Deneb = &deneb.BeaconState{}
+		err = decode(state.Deneb, data, isJSON)
+	case spec.DataVersionElectra:
+		state.Electra = &electra.BeaconState{}
+		err = decode(state.Electra, data, isJSON)
+	default:
+		return nil, fmt.Errorf("unsupported state version %s", version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode %s state: %s", version, err)
+	}
+	return state, nil
+}
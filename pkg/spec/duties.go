@@ -1,10 +1,12 @@
 package spec
 
 import (
+	"fmt"
 	"math"
 
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
 )
 
 type EpochDuties struct {
@@ -23,8 +25,37 @@ func (p EpochDuties) GetValList(slot phase0.Slot, committeeIndex phase0.Committe
 	return nil
 }
 
-func GetEffectiveBalance(balance float64) float64 {
-	return math.Min(MaxEffectiveInc*EffectiveBalanceInc, balance)
+// GetAttestingIndicesElectra returns the validators that voted in an aggregate spanning several committees (EIP-7549):
+// the aggregation bits are the concatenation of the committees set in the committee bits, in order
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md#modified-get_attesting_indices
+func (p EpochDuties) GetAttestingIndicesElectra(
+	slot phase0.Slot,
+	committeeBits bitfield.Bitvector64,
+	aggregationBits bitfield.Bitlist) ([]phase0.ValidatorIndex, error) {
+
+	attestingIndices := make([]phase0.ValidatorIndex, 0)
+	offset := uint64(0)
+	for _, committeeIndex := range committeeBits.BitIndices() {
+		committee := p.GetValList(slot, phase0.CommitteeIndex(committeeIndex))
+		if committee == nil {
+			return nil, fmt.Errorf("no committee %d at slot %d", committeeIndex, slot)
+		}
+		for i, valIdx := range committee {
+			if aggregationBits.BitAt(offset + uint64(i)) {
+				attestingIndices = append(attestingIndices, valIdx)
+			}
+		}
+		offset += uint64(len(committee))
+	}
+	if offset != aggregationBits.Len() {
+		return nil, fmt.Errorf("aggregation bits of length %d do not match the %d committee members at slot %d",
+			aggregationBits.Len(), offset, slot)
+	}
+	return attestingIndices, nil
+}
+
+func GetEffectiveBalance(balance float64, maxEffectiveBalance phase0.Gwei) float64 {
+	return math.Min(float64(maxEffectiveBalance), balance)
 }
 
 type ValVote struct {
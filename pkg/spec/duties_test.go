@@ -0,0 +1,40 @@
+package spec
+
+import (
+	"testing"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAttestingIndicesElectra(t *testing.T) {
+	duties := EpochDuties{
+		BeaconCommittees: []*api.BeaconCommittee{
+			{Slot: 10, Index: 0, Validators: []phase0.ValidatorIndex{1, 2, 3}},
+			{Slot: 10, Index: 1, Validators: []phase0.ValidatorIndex{4, 5}},
+			{Slot: 10, Index: 2, Validators: []phase0.ValidatorIndex{6, 7, 8}},
+		},
+	}
+
+	// committees 0 and 2, the bits of committee 2 start after the 3 of committee 0
+	committeeBits := bitfield.NewBitvector64()
+	committeeBits.SetBitAt(0, true)
+	committeeBits.SetBitAt(2, true)
+	aggregationBits := bitfield.NewBitlist(6)
+	aggregationBits.SetBitAt(1, true)
+	aggregationBits.SetBitAt(3, true)
+	aggregationBits.SetBitAt(5, true)
+
+	indices, err := duties.GetAttestingIndicesElectra(10, committeeBits, aggregationBits)
+	assert.Nil(t, err)
+	assert.Equal(t, []phase0.ValidatorIndex{2, 6, 8}, indices)
+
+	// the bits have to cover exactly the committees
+	_, err = duties.GetAttestingIndicesElectra(10, committeeBits, bitfield.NewBitlist(5))
+	assert.NotNil(t, err)
+	committeeBits.SetBitAt(3, true)
+	_, err = duties.GetAttestingIndicesElectra(10, committeeBits, aggregationBits)
+	assert.NotNil(t, err)
+}
@@ -25,6 +25,15 @@ type Epoch struct {
 	NumActiveVals             int
 	NumExitedVals             int
 	NumInActivationVals       int
+	// finality as left by the epoch transition, see ExportToEpoch
+	JustificationBits   uint8 // bit 0 is the epoch itself
+	JustifiedEpoch      phase0.Epoch
+	FinalizedEpoch      phase0.Epoch
+	EpochsSinceFinality uint64
+	InactivityLeak      bool
+	SourceParticipation float32 // percentage of the active balance with the flag
+	TargetParticipation float32
+	HeadParticipation   float32
 }
 
 func (f Epoch) Type() ModelType {
@@ -0,0 +1,92 @@
+package spec
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ExecutionRequests groups the requests a block carries from the execution layer (EIP-7685), from Electra
+type ExecutionRequests struct {
+	Deposits       []DepositRequest
+	Withdrawals    []WithdrawalRequest
+	Consolidations []ConsolidationRequest
+}
+
+// NewExecutionRequests reads the requests of the block at the slot
+func NewExecutionRequests(slot phase0.Slot, requests *electra.ExecutionRequests) ExecutionRequests {
+	result := ExecutionRequests{
+		Deposits:       make([]DepositRequest, 0),
+		Withdrawals:    make([]WithdrawalRequest, 0),
+		Consolidations: make([]ConsolidationRequest, 0),
+	}
+	if requests == nil {
+		return result
+	}
+	for _, deposit := range requests.Deposits {
+		result.Deposits = append(result.Deposits, DepositRequest{
+			Slot:                  slot,
+			Index:                 deposit.Index,
+			Pubkey:                deposit.Pubkey,
+			WithdrawalCredentials: deposit.WithdrawalCredentials,
+			Amount:                deposit.Amount,
+			Signature:             deposit.Signature,
+		})
+	}
+	for _, withdrawal := range requests.Withdrawals {
+		result.Withdrawals = append(result.Withdrawals, WithdrawalRequest{
+			Slot:            slot,
+			SourceAddress:   withdrawal.SourceAddress,
+			ValidatorPubkey: withdrawal.ValidatorPubkey,
+			Amount:          withdrawal.Amount,
+		})
+	}
+	for _, consolidation := range requests.Consolidations {
+		result.Consolidations = append(result.Consolidations, ConsolidationRequest{
+			Slot:          slot,
+			SourceAddress: consolidation.SourceAddress,
+			SourcePubkey:  consolidation.SourcePubkey,
+			TargetPubkey:  consolidation.TargetPubkey,
+		})
+	}
+	return result
+}
+
+// DepositRequest is a deposit processed in-protocol (EIP-6110)
+type DepositRequest struct {
+	Slot                  phase0.Slot
+	Index                 uint64
+	Pubkey                phase0.BLSPubKey
+	WithdrawalCredentials []byte
+	Amount                phase0.Gwei
+	Signature             phase0.BLSSignature
+}
+
+func (f DepositRequest) Type() ModelType {
+	return DepositRequestModel
+}
+
+// WithdrawalRequest is a withdrawal or exit triggered from the execution layer (EIP-7002),
+// a full exit when the amount is 0
+type WithdrawalRequest struct {
+	Slot            phase0.Slot
+	SourceAddress   bellatrix.ExecutionAddress
+	ValidatorPubkey phase0.BLSPubKey
+	Amount          phase0.Gwei
+}
+
+func (f WithdrawalRequest) Type() ModelType {
+	return WithdrawalRequestModel
+}
+
+// ConsolidationRequest moves the balance of the source validator into the target one (EIP-7251)
+type ConsolidationRequest struct {
+	Slot          phase0.Slot
+	SourceAddress bellatrix.ExecutionAddress
+	SourcePubkey  phase0.BLSPubKey
+	TargetPubkey  phase0.BLSPubKey
+}
+
+func (f ConsolidationRequest) Type() ModelType {
+	return ConsolidationRequestModel
+}
@@ -0,0 +1,17 @@
+package spec
+
+import "github.com/attestantio/go-eth2-client/spec/phase0"
+
+// ForkOrphan is a block seen through the events whose branch lost against the finalized chain
+type ForkOrphan struct {
+	Slot          phase0.Slot
+	Root          phase0.Root
+	ParentRoot    phase0.Root
+	ProposerIndex phase0.ValidatorIndex
+	Depth         uint64      // position of the block in its branch, 1 for the first block after the split
+	SplitSlot     phase0.Slot // slot of the canonical block the branch was built on
+}
+
+func (f ForkOrphan) Type() ModelType {
+	return ForkOrphanModel
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// initAttestationDuties creates the duty of every attester in prevState's epoch,
+// filled while the inclusion delays are processed
+func (p *Phase0Metrics) initAttestationDuties() {
+	prevState := p.baseMetrics.PrevState
+
+	p.attestationDuties = make(map[phase0.ValidatorIndex]*spec.AttestationDuty)
+	for _, committee := range prevState.EpochStructs.BeaconCommittees {
+		for _, valIdx := range committee.Validators {
+			p.attestationDuties[valIdx] = &spec.AttestationDuty{
+				ValidatorIndex: valIdx,
+				Epoch:          prevState.Epoch,
+				DutySlot:       committee.Slot,
+				CommitteeIndex: committee.Index,
+			}
+		}
+	}
+}
+
+// recordAttestationDuty keeps the first block that included the vote of the validator,
+// correct holds whether the source, target and head match the canonical chain
+func (p *Phase0Metrics) recordAttestationDuty(
+	valIdx phase0.ValidatorIndex,
+	data *phase0.AttestationData,
+	block *spec.AgnosticBlock,
+	correct [3]bool) {
+
+	duty, ok := p.attestationDuties[valIdx]
+	if !ok || duty.InclusionSlot != 0 {
+		return
+	}
+	duty.InclusionSlot = block.Slot
+	duty.InclusionRoot = block.Root
+	duty.HeadRoot = data.BeaconBlockRoot
+	duty.TargetRoot = data.Target.Root
+	duty.SourceRoot = data.Source.Root
+	duty.CorrectSource = correct[spec.AttSourceFlagIndex]
+	duty.CorrectTarget = correct[spec.AttTargetFlagIndex]
+	duty.CorrectHead = correct[spec.AttHeadFlagIndex]
+}
+
+// GetAttestationDuties returns the duty of every attester in prevState's epoch, with the first block
+// that included its vote and whether the voted roots match the canonical chain.
+// Votes included after currentState are not seen
+func (p Phase0Metrics) GetAttestationDuties() []spec.AttestationDuty {
+	result := make([]spec.AttestationDuty, 0, len(p.attestationDuties))
+	for valIdx := range p.baseMetrics.PrevState.Validators {
+		duty, ok := p.attestationDuties[phase0.ValidatorIndex(valIdx)]
+		if ok {
+			result = append(result, *duty)
+		}
+	}
+	return result
+}
+
+// correctVoteRoots returns whether the source, target and head roots of the vote match the canonical chain
+func (p AltairMetrics) correctVoteRoots(data *phase0.AttestationData) ([3]bool, error) {
+	var result [3]bool
+
+	sourceRoot, err := p.GetJustifiedRootfromSlot(data.Slot)
+	if err != nil {
+		return result, err
+	}
+	targetRoot := p.baseMetrics.NextState.GetBlockRoot(p.baseMetrics.Spec.EpochAtSlot(data.Slot))
+	headRoot := p.baseMetrics.NextState.GetBlockRootAtSlot(data.Slot)
+
+	result[spec.AttSourceFlagIndex] = data.Source.Root == sourceRoot
+	result[spec.AttTargetFlagIndex] = data.Target.Root == targetRoot
+	result[spec.AttHeadFlagIndex] = data.BeaconBlockRoot == headRoot
+	return result, nil
+}
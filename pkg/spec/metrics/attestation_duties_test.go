@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"testing"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAttestationDuties(t *testing.T) {
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 4
+	chainSpec.SlotsPerHistoricalRoot = 16
+
+	justified := phase0.Root{0xaa}
+	blockRoots := make([]phase0.Root, 16)
+	for i := range blockRoots {
+		blockRoots[i] = phase0.Root{byte(i)}
+	}
+	vote := func(slot phase0.Slot, head phase0.Root, bits ...uint64) *spec.AgnosticAttestation {
+		aggregationBits := bitfield.NewBitlist(2)
+		for _, bit := range bits {
+			aggregationBits.SetBitAt(bit, true)
+		}
+		return &spec.AgnosticAttestation{
+			AggregationBits: aggregationBits,
+			Data: &phase0.AttestationData{
+				Slot:            slot,
+				BeaconBlockRoot: head,
+				Source:          &phase0.Checkpoint{Root: justified},
+				Target:          &phase0.Checkpoint{Epoch: 2, Root: blockRoots[8]},
+			},
+		}
+	}
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.PrevState = &spec.AgnosticState{
+		Epoch:                      2,
+		Validators:                 make([]*phase0.Validator, 3),
+		CurrentJustifiedCheckpoint: phase0.Checkpoint{Root: justified},
+		EpochStructs: spec.EpochDuties{BeaconCommittees: []*api.BeaconCommittee{
+			{Slot: 8, Index: 0, Validators: []phase0.ValidatorIndex{0, 1}},
+			{Slot: 9, Index: 0, Validators: []phase0.ValidatorIndex{2}},
+		}},
+		Blocks: []*spec.AgnosticBlock{
+			{Slot: 9, Root: blockRoots[9], Attestations: []*spec.AgnosticAttestation{vote(8, blockRoots[8], 0)}},
+			// validator 0 was already included, validator 1 voted a wrong head
+			{Slot: 10, Root: blockRoots[10], Attestations: []*spec.AgnosticAttestation{vote(8, phase0.Root{0xff}, 0, 1)}},
+		},
+	}
+	metrics.baseMetrics.CurrentState = &spec.AgnosticState{Epoch: 3}
+	metrics.baseMetrics.NextState = &spec.AgnosticState{Epoch: 4, BlockRoots: blockRoots, Spec: chainSpec}
+	metrics.baseMetrics.InclusionDelays = make([]int, 3)
+
+	assert.NoError(t, metrics.ProcessInclusionDelays())
+	duties := metrics.GetAttestationDuties()
+	assert.Equal(t, 3, len(duties))
+
+	assert.Equal(t, phase0.Slot(9), duties[0].InclusionSlot)
+	assert.Equal(t, blockRoots[9], duties[0].InclusionRoot)
+	assert.True(t, duties[0].CorrectHead && duties[0].CorrectTarget && duties[0].CorrectSource)
+
+	assert.Equal(t, phase0.Slot(10), duties[1].InclusionSlot)
+	assert.False(t, duties[1].CorrectHead)
+	assert.True(t, duties[1].CorrectTarget && duties[1].CorrectSource)
+
+	// never included
+	assert.Equal(t, phase0.Slot(9), duties[2].DutySlot)
+	assert.Equal(t, phase0.Slot(0), duties[2].InclusionSlot)
+	assert.False(t, duties[2].CorrectHead || duties[2].CorrectTarget || duties[2].CorrectSource)
+}
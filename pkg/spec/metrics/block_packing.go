@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"bytes"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// voteKey identifies the vote of a validator, whatever data it voted for
+type voteKey struct {
+	slot      phase0.Slot
+	validator phase0.ValidatorIndex
+}
+
+// ProcessBlockPacking measures the attestation packing of every block in currentState
+func (p AltairMetrics) ProcessBlockPacking() error {
+	return p.processBlockPacking(p.getParticipationFlags, func(attSlot phase0.Slot, blockSlot phase0.Slot) bool {
+		return attSlot+phase0.Slot(p.baseMetrics.Spec.SlotsPerEpoch) >= blockSlot
+	})
+}
+
+// ProcessBlockPacking measures the attestation packing of every block in currentState.
+// From deneb, votes can be included until the end of the next epoch
+func (p DenebMetrics) ProcessBlockPacking() error {
+	return p.processBlockPacking(p.getParticipationFlags, func(attSlot phase0.Slot, blockSlot phase0.Slot) bool {
+		return p.baseMetrics.Spec.EpochAtSlot(blockSlot)-p.baseMetrics.Spec.EpochAtSlot(attSlot) <= 1
+	})
+}
+
+// processBlockPacking walks the blocks in order, keeping the votes already on chain.
+// The available reward of a block is estimated from the pending votes that later blocks
+// (until the end of nextState) included, as if they had been included in the block
+func (p AltairMetrics) processBlockPacking(
+	participationFlags func(spec.AgnosticAttestation, spec.AgnosticBlock) ([3]bool, error),
+	includable func(attSlot phase0.Slot, blockSlot phase0.Slot) bool) error {
+
+	onChain := make(map[voteKey]bool)
+	for _, block := range p.baseMetrics.PrevState.Blocks {
+		for _, attestation := range block.Attestations {
+			// older votes cannot be included by currentState's blocks
+			if attestation.Data.Slot < p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.PrevState.Epoch) {
+				continue
+			}
+			for _, valIdx := range p.attestingIndices(*attestation, *block) {
+				onChain[voteKey{attestation.Data.Slot, valIdx}] = true
+			}
+		}
+	}
+
+	laterBlocks := make([]*spec.AgnosticBlock, 0, len(p.baseMetrics.CurrentState.Blocks)+len(p.baseMetrics.NextState.Blocks))
+	laterBlocks = append(laterBlocks, p.baseMetrics.CurrentState.Blocks...)
+	laterBlocks = append(laterBlocks, p.baseMetrics.NextState.Blocks...)
+
+	for i, block := range p.baseMetrics.CurrentState.Blocks {
+		packing := spec.BlockPacking{}
+
+		for j, attestation := range block.Attestations {
+			if isSubsetAggregate(block.Attestations, j) {
+				packing.SubsetAggregates += 1
+			}
+
+			newVotes := uint64(0)
+			for _, valIdx := range p.attestingIndices(*attestation, *block) {
+				key := voteKey{attestation.Data.Slot, valIdx}
+				if onChain[key] {
+					continue
+				}
+				onChain[key] = true
+				newVotes += 1
+				reward, err := p.voteReward(*attestation, *block, valIdx, participationFlags)
+				if err != nil {
+					return err
+				}
+				packing.CapturedReward += reward
+			}
+			if newVotes == 0 {
+				packing.RedundantAggregates += 1
+			}
+			packing.UniqueNewVotes += newVotes
+		}
+
+		// votes the block could have included, as seen in later blocks
+		packing.AvailableReward = packing.CapturedReward
+		missed := make(map[voteKey]bool)
+		for _, laterBlock := range laterBlocks[i+1:] {
+			for _, attestation := range laterBlock.Attestations {
+				if attestation.Data.Slot >= block.Slot || !includable(attestation.Data.Slot, block.Slot) {
+					continue
+				}
+				for _, valIdx := range p.attestingIndices(*attestation, *laterBlock) {
+					key := voteKey{attestation.Data.Slot, valIdx}
+					if onChain[key] || missed[key] {
+						continue
+					}
+					missed[key] = true
+					reward, err := p.voteReward(*attestation, *block, valIdx, participationFlags)
+					if err != nil {
+						return err
+					}
+					packing.AvailableReward += reward
+				}
+			}
+		}
+
+		denominator := phase0.Gwei((p.baseMetrics.Spec.WeightDenominator - p.baseMetrics.Spec.ProposerWeight) * p.baseMetrics.Spec.WeightDenominator / p.baseMetrics.Spec.ProposerWeight)
+		packing.CapturedReward = packing.CapturedReward / denominator
+		packing.AvailableReward = packing.AvailableReward / denominator
+
+		block.Packing = packing
+	}
+	return nil
+}
+
+// attestingIndices returns the voters of an attestation, or none when its committees are unknown
+func (p AltairMetrics) attestingIndices(attestation spec.AgnosticAttestation, block spec.AgnosticBlock) []phase0.ValidatorIndex {
+	attestingIndices, err := p.GetAttestingIndices(attestation)
+	if err != nil {
+		log.Errorf("error processing packing at block %d: %s", block.Slot, err)
+		return nil
+	}
+	return attestingIndices
+}
+
+// voteReward returns the weighted base reward of the flags a vote gets when included in the block,
+// before applying the proposer denominator
+func (p AltairMetrics) voteReward(
+	attestation spec.AgnosticAttestation,
+	block spec.AgnosticBlock,
+	valIdx phase0.ValidatorIndex,
+	participationFlags func(spec.AgnosticAttestation, spec.AgnosticBlock) ([3]bool, error)) (phase0.Gwei, error) {
+
+	// we are only counting rewards at NextState
+	baseReward := p.GetBaseReward(valIdx, p.baseMetrics.NextState.Validators[valIdx].EffectiveBalance, p.baseMetrics.NextState.TotalActiveBalance)
+
+	reward := phase0.Gwei(0)
+	flags, err := participationFlags(attestation, block)
+	if err != nil {
+		return 0, err
+	}
+	for flagIndex, weight := range p.baseMetrics.Spec.ParticipatingFlagsWeight() {
+		if flags[flagIndex] {
+			reward += baseReward * phase0.Gwei(weight)
+		}
+	}
+	return reward, nil
+}
+
+// isSubsetAggregate returns whether the aggregate at position j is contained in another one
+// with the same data and committees. Of two identical aggregates, only the second one is counted
+func isSubsetAggregate(attestations []*spec.AgnosticAttestation, j int) bool {
+	attestation := attestations[j]
+	for k, other := range attestations {
+		if k == j || !sameAttestationData(other.Data, attestation.Data) ||
+			!bytes.Equal(other.CommitteeBits, attestation.CommitteeBits) ||
+			other.AggregationBits.Len() != attestation.AggregationBits.Len() {
+			continue
+		}
+		contained, err := other.AggregationBits.Contains(attestation.AggregationBits)
+		if err != nil || !contained {
+			continue
+		}
+		if k < j || !bytes.Equal(other.AggregationBits, attestation.AggregationBits) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameAttestationData(a *phase0.AttestationData, b *phase0.AttestationData) bool {
+	return a.Slot == b.Slot &&
+		a.Index == b.Index &&
+		a.BeaconBlockRoot == b.BeaconBlockRoot &&
+		*a.Source == *b.Source &&
+		*a.Target == *b.Target
+}
@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"testing"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessBlockPacking(t *testing.T) {
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 4
+
+	data := &phase0.AttestationData{
+		Slot:   12,
+		Source: &phase0.Checkpoint{},
+		Target: &phase0.Checkpoint{Epoch: 3},
+	}
+	vote := func(bits ...uint64) *spec.AgnosticAttestation {
+		aggregationBits := bitfield.NewBitlist(3)
+		for _, bit := range bits {
+			aggregationBits.SetBitAt(bit, true)
+		}
+		return &spec.AgnosticAttestation{AggregationBits: aggregationBits, Data: data}
+	}
+
+	validators := make([]*phase0.Validator, 3)
+	for i := range validators {
+		validators[i] = &phase0.Validator{EffectiveBalance: 32 * spec.EffectiveBalanceInc}
+	}
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.PrevState = &spec.AgnosticState{Epoch: 2}
+	metrics.baseMetrics.CurrentState = &spec.AgnosticState{
+		Epoch: 3,
+		EpochStructs: spec.EpochDuties{BeaconCommittees: []*api.BeaconCommittee{
+			{Slot: 12, Index: 0, Validators: []phase0.ValidatorIndex{0, 1, 2}},
+		}},
+		Blocks: []*spec.AgnosticBlock{
+			// the first aggregate is contained in the second one
+			{Slot: 13, Attestations: []*spec.AgnosticAttestation{vote(0), vote(0, 1)}},
+			// validator 1 was already included
+			{Slot: 14, Attestations: []*spec.AgnosticAttestation{vote(1)}},
+		},
+	}
+	metrics.baseMetrics.NextState = &spec.AgnosticState{
+		Epoch:              4,
+		Validators:         validators,
+		TotalActiveBalance: 96 * spec.EffectiveBalanceInc,
+		// validator 2 could have been included in both blocks
+		Blocks: []*spec.AgnosticBlock{{Slot: 16, Attestations: []*spec.AgnosticAttestation{vote(2)}}},
+	}
+
+	allFlags := func(spec.AgnosticAttestation, spec.AgnosticBlock) ([3]bool, error) {
+		return [3]bool{true, true, true}, nil
+	}
+	err := metrics.processBlockPacking(allFlags, func(phase0.Slot, phase0.Slot) bool { return true })
+	assert.NoError(t, err)
+
+	packing := metrics.baseMetrics.CurrentState.Blocks[0].Packing
+	assert.Equal(t, uint64(1), packing.SubsetAggregates)
+	assert.Equal(t, uint64(0), packing.RedundantAggregates)
+	assert.Equal(t, uint64(2), packing.UniqueNewVotes)
+	assert.InDelta(t, 2.0/3.0, packing.Efficiency(), 0.001)
+
+	packing = metrics.baseMetrics.CurrentState.Blocks[1].Packing
+	assert.Equal(t, uint64(0), packing.SubsetAggregates)
+	assert.Equal(t, uint64(1), packing.RedundantAggregates)
+	assert.Equal(t, uint64(0), packing.UniqueNewVotes)
+	assert.Equal(t, phase0.Gwei(0), packing.CapturedReward)
+	assert.NotZero(t, packing.AvailableReward)
+	assert.Equal(t, 0.0, packing.Efficiency())
+}
+
+func TestProcessBlockPackingElectra(t *testing.T) {
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 4
+
+	data := &phase0.AttestationData{
+		Slot:   12,
+		Source: &phase0.Checkpoint{},
+		Target: &phase0.Checkpoint{Epoch: 3},
+	}
+	// the aggregation bits cover the committees set in the committee bits, in order
+	vote := func(committees []uint64, size uint64, bits ...uint64) *spec.AgnosticAttestation {
+		committeeBits := bitfield.NewBitvector64()
+		for _, committee := range committees {
+			committeeBits.SetBitAt(committee, true)
+		}
+		aggregationBits := bitfield.NewBitlist(size)
+		for _, bit := range bits {
+			aggregationBits.SetBitAt(bit, true)
+		}
+		return &spec.AgnosticAttestation{AggregationBits: aggregationBits, Data: data, CommitteeBits: committeeBits}
+	}
+
+	validators := make([]*phase0.Validator, 3)
+	for i := range validators {
+		validators[i] = &phase0.Validator{EffectiveBalance: 32 * spec.EffectiveBalanceInc}
+	}
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.PrevState = &spec.AgnosticState{Epoch: 2}
+	metrics.baseMetrics.CurrentState = &spec.AgnosticState{
+		Epoch: 3,
+		EpochStructs: spec.EpochDuties{BeaconCommittees: []*api.BeaconCommittee{
+			{Slot: 12, Index: 0, Validators: []phase0.ValidatorIndex{0, 1}},
+			{Slot: 12, Index: 1, Validators: []phase0.ValidatorIndex{2}},
+		}},
+		Blocks: []*spec.AgnosticBlock{
+			// validators 0 and 2, then validator 2 again from its own committee
+			{Slot: 13, Attestations: []*spec.AgnosticAttestation{vote([]uint64{0, 1}, 3, 0, 2), vote([]uint64{1}, 1, 0)}},
+			// validator 0 was already included, validator 1 is new
+			{Slot: 14, Attestations: []*spec.AgnosticAttestation{vote([]uint64{0}, 2, 0, 1)}},
+		},
+	}
+	metrics.baseMetrics.NextState = &spec.AgnosticState{
+		Epoch:              4,
+		Validators:         validators,
+		TotalActiveBalance: 96 * spec.EffectiveBalanceInc,
+	}
+
+	allFlags := func(spec.AgnosticAttestation, spec.AgnosticBlock) ([3]bool, error) {
+		return [3]bool{true, true, true}, nil
+	}
+	err := metrics.processBlockPacking(allFlags, func(phase0.Slot, phase0.Slot) bool { return true })
+	assert.NoError(t, err)
+
+	packing := metrics.baseMetrics.CurrentState.Blocks[0].Packing
+	assert.Equal(t, uint64(0), packing.SubsetAggregates)
+	assert.Equal(t, uint64(1), packing.RedundantAggregates)
+	assert.Equal(t, uint64(2), packing.UniqueNewVotes)
+	assert.InDelta(t, 2.0/3.0, packing.Efficiency(), 0.001)
+
+	packing = metrics.baseMetrics.CurrentState.Blocks[1].Packing
+	assert.Equal(t, uint64(0), packing.RedundantAggregates)
+	assert.Equal(t, uint64(1), packing.UniqueNewVotes)
+
+	// the aggregation bits must match the committees
+	_, err = metrics.GetAttestingIndices(*vote([]uint64{0, 1}, 2, 0))
+	assert.NotNil(t, err)
+}
+
+func TestHeadVoteMisses(t *testing.T) {
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 4
+	chainSpec.SlotsPerHistoricalRoot = 16
+
+	// slot 12 was proposed, its attesters should have voted for it
+	justified := phase0.Root{0xaa}
+	blockRoots := make([]phase0.Root, 16)
+	blockRoots[12] = phase0.Root{0x12}
+	vote := func(head phase0.Root, bits ...uint64) *spec.AgnosticAttestation {
+		aggregationBits := bitfield.NewBitlist(3)
+		for _, bit := range bits {
+			aggregationBits.SetBitAt(bit, true)
+		}
+		return &spec.AgnosticAttestation{AggregationBits: aggregationBits, Data: &phase0.AttestationData{
+			Slot:            12,
+			BeaconBlockRoot: head,
+			Source:          &phase0.Checkpoint{Root: justified},
+			Target:          &phase0.Checkpoint{Epoch: 3, Root: blockRoots[12]},
+		}}
+	}
+
+	validators := make([]*phase0.Validator, 3)
+	for i := range validators {
+		validators[i] = &phase0.Validator{EffectiveBalance: 32 * spec.EffectiveBalanceInc}
+	}
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.CurrentNumAttestingVals = make([]bool, 3)
+	metrics.baseMetrics.PrevState = &spec.AgnosticState{Epoch: 2}
+	metrics.baseMetrics.CurrentState = &spec.AgnosticState{
+		Epoch:                      3,
+		Validators:                 validators,
+		CurrentJustifiedCheckpoint: phase0.Checkpoint{Root: justified},
+		EpochStructs: spec.EpochDuties{
+			BeaconCommittees: []*api.BeaconCommittee{
+				{Slot: 12, Index: 0, Validators: []phase0.ValidatorIndex{0, 1, 2}},
+			},
+			ValidatorAttSlot: map[phase0.ValidatorIndex]phase0.Slot{0: 12, 1: 12, 2: 12},
+		},
+		Blocks: []*spec.AgnosticBlock{
+			{Slot: 12},
+			// two votes for the parent, one of them included again later
+			{Slot: 13, Attestations: []*spec.AgnosticAttestation{vote(phase0.Root{0x11}, 0, 1), vote(phase0.Root{0x12}, 2)}},
+			{Slot: 14, Attestations: []*spec.AgnosticAttestation{vote(phase0.Root{0x11}, 1)}},
+		},
+	}
+	metrics.baseMetrics.NextState = &spec.AgnosticState{
+		Epoch:              4,
+		Validators:         validators,
+		TotalActiveBalance: 96 * spec.EffectiveBalanceInc,
+		Spec:               chainSpec,
+		BlockRoots:         blockRoots,
+	}
+	assert.NoError(t, metrics.ProcessAttestations())
+
+	assert.Equal(t, uint64(2), metrics.baseMetrics.CurrentState.Blocks[0].HeadVoteMisses)
+	assert.Equal(t, uint64(0), metrics.baseMetrics.CurrentState.Blocks[1].HeadVoteMisses)
+}
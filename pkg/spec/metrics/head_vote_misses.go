@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// setHeadVoteMisses counts, for every slot of currentState, the attesters whose votes were
+// included in currentState and nextState without getting the head flag, from the participation
+// of ProcessAttestations. A late block makes its attesters vote for the parent,
+// so the count points at the proposer of the slot
+func (p AltairMetrics) setHeadVoteMisses(currentEpochParticipation [][]bool) {
+	currentState := p.baseMetrics.CurrentState
+
+	misses := make(map[phase0.Slot]uint64)
+	for valIdx, flags := range currentEpochParticipation {
+		if flags == nil || flags[spec.AttHeadFlagIndex] {
+			continue
+		}
+		attSlot, ok := currentState.EpochStructs.ValidatorAttSlot[phase0.ValidatorIndex(valIdx)]
+		if ok {
+			misses[attSlot] += 1
+		}
+	}
+
+	for _, block := range currentState.Blocks {
+		block.HeadVoteMisses = misses[block.Slot]
+	}
+}
@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+)
+
+// The reward of a validator at nextState is the balance delta from currentState, which covers:
+// - the epoch transition into nextState's epoch: flag rewards and penalties, inactivity and correlation penalties.
+// They are computed as the spec does, from currentState (the transition starts from it) and the
+// inactivity scores and finality the transition leaves, unchanged until nextState
+// - the blocks of nextState's epoch: proposer rewards, sync committee rewards and penalties and slashings
+// Phase0 has no sync committee, and the proposers of the previous epoch attestations are rewarded in the transition
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#epoch-processing
+
+// ProcessPenalties computes the block level penalties of nextState's epoch
+// and the totals the epoch transition rewards and penalties of every validator need
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#rewards-and-penalties-1
+func (p *Phase0Metrics) ProcessPenalties() {
+	p.processSlashingPenalties()
+
+	// the participation flags are only filled from the attestations of both states
+	if p.baseMetrics.PrevState.EmptyStateRoot() || p.baseMetrics.CurrentState.EmptyStateRoot() {
+		return
+	}
+	p.processTransitionTotals()
+}
+
+// ProcessPenalties computes the block level penalties of nextState's epoch
+// and the totals the epoch transition penalties of every validator need
+func (p *AltairMetrics) ProcessPenalties() {
+	p.SyncRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
+	p.SyncPenalties = make(map[phase0.ValidatorIndex]phase0.Gwei)
+
+	for _, item := range p.SyncParticipation {
+		p.SyncRewards[item.ValidatorIndex] += item.Reward
+		p.SyncPenalties[item.ValidatorIndex] += item.Penalty
+	}
+
+	p.processSlashingPenalties()
+
+	currentState := p.baseMetrics.CurrentState
+	if currentState.EmptyStateRoot() || currentState.Version < spec.DataVersionAltair {
+		return
+	}
+	p.processTransitionTotals()
+}
+
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/bellatrix/beacon-chain.md#modified-slash_validator
+func (p *Phase0Metrics) processSlashingPenalties() {
+	p.SlashingPenalties = make(map[phase0.ValidatorIndex]phase0.Gwei)
+
+	quotient := phase0.Gwei(p.baseMetrics.Spec.MinSlashingPenaltyQuotient(p.baseMetrics.NextState.Version))
+	for _, block := range p.baseMetrics.NextState.Blocks {
+		for _, valIdx := range block.SlashedValidators() {
+			_, slashed := p.SlashingPenalties[valIdx]
+			if slashed || int(valIdx) >= len(p.baseMetrics.NextState.Validators) {
+				continue
+			}
+			// validators that were already slashed are not slashable
+			if int(valIdx) < len(p.baseMetrics.CurrentState.Validators) && p.baseMetrics.CurrentState.Validators[valIdx].Slashed {
+				continue
+			}
+			p.SlashingPenalties[valIdx] = p.baseMetrics.NextState.Validators[valIdx].EffectiveBalance / quotient
+		}
+	}
+}
+
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#get_unslashed_participating_indices
+func (p *Phase0Metrics) processTransitionTotals() {
+	currentState := p.baseMetrics.CurrentState
+	prevEpoch := p.baseMetrics.PrevState.Epoch
+	for flagIndex := range currentState.PrevEpochCorrectFlags {
+		balance := phase0.Gwei(0)
+		for valIdx, validator := range currentState.Validators {
+			if p.participated(flagIndex, phase0.ValidatorIndex(valIdx)) && local_spec.IsActive(*validator, prevEpoch) {
+				balance += validator.EffectiveBalance
+			}
+		}
+		if balance < p.baseMetrics.Spec.EffectiveBalanceIncrement {
+			balance = p.baseMetrics.Spec.EffectiveBalanceIncrement
+		}
+		p.unslashedParticipatingInc[flagIndex] = balance / p.baseMetrics.Spec.EffectiveBalanceIncrement
+	}
+
+	p.inactivityLeak = p.baseMetrics.Spec.IsInInactivityLeak(prevEpoch, p.baseMetrics.NextState.FinalizedCheckpoint.Epoch)
+
+	p.totalSlashings = 0
+	for _, item := range currentState.Slashings {
+		p.totalSlashings += item
+	}
+}
+
+// participated returns whether the unslashed validator attested the flag in the previous epoch.
+// Phase0 only counts the head of the attestations with the correct target
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#helper-functions-1
+func (p Phase0Metrics) participated(flagIndex int, valIdx phase0.ValidatorIndex) bool {
+	currentState := p.baseMetrics.CurrentState
+	if int(valIdx) >= len(currentState.PrevEpochCorrectFlags[flagIndex]) ||
+		!currentState.PrevEpochCorrectFlags[flagIndex][valIdx] ||
+		currentState.Validators[valIdx].Slashed {
+		return false
+	}
+	if currentState.Version == spec.DataVersionPhase0 && flagIndex == local_spec.AttHeadFlagIndex {
+		return currentState.PrevEpochCorrectFlags[local_spec.AttTargetFlagIndex][valIdx]
+	}
+	return true
+}
+
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#get_eligible_validator_indices
+func (p Phase0Metrics) isEligible(validator *phase0.Validator) bool {
+	prevEpoch := p.baseMetrics.PrevState.Epoch
+	return local_spec.IsActive(*validator, prevEpoch) || (validator.Slashed && prevEpoch+1 < validator.WithdrawableEpoch)
+}
+
+// addRewardsBreakdown fills the reward and penalty components of the validator.
+// Along with the proposer reward they add up to its balance delta
+func (p Phase0Metrics) addRewardsBreakdown(result *local_spec.ValidatorRewards) {
+	valIdx := result.ValidatorIndex
+
+	result.SlashingPenalty = p.SlashingPenalties[valIdx]
+
+	currentState := p.baseMetrics.CurrentState
+	// no rewards are applied at genesis
+	if p.baseMetrics.PrevState.EmptyStateRoot() || currentState.EmptyStateRoot() || currentState.Epoch == 0 ||
+		int(valIdx) >= len(currentState.Validators) ||
+		int(valIdx) >= len(currentState.PrevEpochCorrectFlags[0]) {
+		return
+	}
+	validator := currentState.Validators[valIdx]
+	baseReward := p.GetBaseReward(valIdx)
+	proposerReward := p.GetProposerReward(valIdx)
+
+	if p.isEligible(validator) {
+		// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#components-of-attestation-deltas
+		activeInc := currentState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement
+		for flagIndex := range p.unslashedParticipatingInc {
+			if !p.participated(flagIndex, valIdx) {
+				result.FlagsPenalty += baseReward
+			} else if p.inactivityLeak {
+				// optimal participation, the inactivity penalty cancels it
+				result.FlagsReward += baseReward
+			} else {
+				result.FlagsReward += baseReward * p.unslashedParticipatingInc[flagIndex] / activeInc
+			}
+		}
+
+		// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#inactivity-penalty-deltas
+		if p.inactivityLeak {
+			result.InactivityPenalty = phase0.Gwei(p.baseMetrics.Spec.BaseRewardsPerEpoch)*baseReward - proposerReward
+			if !p.participated(local_spec.AttTargetFlagIndex, valIdx) {
+				finalityDelay := p.baseMetrics.PrevState.Epoch - p.baseMetrics.NextState.FinalizedCheckpoint.Epoch
+				penaltyQuotient := p.baseMetrics.Spec.InactivityPenaltyQuotient(currentState.Version)
+				result.InactivityPenalty += validator.EffectiveBalance * phase0.Gwei(finalityDelay) / phase0.Gwei(penaltyQuotient)
+			}
+		}
+	}
+
+	// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#inclusion-delay-deltas
+	inclusionDelay := p.baseMetrics.InclusionDelays[valIdx]
+	if p.participated(local_spec.AttSourceFlagIndex, valIdx) && inclusionDelay > 0 {
+		result.FlagsReward += (baseReward - proposerReward) / phase0.Gwei(inclusionDelay)
+	}
+
+	result.CorrelationPenalty = p.correlationPenalty(validator)
+}
+
+// addRewardsBreakdown fills the reward and penalty components of the validator.
+// Along with the proposer reward they add up to its balance delta
+func (p AltairMetrics) addRewardsBreakdown(result *local_spec.ValidatorRewards) {
+	valIdx := result.ValidatorIndex
+
+	result.SyncReward = p.SyncRewards[valIdx]
+	result.SyncPenalty = p.SyncPenalties[valIdx]
+	result.SlashingPenalty = p.SlashingPenalties[valIdx]
+
+	currentState := p.baseMetrics.CurrentState
+	if currentState.Version < spec.DataVersionAltair ||
+		int(valIdx) >= len(currentState.Validators) ||
+		int(valIdx) >= len(currentState.PrevEpochCorrectFlags[0]) {
+		return
+	}
+	validator := currentState.Validators[valIdx]
+
+	if p.isEligible(validator) {
+		// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#get_flag_index_deltas
+		baseReward := p.GetBaseReward(valIdx, validator.EffectiveBalance, currentState.TotalActiveBalance)
+		activeInc := currentState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement
+		weightDenominator := phase0.Gwei(p.baseMetrics.Spec.WeightDenominator)
+
+		for flagIndex, weight := range p.baseMetrics.Spec.ParticipatingFlagsWeight() {
+			if p.participated(flagIndex, valIdx) {
+				if !p.inactivityLeak {
+					result.FlagsReward += baseReward * phase0.Gwei(weight) * p.unslashedParticipatingInc[flagIndex] / (activeInc * weightDenominator)
+				}
+			} else if flagIndex != local_spec.AttHeadFlagIndex {
+				result.FlagsPenalty += baseReward * phase0.Gwei(weight) / weightDenominator
+			}
+		}
+
+		// https://github.com/ethereum/consensus-specs/blob/dev/specs/bellatrix/beacon-chain.md#modified-get_inactivity_penalty_deltas
+		if !p.participated(local_spec.AttTargetFlagIndex, valIdx) && int(valIdx) < len(p.baseMetrics.NextState.InactivityScores) {
+			penaltyNumerator := validator.EffectiveBalance * phase0.Gwei(p.baseMetrics.NextState.InactivityScores[valIdx])
+			penaltyDenominator := p.baseMetrics.Spec.InactivityScoreBias * p.baseMetrics.Spec.InactivityPenaltyQuotient(currentState.Version)
+			result.InactivityPenalty = penaltyNumerator / phase0.Gwei(penaltyDenominator)
+		}
+	}
+
+	result.CorrelationPenalty = p.correlationPenalty(validator)
+}
+
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/bellatrix/beacon-chain.md#slashings
+func (p Phase0Metrics) correlationPenalty(validator *phase0.Validator) phase0.Gwei {
+	currentState := p.baseMetrics.CurrentState
+	if !validator.Slashed || uint64(currentState.Epoch)+p.baseMetrics.Spec.EpochsPerSlashingsVector/2 != uint64(validator.WithdrawableEpoch) {
+		return 0
+	}
+	increment := p.baseMetrics.Spec.EffectiveBalanceIncrement
+	totalBalance := currentState.TotalActiveBalance
+	adjustedTotalSlashingBalance := p.totalSlashings * phase0.Gwei(p.baseMetrics.Spec.ProportionalSlashingMultiplier(currentState.Version))
+	if adjustedTotalSlashingBalance > totalBalance {
+		adjustedTotalSlashingBalance = totalBalance
+	}
+	if currentState.Version >= spec.DataVersionElectra {
+		// the penalty per increment is computed first, as 2048 ETH balances overflow the numerator
+		// https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md#modified-process_slashings
+		penaltyPerIncrement := adjustedTotalSlashingBalance / (totalBalance / increment)
+		return penaltyPerIncrement * (validator.EffectiveBalance / increment)
+	}
+	penaltyNumerator := validator.EffectiveBalance / increment * adjustedTotalSlashingBalance
+	return penaltyNumerator / totalBalance * increment
+}
@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"testing"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	local_spec "github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewardsBreakdown(t *testing.T) {
+	chainSpec := local_spec.MainnetChainSpec()
+	farFuture := phase0.Epoch(1 << 40)
+
+	validators := make([]*phase0.Validator, 4)
+	for i := range validators {
+		validators[i] = &phase0.Validator{
+			EffectiveBalance:  32 * local_spec.EffectiveBalanceInc,
+			ExitEpoch:         farFuture,
+			WithdrawableEpoch: farFuture,
+		}
+	}
+	// validator 3 was slashed 4096 epochs ago
+	validators[3].Slashed = true
+	validators[3].WithdrawableEpoch = 10 + 4096
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.PrevState = &local_spec.AgnosticState{Epoch: 9}
+	metrics.baseMetrics.CurrentState = &local_spec.AgnosticState{
+		Version:            spec.DataVersionBellatrix,
+		StateRoot:          phase0.Root{0x01},
+		Epoch:              10,
+		Validators:         validators,
+		TotalActiveBalance: 128 * local_spec.EffectiveBalanceInc,
+		// validator 0 got every flag, 1 none and 2 only the source
+		PrevEpochCorrectFlags: [][]bool{
+			{true, false, true, true},
+			{true, false, false, true},
+			{true, false, false, true},
+		},
+		Slashings: []phase0.Gwei{32 * local_spec.EffectiveBalanceInc, 0},
+	}
+	metrics.baseMetrics.NextState = &local_spec.AgnosticState{
+		Version:             spec.DataVersionBellatrix,
+		Epoch:               11,
+		Validators:          validators,
+		InactivityScores:    []uint64{0, 40, 8, 0},
+		FinalizedCheckpoint: phase0.Checkpoint{Epoch: 8},
+		Blocks: []*local_spec.AgnosticBlock{{ProposerSlashings: []*phase0.ProposerSlashing{
+			{SignedHeader1: &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{ProposerIndex: 1}}},
+		}}},
+	}
+	metrics.SyncParticipation = []local_spec.SyncCommitteeParticipation{
+		{ValidatorIndex: 0, Signed: true, Reward: 100},
+		{ValidatorIndex: 0, Penalty: 50},
+	}
+	metrics.ProcessPenalties()
+
+	breakdown := func(valIdx phase0.ValidatorIndex) local_spec.ValidatorRewards {
+		result := local_spec.ValidatorRewards{ValidatorIndex: valIdx}
+		metrics.addRewardsBreakdown(&result)
+		return result
+	}
+
+	result := breakdown(0)
+	assert.Equal(t, phase0.Gwei(626097+581376+313048), result.FlagsReward)
+	assert.Equal(t, phase0.Gwei(0), result.FlagsPenalty)
+	assert.Equal(t, phase0.Gwei(100), result.SyncReward)
+	assert.Equal(t, phase0.Gwei(50), result.SyncPenalty)
+
+	result = breakdown(1)
+	assert.Equal(t, phase0.Gwei(0), result.FlagsReward)
+	assert.Equal(t, phase0.Gwei(1252195+2325505), result.FlagsPenalty)
+	assert.Equal(t, phase0.Gwei(19073), result.InactivityPenalty)
+	assert.Equal(t, phase0.Gwei(1*local_spec.EffectiveBalanceInc), result.SlashingPenalty)
+
+	result = breakdown(2)
+	assert.Equal(t, phase0.Gwei(626097), result.FlagsReward)
+	assert.Equal(t, phase0.Gwei(2325505), result.FlagsPenalty)
+	assert.Equal(t, phase0.Gwei(3814), result.InactivityPenalty)
+
+	// slashed validators do not count as participating
+	result = breakdown(3)
+	assert.Equal(t, phase0.Gwei(0), result.FlagsReward)
+	assert.Equal(t, phase0.Gwei(1252195+2325505), result.FlagsPenalty)
+	assert.Equal(t, phase0.Gwei(24*local_spec.EffectiveBalanceInc), result.CorrelationPenalty)
+
+	// no flag rewards during a leak
+	metrics.baseMetrics.NextState.FinalizedCheckpoint.Epoch = 4
+	metrics.ProcessPenalties()
+	assert.Equal(t, phase0.Gwei(0), breakdown(0).FlagsReward)
+}
+
+func TestPhase0RewardsReconciliation(t *testing.T) {
+	chainSpec := local_spec.MainnetChainSpec()
+	chainSpec.SlotsPerEpoch = 2
+	chainSpec.SlotsPerHistoricalRoot = 8
+	farFuture := phase0.Epoch(1 << 40)
+	balance := phase0.Gwei(32 * local_spec.EffectiveBalanceInc)
+
+	newValidators := func() []*phase0.Validator {
+		validators := make([]*phase0.Validator, 4)
+		for i := range validators {
+			validators[i] = &phase0.Validator{
+				EffectiveBalance:  balance,
+				ExitEpoch:         farFuture,
+				WithdrawableEpoch: farFuture,
+			}
+		}
+		return validators
+	}
+	// slot 18 to 23, proposed by validators 0, 1, 2, 3, 0 and 1
+	newBlocks := func(firstSlot phase0.Slot) []*local_spec.AgnosticBlock {
+		return []*local_spec.AgnosticBlock{
+			{Slot: firstSlot, ProposerIndex: phase0.ValidatorIndex((firstSlot - 18) % 4), Proposed: true},
+			{Slot: firstSlot + 1, ProposerIndex: phase0.ValidatorIndex((firstSlot - 17) % 4), Proposed: true},
+		}
+	}
+	aggregationBits := func(indices ...uint64) bitfield.Bitlist {
+		bits := bitfield.NewBitlist(2)
+		for _, index := range indices {
+			bits.SetBitAt(index, true)
+		}
+		return bits
+	}
+	targetRoot := phase0.Root{0x12}
+	headRoot := phase0.Root{0x13}
+	blockRoots := make([]phase0.Root, chainSpec.SlotsPerHistoricalRoot)
+	blockRoots[18%8] = targetRoot
+	blockRoots[19%8] = headRoot
+
+	prevState := &local_spec.AgnosticState{
+		Version:    spec.DataVersionPhase0,
+		StateRoot:  phase0.Root{0x01},
+		Epoch:      9,
+		Slot:       19,
+		Validators: newValidators(),
+		BlockRoots: blockRoots,
+		Blocks:     newBlocks(18),
+		Spec:       chainSpec,
+		EpochStructs: local_spec.EpochDuties{BeaconCommittees: []*api.BeaconCommittee{
+			{Slot: 18, Index: 0, Validators: []phase0.ValidatorIndex{0, 1}},
+			{Slot: 19, Index: 0, Validators: []phase0.ValidatorIndex{2, 3}},
+		}},
+	}
+	currentState := &local_spec.AgnosticState{
+		Version:            spec.DataVersionPhase0,
+		StateRoot:          phase0.Root{0x02},
+		Epoch:              10,
+		Slot:               21,
+		Balances:           []phase0.Gwei{balance, balance, balance, balance},
+		Validators:         newValidators(),
+		TotalActiveBalance: 128 * local_spec.EffectiveBalanceInc,
+		AttestingBalance:   make([]phase0.Gwei, 3),
+		PrevEpochCorrectFlags: [][]bool{
+			make([]bool, 4), make([]bool, 4), make([]bool, 4),
+		},
+		// 0 and 1 vote everything right in the next slot, 2 misses the head and is included two slots later
+		PrevAttestations: []*phase0.PendingAttestation{
+			{
+				AggregationBits: aggregationBits(0, 1),
+				Data:            &phase0.AttestationData{Slot: 18, BeaconBlockRoot: targetRoot, Source: &phase0.Checkpoint{}, Target: &phase0.Checkpoint{Epoch: 9, Root: targetRoot}},
+				InclusionDelay:  1,
+			},
+			{
+				AggregationBits: aggregationBits(0),
+				Data:            &phase0.AttestationData{Slot: 19, BeaconBlockRoot: targetRoot, Source: &phase0.Checkpoint{}, Target: &phase0.Checkpoint{Epoch: 9, Root: targetRoot}},
+				InclusionDelay:  2,
+			},
+		},
+		BlockRoots: blockRoots,
+		Blocks:     newBlocks(20),
+		Spec:       chainSpec,
+	}
+	nextState := &local_spec.AgnosticState{
+		Version:             spec.DataVersionPhase0,
+		StateRoot:           phase0.Root{0x03},
+		Epoch:               11,
+		Slot:                23,
+		Validators:          newValidators(),
+		FinalizedCheckpoint: phase0.Checkpoint{Epoch: 8},
+		Withdrawals:         make([]phase0.Gwei, 4),
+		Deposits:            make([]phase0.Gwei, 4),
+		Blocks:              newBlocks(22),
+		Spec:                chainSpec,
+	}
+	// validator 0 reports validator 3 in slot 22
+	nextState.Validators[3].Slashed = true
+	nextState.Blocks[0].ProposerSlashings = []*phase0.ProposerSlashing{
+		{SignedHeader1: &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{ProposerIndex: 3}}},
+	}
+
+	// balance deltas worked out from the spec, base reward 1431087 and proposer reward 178885
+	deltas := []int64{
+		// 2 * 1073315 source and target + 715543 head + 1252202 inclusion + 62500000 whistleblower
+		66614375,
+		// the same attestation rewards + 2 * 178885 for including the attestations of 0 and 1
+		4472145,
+		// 2 * 1073315 source and target - 1431087 head + 1252202 / 2 inclusion
+		1341644,
+		// -3 * 1431087 + 178885 for including the attestation of 2 - 250000000 slashing
+		-254114376,
+	}
+	nextState.Balances = make([]phase0.Gwei, len(deltas))
+	for valIdx, delta := range deltas {
+		nextState.Balances[valIdx] = phase0.Gwei(int64(balance) + delta)
+	}
+
+	metrics, err := NewPhase0Metrics(nextState, currentState, prevState)
+	assert.NoError(t, err)
+	for valIdx, delta := range deltas {
+		result, err := metrics.GetMaxReward(phase0.ValidatorIndex(valIdx))
+		assert.NoError(t, err)
+		assert.Equal(t, delta, result.Reward)
+
+		breakdown := int64(result.FlagsReward) - int64(result.FlagsPenalty) -
+			int64(result.InactivityPenalty) - int64(result.SlashingPenalty) - int64(result.CorrelationPenalty) +
+			int64(result.SyncReward) - int64(result.SyncPenalty) + int64(result.ProposerManualReward)
+		assert.Equal(t, result.Reward, breakdown, "validator %d", valIdx)
+	}
+	// the attestation duties are recorded along
+	duties := metrics.GetAttestationDuties()
+	assert.Equal(t, 4, len(duties))
+	assert.Equal(t, phase0.Slot(21), duties[2].InclusionSlot)
+	assert.True(t, duties[2].CorrectSource && duties[2].CorrectTarget)
+	assert.False(t, duties[2].CorrectHead)
+	assert.Equal(t, phase0.Slot(0), duties[3].InclusionSlot)
+}
+
+func TestIntegerSquareroot(t *testing.T) {
+	assert.Equal(t, uint64(0), integerSquareroot(0))
+	assert.Equal(t, uint64(3), integerSquareroot(15))
+	assert.Equal(t, uint64(4), integerSquareroot(16))
+	// float64 rounds 184390889^2 - 1 up, its square root would be 184390889
+	assert.Equal(t, uint64(184390888), integerSquareroot(184390889*184390889-1))
+}
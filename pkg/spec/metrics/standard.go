@@ -21,6 +21,7 @@ type StateMetricsBase struct {
 	PrevState    *local_spec.AgnosticState
 	CurrentState *local_spec.AgnosticState
 	NextState    *local_spec.AgnosticState
+	Spec         *local_spec.ChainSpec
 	// these are the max rewards calculated by our tool
 	MaxSlashingRewards      map[phase0.ValidatorIndex]phase0.Gwei // for now just proposer as per spec
 	MaxBlockRewards         map[phase0.ValidatorIndex]phase0.Gwei // from including attestation and sync aggregates. In this case, not max reward but the actual reward
@@ -57,45 +58,78 @@ func StateMetricsByForkVersion(
 	switch nextState.Version { // rewards are written at nextState epoch
 
 	case spec.DataVersionPhase0:
-		return NewPhase0Metrics(nextState, currentState, prevState), nil
+		return NewPhase0Metrics(nextState, currentState, prevState)
 
 	case spec.DataVersionAltair:
-		return NewAltairMetrics(nextState, currentState, prevState), nil
+		return NewAltairMetrics(nextState, currentState, prevState)
 
 	case spec.DataVersionBellatrix:
-		return NewAltairMetrics(nextState, currentState, prevState), nil // We use Altair as Rewards system is the same
+		return NewAltairMetrics(nextState, currentState, prevState) // We use Altair as Rewards system is the same
 
 	case spec.DataVersionCapella:
-		return NewAltairMetrics(nextState, currentState, prevState), nil // We use Altair as Rewards system is the same
+		return NewAltairMetrics(nextState, currentState, prevState) // We use Altair as Rewards system is the same
 
 	case spec.DataVersionDeneb:
-		return NewDenebMetrics(nextState, currentState, prevState), nil
+		return NewDenebMetrics(nextState, currentState, prevState)
+
+	case spec.DataVersionElectra:
+		return NewElectraMetrics(nextState, currentState, prevState)
 	default:
 		return nil, fmt.Errorf("could not figure out the State Metrics Fork Version: %s", currentState.Version)
 	}
 }
 
+// ExportToEpoch summarizes CurrentState's epoch. Its attestations, and so its justification
+// and finalization, are processed in the transition to NextState
 func (s StateMetricsBase) ExportToEpoch() local_spec.Epoch {
 
+	justificationBits := uint8(0)
+	for i := uint64(0); i < s.NextState.JustificationBits.Len(); i++ {
+		if s.NextState.JustificationBits.BitAt(i) {
+			justificationBits |= 1 << i
+		}
+	}
+	finalizedEpoch := s.NextState.FinalizedCheckpoint.Epoch
+	epochsSinceFinality := uint64(0)
+	if s.CurrentState.Epoch > finalizedEpoch {
+		epochsSinceFinality = uint64(s.CurrentState.Epoch - finalizedEpoch)
+	}
+	participation := func(flagIndex int) float32 {
+		if s.CurrentState.TotalActiveBalance == 0 {
+			return 0
+		}
+		return float32(s.NextState.AttestingBalance[flagIndex]) / float32(s.CurrentState.TotalActiveBalance) * 100
+	}
+
+	balanceInc := s.Spec.EffectiveBalanceIncrement
+
 	return local_spec.Epoch{
 		Epoch:                     s.CurrentState.Epoch,
 		Slot:                      s.CurrentState.Slot,
 		NumAttestations:           len(s.NextState.PrevAttestations),
 		NumAttValidators:          int(countTrue(s.CurrentNumAttestingVals)),
 		NumValidators:             len(s.CurrentState.Validators),
-		TotalBalance:              float32(s.CurrentState.TotalActiveRealBalance) / float32(local_spec.EffectiveBalanceInc),
-		AttEffectiveBalance:       s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / local_spec.EffectiveBalanceInc,
-		SourceAttEffectiveBalance: s.NextState.AttestingBalance[local_spec.AttSourceFlagIndex] / local_spec.EffectiveBalanceInc,
-		TargetAttEffectiveBalance: s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / local_spec.EffectiveBalanceInc,
-		HeadAttEffectiveBalance:   s.NextState.AttestingBalance[local_spec.AttHeadFlagIndex] / local_spec.EffectiveBalanceInc,
-		TotalEffectiveBalance:     s.CurrentState.TotalActiveBalance / local_spec.EffectiveBalanceInc,
+		TotalBalance:              float32(s.CurrentState.TotalActiveRealBalance) / float32(balanceInc),
+		AttEffectiveBalance:       s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / balanceInc,
+		SourceAttEffectiveBalance: s.NextState.AttestingBalance[local_spec.AttSourceFlagIndex] / balanceInc,
+		TargetAttEffectiveBalance: s.NextState.AttestingBalance[local_spec.AttTargetFlagIndex] / balanceInc,
+		HeadAttEffectiveBalance:   s.NextState.AttestingBalance[local_spec.AttHeadFlagIndex] / balanceInc,
+		TotalEffectiveBalance:     s.CurrentState.TotalActiveBalance / balanceInc,
 		MissingSource:             int(s.NextState.GetMissingFlagCount(int(altair.TimelySourceFlagIndex))),
 		MissingTarget:             int(s.NextState.GetMissingFlagCount(int(altair.TimelyTargetFlagIndex))),
 		MissingHead:               int(s.NextState.GetMissingFlagCount(int(altair.TimelyHeadFlagIndex))),
-		Timestamp:                 int64(s.CurrentState.GenesisTimestamp + uint64(s.CurrentState.Epoch)*local_spec.SlotsPerEpoch*local_spec.SlotSeconds),
+		Timestamp:                 int64(s.Spec.SlotTime(s.CurrentState.GenesisTimestamp, s.Spec.EpochStartSlot(s.CurrentState.Epoch))),
 		NumSlashedVals:            int(s.CurrentState.NumSlashedVals),
 		NumActiveVals:             int(s.CurrentState.NumActiveVals),
 		NumExitedVals:             int(s.CurrentState.NumExitedVals),
 		NumInActivationVals:       int(s.CurrentState.NumQueuedVals),
+		JustificationBits:         justificationBits,
+		JustifiedEpoch:            s.NextState.CurrentJustifiedCheckpoint.Epoch,
+		FinalizedEpoch:            finalizedEpoch,
+		EpochsSinceFinality:       epochsSinceFinality,
+		InactivityLeak:            s.CurrentState.Epoch > 0 && s.Spec.IsInInactivityLeak(s.CurrentState.Epoch-1, finalizedEpoch),
+		SourceParticipation:       participation(local_spec.AttSourceFlagIndex),
+		TargetParticipation:       participation(local_spec.AttTargetFlagIndex),
+		HeadParticipation:         participation(local_spec.AttHeadFlagIndex),
 	}
 }
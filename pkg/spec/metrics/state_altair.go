@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -11,19 +12,22 @@ import (
 type AltairMetrics struct {
 	Phase0Metrics
 	MaxSyncCommitteeRewards map[phase0.ValidatorIndex]phase0.Gwei // rewards from participating in the sync committee
+	SyncParticipation       []spec.SyncCommitteeParticipation     // one per sync committee member and slot of nextState
+	SyncRewards             map[phase0.ValidatorIndex]phase0.Gwei // received by the sync committee members in nextState
+	SyncPenalties           map[phase0.ValidatorIndex]phase0.Gwei // for the slots the sync committee members did not sign in nextState
 }
 
 func NewAltairMetrics(
 	nextState *spec.AgnosticState,
 	currentState *spec.AgnosticState,
-	prevState *spec.AgnosticState) AltairMetrics {
+	prevState *spec.AgnosticState) (AltairMetrics, error) {
 
 	altairObj := AltairMetrics{}
 
 	altairObj.InitBundle(nextState, currentState, prevState)
-	altairObj.PreProcessBundle()
+	err := altairObj.PreProcessBundle()
 
-	return altairObj
+	return altairObj, err
 
 }
 
@@ -33,6 +37,7 @@ func (p *AltairMetrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.NextState = nextState
 	p.baseMetrics.CurrentState = currentState
 	p.baseMetrics.PrevState = prevState
+	p.baseMetrics.Spec = nextState.Spec
 	p.baseMetrics.MaxBlockRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.MaxSlashingRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.InclusionDelays = make([]int, len(p.baseMetrics.NextState.Validators))
@@ -41,42 +46,52 @@ func (p *AltairMetrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.CurrentNumAttestingVals = make([]bool, len(currentState.Validators))
 }
 
-func (p *AltairMetrics) PreProcessBundle() {
+func (p *AltairMetrics) PreProcessBundle() error {
 
 	if !p.baseMetrics.PrevState.EmptyStateRoot() && !p.baseMetrics.CurrentState.EmptyStateRoot() {
 		// block rewards
-		p.ProcessAttestations()
+		err := p.ProcessAttestations()
+		if err != nil {
+			return err
+		}
 		p.ProcessSlashings()
 		p.ProcessSyncAggregates()
 
-		p.GetMaxFlagIndexDeltas()
-		p.ProcessInclusionDelays()
+		err = p.GetMaxFlagIndexDeltas()
+		if err != nil {
+			return err
+		}
+		err = p.ProcessInclusionDelays()
+		if err != nil {
+			return err
+		}
 		p.GetMaxSyncComReward()
 	}
+	// only needs the blocks and sync committee of nextState
+	p.ProcessSyncParticipation()
+	p.ProcessPenalties()
+	return nil
 }
 
 func (p AltairMetrics) GetMetricsBase() StateMetricsBase {
 	return p.baseMetrics
 }
 
+func (p AltairMetrics) GetSyncParticipation() []spec.SyncCommitteeParticipation {
+	return p.SyncParticipation
+}
+
 func (p *AltairMetrics) ProcessSlashings() {
 
 	for _, block := range p.GetMetricsBase().NextState.Blocks {
-		slashedIdxs := make([]phase0.ValidatorIndex, 0)
 		whistleBlowerIdx := block.ProposerIndex // spec always contemplates whistleblower to be the block proposer
 		whistleBlowerReward := phase0.Gwei(0)
 		proposerReward := phase0.Gwei(0)
-		for _, attSlashing := range block.AttesterSlashings {
-			slashedIdxs = append(slashedIdxs, spec.SlashingIntersection(attSlashing.Attestation1.AttestingIndices, attSlashing.Attestation2.AttestingIndices)...)
-		}
-		for _, proposerSlashing := range block.ProposerSlashings {
-			slashedIdxs = append(slashedIdxs, proposerSlashing.SignedHeader1.Message.ProposerIndex)
-		}
 
-		for _, idx := range slashedIdxs {
+		for _, idx := range block.SlashedValidators() {
 			slashedEffBalance := p.baseMetrics.NextState.Validators[idx].EffectiveBalance
-			whistleBlowerReward += slashedEffBalance / spec.WhistleBlowerRewardQuotient
-			proposerReward += whistleBlowerReward * spec.ProposerWeight / spec.WeightDenominator
+			whistleBlowerReward += slashedEffBalance / phase0.Gwei(p.baseMetrics.Spec.WhistleblowerRewardQuotient(p.baseMetrics.NextState.Version))
+			proposerReward += whistleBlowerReward * phase0.Gwei(p.baseMetrics.Spec.ProposerWeight) / phase0.Gwei(p.baseMetrics.Spec.WeightDenominator)
 		}
 		p.baseMetrics.MaxSlashingRewards[block.ProposerIndex] += proposerReward
 		p.baseMetrics.MaxSlashingRewards[whistleBlowerIdx] += whistleBlowerReward - proposerReward
@@ -88,11 +103,8 @@ func (p *AltairMetrics) ProcessSlashings() {
 func (p AltairMetrics) ProcessSyncAggregates() {
 	for _, block := range p.baseMetrics.NextState.Blocks {
 
-		totalActiveInc := p.baseMetrics.NextState.TotalActiveBalance / spec.EffectiveBalanceInc
-		totalBaseRewards := p.GetBaseRewardPerInc(p.baseMetrics.NextState.TotalActiveBalance) * totalActiveInc
-		maxParticipantRewards := totalBaseRewards * phase0.Gwei(spec.SyncRewardWeight) / phase0.Gwei(spec.WeightDenominator) / spec.SlotsPerEpoch
-		participantReward := maxParticipantRewards / phase0.Gwei(spec.SyncCommitteeSize) // this is the participantReward for a single slot
-		singleProposerSyncReward := phase0.Gwei(participantReward * spec.ProposerWeight / (spec.WeightDenominator - spec.ProposerWeight))
+		participantReward := p.GetSyncParticipantReward() // this is the participantReward for a single slot
+		singleProposerSyncReward := participantReward * phase0.Gwei(p.baseMetrics.Spec.ProposerWeight) / phase0.Gwei(p.baseMetrics.Spec.WeightDenominator-p.baseMetrics.Spec.ProposerWeight)
 		proposerSyncReward := singleProposerSyncReward * phase0.Gwei(block.SyncAggregate.SyncCommitteeBits.Count())
 
 		p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += proposerSyncReward
@@ -100,29 +112,110 @@ func (p AltairMetrics) ProcessSyncAggregates() {
 	}
 }
 
-func (p *AltairMetrics) ProcessInclusionDelays() {
+// ProcessSyncParticipation decodes the sync committee bits of every block in nextState's epoch
+// against the sync committee, one entry per validator and slot
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#sync-aggregate-processing
+func (p *AltairMetrics) ProcessSyncParticipation() {
+	committee := p.syncCommitteeIndices()
+	if len(committee) == 0 {
+		return
+	}
+	participantReward := p.GetSyncParticipantReward()
+
+	p.SyncParticipation = make([]spec.SyncCommitteeParticipation, 0)
+	for _, block := range p.baseMetrics.NextState.Blocks {
+		// a validator can hold several seats, keep the members in committee order
+		participation := make(map[phase0.ValidatorIndex]*spec.SyncCommitteeParticipation)
+		members := make([]phase0.ValidatorIndex, 0)
+
+		for seat, valIdx := range committee {
+			if valIdx == unknownSyncSeat {
+				continue
+			}
+			item, ok := participation[valIdx]
+			if !ok {
+				item = &spec.SyncCommitteeParticipation{
+					Slot:           block.Slot,
+					ValidatorIndex: valIdx,
+					Signed:         block.Proposed,
+					MissedBlock:    !block.Proposed,
+				}
+				participation[valIdx] = item
+				members = append(members, valIdx)
+			}
+			if !block.Proposed {
+				continue // no sync aggregate was processed at the slot
+			}
+			if block.SyncAggregate.SyncCommitteeBits.BitAt(uint64(seat)) {
+				item.Reward += participantReward
+			} else {
+				item.Signed = false
+				item.Penalty += participantReward
+			}
+		}
+		for _, valIdx := range members {
+			p.SyncParticipation = append(p.SyncParticipation, *participation[valIdx])
+		}
+	}
+}
+
+// unknownSyncSeat marks the seats whose pubkey is not in the validator registry
+const unknownSyncSeat = phase0.ValidatorIndex(math.MaxUint64)
+
+// syncCommitteeIndices returns the validator index at every seat of nextState's sync committee,
+// unknownSyncSeat for the pubkeys that do not belong to any validator
+func (p AltairMetrics) syncCommitteeIndices() []phase0.ValidatorIndex {
+	pubkeys := p.baseMetrics.NextState.SyncCommittee.Pubkeys
+	members := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		members[pubkey] = unknownSyncSeat
+	}
+	for valIdx, validator := range p.baseMetrics.NextState.Validators {
+		if _, ok := members[validator.PublicKey]; ok {
+			members[validator.PublicKey] = phase0.ValidatorIndex(valIdx)
+		}
+	}
+
+	committee := make([]phase0.ValidatorIndex, len(pubkeys))
+	for seat, pubkey := range pubkeys {
+		committee[seat] = members[pubkey]
+		if committee[seat] == unknownSyncSeat {
+			log.Errorf("sync committee seat %d at epoch %d: pubkey %s not found in the validator registry",
+				seat, p.baseMetrics.NextState.Epoch, pubkey.String())
+		}
+	}
+	return committee
+}
+
+// ProcessInclusionDelays also records the attestation duties of prevState's epoch
+func (p *AltairMetrics) ProcessInclusionDelays() error {
+	p.initAttestationDuties()
 	for _, block := range append(p.baseMetrics.PrevState.Blocks, p.baseMetrics.CurrentState.Blocks...) {
 		// we assume the blocks are in order asc
 		for _, attestation := range block.Attestations {
 			attSlot := attestation.Data.Slot
 			// Calculate inclusion delays only for attestations corresponding to slots from the previous epoch
-			attSlotNotInPrevEpoch := attSlot < phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch || attSlot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch
+			attSlotNotInPrevEpoch := !slotInEpoch(p.baseMetrics.Spec, attSlot, p.baseMetrics.PrevState.Epoch)
 			if attSlotNotInPrevEpoch {
 				continue
 			}
 			inclusionDelay := p.GetInclusionDelay(*attestation, *block)
-			committeIndex := attestation.Data.Index
-
-			attestingIndices := attestation.AggregationBits.BitIndices()
+			correctVote, rootsErr := p.correctVoteRoots(attestation.Data)
+			if rootsErr != nil {
+				log.Errorf("error getting canonical roots at slot %d: %s", attSlot, rootsErr)
+			}
 
-			for _, idx := range attestingIndices {
-				valIdx, err := p.GetValidatorFromCommitteeIndex(attSlot, committeIndex, idx)
-				if err != nil {
-					log.Fatalf("error processing attestations at block %d: %s", block.Slot, err)
-				}
+			attestingIndices, err := p.GetAttestingIndices(*attestation)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
+			for _, valIdx := range attestingIndices {
 				if p.baseMetrics.InclusionDelays[valIdx] == 0 {
 					p.baseMetrics.InclusionDelays[valIdx] = inclusionDelay
+					if rootsErr == nil {
+						p.recordAttestationDuty(valIdx, attestation.Data, block, correctVote)
+					}
 				}
 			}
 		}
@@ -133,13 +226,14 @@ func (p *AltairMetrics) ProcessInclusionDelays() {
 			p.baseMetrics.InclusionDelays[valIdx] = p.maxInclusionDelay(phase0.ValidatorIndex(valIdx)) + 1
 		}
 	}
+	return nil
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#modified-process_attestation
-func (p AltairMetrics) ProcessAttestations() {
+func (p AltairMetrics) ProcessAttestations() error {
 
 	if p.baseMetrics.CurrentState.Blocks == nil { // only process attestations when CurrentState available
-		return
+		return nil
 	}
 
 	currentEpochParticipation := make([][]bool, len(p.baseMetrics.CurrentState.Validators))
@@ -157,32 +251,32 @@ func (p AltairMetrics) ProcessAttestations() {
 			attReward := phase0.Gwei(0)
 			slot := attestation.Data.Slot
 			epochParticipation := nextEpochParticipation
-			if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
+			if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 				epochParticipation = currentEpochParticipation
 			}
 
-			if slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+			if slot < p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.CurrentState.Epoch) {
 				continue
 			}
 
-			participationFlags := p.getParticipationFlags(*attestation, *block)
-
-			committeIndex := attestation.Data.Index
+			participationFlags, err := p.getParticipationFlags(*attestation, *block)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
-			attestingIndices := attestation.AggregationBits.BitIndices()
+			attestingIndices, err := p.GetAttestingIndices(*attestation)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
-			for _, idx := range attestingIndices {
+			for _, valIdx := range attestingIndices {
 				block.VotesIncluded += 1
 
-				valIdx, err := p.GetValidatorFromCommitteeIndex(slot, committeIndex, idx)
-				if err != nil {
-					log.Fatalf("error processing attestations at block %d: %s", block.Slot, err)
-				}
 				if epochParticipation[valIdx] == nil {
-					epochParticipation[valIdx] = make([]bool, len(spec.ParticipatingFlagsWeight))
+					epochParticipation[valIdx] = make([]bool, len(p.baseMetrics.Spec.ParticipatingFlagsWeight()))
 				}
 
-				if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
+				if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 					p.baseMetrics.CurrentNumAttestingVals[valIdx] = true
 				}
 
@@ -191,17 +285,17 @@ func (p AltairMetrics) ProcessAttestations() {
 
 				new := false
 				if participationFlags[spec.AttSourceFlagIndex] && !epochParticipation[valIdx][spec.AttSourceFlagIndex] { // source
-					attReward += attesterBaseReward * spec.TimelySourceWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelySourceWeight)
 					epochParticipation[valIdx][spec.AttSourceFlagIndex] = true
 					new = true
 				}
 				if participationFlags[spec.AttTargetFlagIndex] && !epochParticipation[valIdx][spec.AttTargetFlagIndex] { // target
-					attReward += attesterBaseReward * spec.TimelyTargetWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelyTargetWeight)
 					epochParticipation[valIdx][spec.AttTargetFlagIndex] = true
 					new = true
 				}
 				if participationFlags[spec.AttHeadFlagIndex] && !epochParticipation[valIdx][spec.AttHeadFlagIndex] { // head
-					attReward += attesterBaseReward * spec.TimelyHeadWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelyHeadWeight)
 					epochParticipation[valIdx][spec.AttHeadFlagIndex] = true
 					new = true
 				}
@@ -211,8 +305,8 @@ func (p AltairMetrics) ProcessAttestations() {
 			}
 
 			// only process rewards for blocks in NextState
-			if block.Slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
-				denominator := phase0.Gwei((spec.WeightDenominator - spec.ProposerWeight) * spec.WeightDenominator / spec.ProposerWeight)
+			if block.Slot >= p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.NextState.Epoch) {
+				denominator := phase0.Gwei((p.baseMetrics.Spec.WeightDenominator - p.baseMetrics.Spec.ProposerWeight) * p.baseMetrics.Spec.WeightDenominator / p.baseMetrics.Spec.ProposerWeight)
 				attReward = attReward / denominator
 
 				p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += attReward
@@ -222,6 +316,8 @@ func (p AltairMetrics) ProcessAttestations() {
 		}
 
 	}
+	p.setHeadVoteMisses(currentEpochParticipation)
+	return nil
 }
 
 // So far we have computed the max sync committee proposer reward for a slot. Since the validator remains in the sync committee for the full epoch, we multiply the reward for the 32 slots in the epoch.
@@ -236,12 +332,9 @@ func (p AltairMetrics) GetMaxSyncComReward() {
 				// at this point we know the validator was inside the sync committee and, therefore, active at that point
 
 				reward := phase0.Gwei(0)
-				totalActiveInc := p.baseMetrics.NextState.TotalActiveBalance / spec.EffectiveBalanceInc
-				totalBaseRewards := p.GetBaseRewardPerInc(p.baseMetrics.NextState.TotalActiveBalance) * totalActiveInc
-				maxParticipantRewards := totalBaseRewards * phase0.Gwei(spec.SyncRewardWeight) / phase0.Gwei(spec.WeightDenominator) / spec.SlotsPerEpoch
-				participantReward := maxParticipantRewards / phase0.Gwei(spec.SyncCommitteeSize) // this is the participantReward for a single slot
+				participantReward := p.GetSyncParticipantReward() // this is the participantReward for a single slot
 
-				reward += participantReward * phase0.Gwei(spec.SlotsPerEpoch-len(p.baseMetrics.NextState.MissedBlocks)) // max reward would be 32 perfect slots
+				reward += participantReward * phase0.Gwei(int(p.baseMetrics.Spec.SlotsPerEpoch)-len(p.baseMetrics.NextState.MissedBlocks)) // max reward would be all slots of the epoch proposed
 				p.MaxSyncCommitteeRewards[phase0.ValidatorIndex(valIdx)] += reward
 			}
 		}
@@ -251,7 +344,7 @@ func (p AltairMetrics) GetMaxSyncComReward() {
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#get_flag_index_deltas
-func (p AltairMetrics) GetMaxFlagIndexDeltas() {
+func (p AltairMetrics) GetMaxFlagIndexDeltas() error {
 
 	for valIdx, validator := range p.baseMetrics.NextState.Validators {
 		maxFlagsReward := phase0.Gwei(0)
@@ -263,20 +356,25 @@ func (p AltairMetrics) GetMaxFlagIndexDeltas() {
 
 			for i := range p.baseMetrics.CurrentState.AttestingBalance {
 
-				if !p.isFlagPossible(phase0.ValidatorIndex(valIdx), i) { // consider if the attester could have achieved the flag (inclusion delay wise)
+				possible, err := p.isFlagPossible(phase0.ValidatorIndex(valIdx), i)
+				if err != nil {
+					return err
+				}
+				if !possible { // consider if the attester could have achieved the flag (inclusion delay wise)
 					continue
 				}
 				// apply formula
-				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / spec.EffectiveBalanceInc
+				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / p.baseMetrics.Spec.EffectiveBalanceIncrement
 
-				flagReward := phase0.Gwei(spec.ParticipatingFlagsWeight[i]) * baseReward * attestingBalanceInc
-				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / spec.EffectiveBalanceInc)) * phase0.Gwei(spec.WeightDenominator))
+				flagReward := phase0.Gwei(p.baseMetrics.Spec.ParticipatingFlagsWeight()[i]) * baseReward * attestingBalanceInc
+				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement)) * phase0.Gwei(p.baseMetrics.Spec.WeightDenominator))
 				maxFlagsReward += flagReward
 			}
 		}
 
 		p.baseMetrics.MaxAttesterRewards[phase0.ValidatorIndex(valIdx)] += maxFlagsReward
 	}
+	return nil
 }
 
 // This method returns the Max Reward the validator could gain
@@ -332,12 +430,13 @@ func (p AltairMetrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 		InSyncCommittee:      inSyncCommitte,
 		InclusionDelay:       p.baseMetrics.InclusionDelays[valIdx],
 	}
+	p.addRewardsBreakdown(&result)
 	return result, nil
 
 }
 
 func (p AltairMetrics) GetBaseReward(valIdx phase0.ValidatorIndex, effectiveBalance phase0.Gwei, totalEffectiveBalance phase0.Gwei) phase0.Gwei {
-	effectiveBalanceInc := effectiveBalance / spec.EffectiveBalanceInc
+	effectiveBalanceInc := effectiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement
 	return p.GetBaseRewardPerInc(totalEffectiveBalance) * effectiveBalanceInc
 }
 
@@ -345,24 +444,33 @@ func (p AltairMetrics) GetBaseRewardPerInc(totalEffectiveBalance phase0.Gwei) ph
 
 	var baseReward phase0.Gwei
 
-	sqrt := uint64(math.Sqrt(float64(totalEffectiveBalance)))
+	sqrt := integerSquareroot(uint64(totalEffectiveBalance))
 
-	num := spec.EffectiveBalanceInc * spec.BaseRewardFactor
-	baseReward = phase0.Gwei(uint64(num) / sqrt)
+	num := uint64(p.baseMetrics.Spec.EffectiveBalanceIncrement) * p.baseMetrics.Spec.BaseRewardFactor
+	baseReward = phase0.Gwei(num / sqrt)
 
 	return baseReward
 }
 
-func (p AltairMetrics) GetInclusionDelay(attestation phase0.Attestation, includedInBlock spec.AgnosticBlock) int {
+// GetSyncParticipantReward returns the reward of a sync committee member for a single slot
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#sync-aggregate-processing
+func (p AltairMetrics) GetSyncParticipantReward() phase0.Gwei {
+	totalActiveInc := p.baseMetrics.NextState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement
+	totalBaseRewards := p.GetBaseRewardPerInc(p.baseMetrics.NextState.TotalActiveBalance) * totalActiveInc
+	maxParticipantRewards := totalBaseRewards * phase0.Gwei(p.baseMetrics.Spec.SyncRewardWeight) / phase0.Gwei(p.baseMetrics.Spec.WeightDenominator) / phase0.Gwei(p.baseMetrics.Spec.SlotsPerEpoch)
+	return maxParticipantRewards / phase0.Gwei(p.baseMetrics.Spec.SyncCommitteeSize)
+}
+
+func (p AltairMetrics) GetInclusionDelay(attestation spec.AgnosticAttestation, includedInBlock spec.AgnosticBlock) int {
 	return int(includedInBlock.Slot - attestation.Data.Slot)
 }
 
-func (p AltairMetrics) getParticipationFlags(attestation phase0.Attestation, includedInBlock spec.AgnosticBlock) [3]bool {
+func (p AltairMetrics) getParticipationFlags(attestation spec.AgnosticAttestation, includedInBlock spec.AgnosticBlock) ([3]bool, error) {
 	var result [3]bool
 
 	justifiedCheckpoint, err := p.GetJustifiedRootfromSlot(attestation.Data.Slot)
 	if err != nil {
-		log.Fatalf("error getting justified checkpoint: %s", err)
+		return result, fmt.Errorf("error getting justified checkpoint: %s", err)
 	}
 
 	inclusionDelay := p.GetInclusionDelay(attestation, includedInBlock)
@@ -374,50 +482,50 @@ func (p AltairMetrics) getParticipationFlags(attestation phase0.Attestation, inc
 	matchingTarget := matchingSource && targetRoot == attestation.Data.Target.Root
 	matchingHead := matchingTarget && attestation.Data.BeaconBlockRoot == headRoot
 
-	if matchingSource && (inclusionDelay <= int(math.Sqrt(spec.SlotsPerEpoch))) {
+	if matchingSource && (inclusionDelay <= int(math.Sqrt(float64(p.baseMetrics.Spec.SlotsPerEpoch)))) {
 		result[spec.AttSourceFlagIndex] = true
 	}
-	if matchingTarget && (inclusionDelay <= spec.SlotsPerEpoch) {
+	if matchingTarget && (inclusionDelay <= int(p.baseMetrics.Spec.SlotsPerEpoch)) {
 		result[spec.AttTargetFlagIndex] = true
 	}
-	if matchingHead && (inclusionDelay <= spec.MinInclusionDelay) {
+	if matchingHead && (inclusionDelay <= int(p.baseMetrics.Spec.MinAttestationInclusionDelay)) {
 		result[spec.AttHeadFlagIndex] = true
 	}
 
-	return result
+	return result, nil
 }
 
-func (p AltairMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int) bool {
+func (p AltairMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int) (bool, error) {
 	attSlot := p.baseMetrics.PrevState.EpochStructs.ValidatorAttSlot[valIdx]
 	maxInclusionDelay := 0
 
 	switch flagIndex { // for every flag there is a max inclusion delay to obtain a reward
 	case spec.AttSourceFlagIndex: // 5
-		maxInclusionDelay = int(math.Sqrt(spec.SlotsPerEpoch))
+		maxInclusionDelay = int(math.Sqrt(float64(p.baseMetrics.Spec.SlotsPerEpoch)))
 	case spec.AttTargetFlagIndex: // 32
-		maxInclusionDelay = spec.SlotsPerEpoch
+		maxInclusionDelay = int(p.baseMetrics.Spec.SlotsPerEpoch)
 	case spec.AttHeadFlagIndex: // 1
-		maxInclusionDelay = spec.MinInclusionDelay
+		maxInclusionDelay = int(p.baseMetrics.Spec.MinAttestationInclusionDelay)
 	default:
-		log.Fatalf("provided flag index %d is not known", flagIndex)
+		return false, fmt.Errorf("provided flag index %d is not known", flagIndex)
 	}
 
 	// look for any block proposed => the attester could have achieved it
 	for slot := attSlot + 1; slot <= (attSlot + phase0.Slot(maxInclusionDelay)); slot++ {
-		slotInEpoch := slot % spec.SlotsPerEpoch
+		slotInEpoch := uint64(slot) % p.baseMetrics.Spec.SlotsPerEpoch
 		block := p.baseMetrics.PrevState.Blocks[slotInEpoch]
-		if slot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch*spec.SlotsPerEpoch) {
+		if slot >= p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.CurrentState.Epoch) {
 			block = p.baseMetrics.CurrentState.Blocks[slotInEpoch]
 		}
 
 		if block.Proposed { // if there was a block proposed inside the inclusion window
-			return true
+			return true, nil
 		}
 	}
-	return false
+	return false, nil
 
 }
 
 func (p AltairMetrics) maxInclusionDelay(valIdx phase0.ValidatorIndex) int {
-	return spec.SlotsPerEpoch
+	return int(p.baseMetrics.Spec.SlotsPerEpoch)
 }
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/migalabs/goteth/pkg/spec"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessSyncParticipation(t *testing.T) {
+	chainSpec := spec.MainnetChainSpec()
+	chainSpec.SyncCommitteeSize = 4
+
+	validators := make([]*phase0.Validator, 3)
+	for i := range validators {
+		validators[i] = &phase0.Validator{PublicKey: phase0.BLSPubKey{byte(i + 1)}}
+	}
+	// validator 2 holds two seats
+	committee := altair.SyncCommittee{Pubkeys: []phase0.BLSPubKey{
+		validators[2].PublicKey, validators[0].PublicKey, validators[2].PublicKey, validators[1].PublicKey}}
+
+	bits := bitfield.NewBitvector512()
+	bits.SetBitAt(0, true)
+	bits.SetBitAt(1, true)
+	bits.SetBitAt(2, true)
+	blocks := []*spec.AgnosticBlock{
+		{Slot: 64, Proposed: true, SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: bits}},
+		{Slot: 65, Proposed: false},
+	}
+
+	metrics := AltairMetrics{}
+	metrics.baseMetrics.Spec = chainSpec
+	metrics.baseMetrics.NextState = &spec.AgnosticState{
+		Validators:         validators,
+		SyncCommittee:      committee,
+		Blocks:             blocks,
+		TotalActiveBalance: 1000000 * spec.EffectiveBalanceInc,
+	}
+	metrics.ProcessSyncParticipation()
+	reward := metrics.GetSyncParticipantReward()
+
+	assert.Equal(t, []spec.SyncCommitteeParticipation{
+		{Slot: 64, ValidatorIndex: 2, Signed: true, Reward: 2 * reward},
+		{Slot: 64, ValidatorIndex: 0, Signed: true, Reward: reward},
+		{Slot: 64, ValidatorIndex: 1, Signed: false, Penalty: reward},
+		{Slot: 65, ValidatorIndex: 2, MissedBlock: true},
+		{Slot: 65, ValidatorIndex: 0, MissedBlock: true},
+		{Slot: 65, ValidatorIndex: 1, MissedBlock: true},
+	}, metrics.GetSyncParticipation())
+	// the seats of unknown pubkeys are skipped, not credited to validator 0
+	metrics.baseMetrics.NextState.SyncCommittee.Pubkeys[1] = phase0.BLSPubKey{0xff}
+	metrics.ProcessSyncParticipation()
+	assert.Equal(t, []spec.SyncCommitteeParticipation{
+		{Slot: 64, ValidatorIndex: 2, Signed: true, Reward: 2 * reward},
+		{Slot: 64, ValidatorIndex: 1, Signed: false, Penalty: reward},
+		{Slot: 65, ValidatorIndex: 2, MissedBlock: true},
+		{Slot: 65, ValidatorIndex: 1, MissedBlock: true},
+	}, metrics.GetSyncParticipation())
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -14,14 +15,14 @@ type DenebMetrics struct {
 func NewDenebMetrics(
 	nextState *spec.AgnosticState,
 	currentState *spec.AgnosticState,
-	prevState *spec.AgnosticState) DenebMetrics {
+	prevState *spec.AgnosticState) (DenebMetrics, error) {
 
 	denebObj := DenebMetrics{}
 
 	denebObj.InitBundle(nextState, currentState, prevState)
-	denebObj.PreProcessBundle()
+	err := denebObj.PreProcessBundle()
 
-	return denebObj
+	return denebObj, err
 }
 
 func (p *DenebMetrics) InitBundle(nextState *spec.AgnosticState,
@@ -30,6 +31,7 @@ func (p *DenebMetrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.NextState = nextState
 	p.baseMetrics.CurrentState = currentState
 	p.baseMetrics.PrevState = prevState
+	p.baseMetrics.Spec = nextState.Spec
 	p.baseMetrics.MaxBlockRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.MaxSlashingRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.InclusionDelays = make([]int, len(p.baseMetrics.NextState.Validators))
@@ -38,25 +40,38 @@ func (p *DenebMetrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.CurrentNumAttestingVals = make([]bool, len(currentState.Validators))
 }
 
-func (p *DenebMetrics) PreProcessBundle() {
+func (p *DenebMetrics) PreProcessBundle() error {
 
 	if !p.baseMetrics.PrevState.EmptyStateRoot() && !p.baseMetrics.CurrentState.EmptyStateRoot() {
 		// block rewards
-		p.ProcessAttestations()
+		err := p.ProcessAttestations()
+		if err != nil {
+			return err
+		}
 		p.ProcessSlashings()
 		p.ProcessSyncAggregates()
 
-		p.GetMaxFlagIndexDeltas()
-		p.ProcessInclusionDelays()
+		err = p.GetMaxFlagIndexDeltas()
+		if err != nil {
+			return err
+		}
+		err = p.ProcessInclusionDelays()
+		if err != nil {
+			return err
+		}
 		p.GetMaxSyncComReward()
 	}
+	// only needs the blocks and sync committee of nextState
+	p.ProcessSyncParticipation()
+	p.ProcessPenalties()
+	return nil
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#modified-process_attestation
-func (p DenebMetrics) ProcessAttestations() {
+func (p DenebMetrics) ProcessAttestations() error {
 
 	if p.baseMetrics.CurrentState.Blocks == nil { // only process attestations when CurrentState available
-		return
+		return nil
 	}
 
 	currentEpochParticipation := make([][]bool, len(p.baseMetrics.CurrentState.Validators))
@@ -74,32 +89,32 @@ func (p DenebMetrics) ProcessAttestations() {
 			attReward := phase0.Gwei(0)
 			slot := attestation.Data.Slot
 			epochParticipation := nextEpochParticipation
-			if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
+			if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 				epochParticipation = currentEpochParticipation
 			}
 
-			if slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+			if slot < p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.CurrentState.Epoch) {
 				continue
 			}
 
-			participationFlags := p.getParticipationFlags(*attestation, *block)
-
-			committeIndex := attestation.Data.Index
+			participationFlags, err := p.getParticipationFlags(*attestation, *block)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
-			attestingIndices := attestation.AggregationBits.BitIndices()
+			attestingIndices, err := p.GetAttestingIndices(*attestation)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
-			for _, idx := range attestingIndices {
+			for _, valIdx := range attestingIndices {
 				block.VotesIncluded += 1
 
-				valIdx, err := p.GetValidatorFromCommitteeIndex(slot, committeIndex, idx)
-				if err != nil {
-					log.Fatalf("error processing attestations at block %d: %s", block.Slot, err)
-				}
 				if epochParticipation[valIdx] == nil {
-					epochParticipation[valIdx] = make([]bool, len(spec.ParticipatingFlagsWeight))
+					epochParticipation[valIdx] = make([]bool, len(p.baseMetrics.Spec.ParticipatingFlagsWeight()))
 				}
 
-				if slotInEpoch(slot, p.baseMetrics.CurrentState.Epoch) {
+				if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 					p.baseMetrics.CurrentNumAttestingVals[valIdx] = true
 				}
 
@@ -108,17 +123,17 @@ func (p DenebMetrics) ProcessAttestations() {
 
 				new := false
 				if participationFlags[spec.AttSourceFlagIndex] && !epochParticipation[valIdx][spec.AttSourceFlagIndex] { // source
-					attReward += attesterBaseReward * spec.TimelySourceWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelySourceWeight)
 					epochParticipation[valIdx][spec.AttSourceFlagIndex] = true
 					new = true
 				}
 				if participationFlags[spec.AttTargetFlagIndex] && !epochParticipation[valIdx][spec.AttTargetFlagIndex] { // target
-					attReward += attesterBaseReward * spec.TimelyTargetWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelyTargetWeight)
 					epochParticipation[valIdx][spec.AttTargetFlagIndex] = true
 					new = true
 				}
 				if participationFlags[spec.AttHeadFlagIndex] && !epochParticipation[valIdx][spec.AttHeadFlagIndex] { // head
-					attReward += attesterBaseReward * spec.TimelyHeadWeight
+					attReward += attesterBaseReward * phase0.Gwei(p.baseMetrics.Spec.TimelyHeadWeight)
 					epochParticipation[valIdx][spec.AttHeadFlagIndex] = true
 					new = true
 				}
@@ -128,8 +143,8 @@ func (p DenebMetrics) ProcessAttestations() {
 			}
 
 			// only process rewards for blocks in NextState
-			if block.Slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
-				denominator := phase0.Gwei((spec.WeightDenominator - spec.ProposerWeight) * spec.WeightDenominator / spec.ProposerWeight)
+			if block.Slot >= p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.NextState.Epoch) {
+				denominator := phase0.Gwei((p.baseMetrics.Spec.WeightDenominator - p.baseMetrics.Spec.ProposerWeight) * p.baseMetrics.Spec.WeightDenominator / p.baseMetrics.Spec.ProposerWeight)
 				attReward = attReward / denominator
 
 				p.baseMetrics.MaxBlockRewards[block.ProposerIndex] += attReward
@@ -139,31 +154,39 @@ func (p DenebMetrics) ProcessAttestations() {
 		}
 
 	}
+	p.setHeadVoteMisses(currentEpochParticipation)
+	return nil
 }
 
-func (p *DenebMetrics) ProcessInclusionDelays() {
+// ProcessInclusionDelays also records the attestation duties of prevState's epoch
+func (p *DenebMetrics) ProcessInclusionDelays() error {
+	p.initAttestationDuties()
 	for _, block := range append(p.baseMetrics.PrevState.Blocks, p.baseMetrics.CurrentState.Blocks...) {
 		// we assume the blocks are in order asc
 		for _, attestation := range block.Attestations {
 			attSlot := attestation.Data.Slot
 			// Calculate inclusion delays only for attestations corresponding to slots from the previous epoch
-			attSlotNotInPrevEpoch := attSlot < phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch || attSlot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch
+			attSlotNotInPrevEpoch := !slotInEpoch(p.baseMetrics.Spec, attSlot, p.baseMetrics.PrevState.Epoch)
 			if attSlotNotInPrevEpoch {
 				continue
 			}
 			inclusionDelay := p.GetInclusionDelay(*attestation, *block)
-			committeIndex := attestation.Data.Index
-
-			attestingIndices := attestation.AggregationBits.BitIndices()
+			correctVote, rootsErr := p.correctVoteRoots(attestation.Data)
+			if rootsErr != nil {
+				log.Errorf("error getting canonical roots at slot %d: %s", attSlot, rootsErr)
+			}
 
-			for _, idx := range attestingIndices {
-				valIdx, err := p.GetValidatorFromCommitteeIndex(attSlot, committeIndex, idx)
-				if err != nil {
-					log.Fatalf("error processing attestations at block %d: %s", block.Slot, err)
-				}
+			attestingIndices, err := p.GetAttestingIndices(*attestation)
+			if err != nil {
+				return fmt.Errorf("error processing attestations at block %d: %s", block.Slot, err)
+			}
 
+			for _, valIdx := range attestingIndices {
 				if p.baseMetrics.InclusionDelays[valIdx] == 0 {
 					p.baseMetrics.InclusionDelays[valIdx] = inclusionDelay
+					if rootsErr == nil {
+						p.recordAttestationDuty(valIdx, attestation.Data, block, correctVote)
+					}
 				}
 			}
 		}
@@ -174,10 +197,11 @@ func (p *DenebMetrics) ProcessInclusionDelays() {
 			p.baseMetrics.InclusionDelays[valIdx] = p.maxInclusionDelay(phase0.ValidatorIndex(valIdx)) + 1
 		}
 	}
+	return nil
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/altair/beacon-chain.md#get_flag_index_deltas
-func (p DenebMetrics) GetMaxFlagIndexDeltas() {
+func (p DenebMetrics) GetMaxFlagIndexDeltas() error {
 
 	for valIdx, validator := range p.baseMetrics.NextState.Validators {
 		maxFlagsReward := phase0.Gwei(0)
@@ -189,28 +213,33 @@ func (p DenebMetrics) GetMaxFlagIndexDeltas() {
 
 			for i := range p.baseMetrics.CurrentState.AttestingBalance {
 
-				if !p.isFlagPossible(phase0.ValidatorIndex(valIdx), i) { // consider if the attester could have achieved the flag (inclusion delay wise)
+				possible, err := p.isFlagPossible(phase0.ValidatorIndex(valIdx), i)
+				if err != nil {
+					return err
+				}
+				if !possible { // consider if the attester could have achieved the flag (inclusion delay wise)
 					continue
 				}
 				// apply formula
-				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / spec.EffectiveBalanceInc
+				attestingBalanceInc := p.baseMetrics.CurrentState.AttestingBalance[i] / p.baseMetrics.Spec.EffectiveBalanceIncrement
 
-				flagReward := phase0.Gwei(spec.ParticipatingFlagsWeight[i]) * baseReward * attestingBalanceInc
-				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / spec.EffectiveBalanceInc)) * phase0.Gwei(spec.WeightDenominator))
+				flagReward := phase0.Gwei(p.baseMetrics.Spec.ParticipatingFlagsWeight()[i]) * baseReward * attestingBalanceInc
+				flagReward = flagReward / ((phase0.Gwei(p.baseMetrics.CurrentState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement)) * phase0.Gwei(p.baseMetrics.Spec.WeightDenominator))
 				maxFlagsReward += flagReward
 			}
 		}
 
 		p.baseMetrics.MaxAttesterRewards[phase0.ValidatorIndex(valIdx)] += maxFlagsReward
 	}
+	return nil
 }
 
-func (p DenebMetrics) getParticipationFlags(attestation phase0.Attestation, includedInBlock spec.AgnosticBlock) [3]bool {
+func (p DenebMetrics) getParticipationFlags(attestation spec.AgnosticAttestation, includedInBlock spec.AgnosticBlock) ([3]bool, error) {
 	var result [3]bool
 
 	justifiedCheckpoint, err := p.GetJustifiedRootfromSlot(attestation.Data.Slot)
 	if err != nil {
-		log.Fatalf("error getting justified checkpoint: %s", err)
+		return result, fmt.Errorf("error getting justified checkpoint: %s", err)
 	}
 
 	inclusionDelay := p.GetInclusionDelay(attestation, includedInBlock)
@@ -226,55 +255,55 @@ func (p DenebMetrics) getParticipationFlags(attestation phase0.Attestation, incl
 	// the worst case scenario is an attestation to the slot 31, which gives a max inclusion delay of 32
 	// the best case scenario is an attestation to the slot 0, which gives a max inclusion delay of 64
 	// https://github.com/ethereum/consensus-specs/blob/dev/specs/deneb/beacon-chain.md#modified-get_attestation_participation_flag_indices
-	includedInEpoch := phase0.Epoch(includedInBlock.Slot / spec.SlotsPerEpoch)
-	attestationEpoch := phase0.Epoch(attestation.Data.Slot / spec.SlotsPerEpoch)
+	includedInEpoch := p.baseMetrics.Spec.EpochAtSlot(includedInBlock.Slot)
+	attestationEpoch := p.baseMetrics.Spec.EpochAtSlot(attestation.Data.Slot)
 	targetInclusionOk := includedInEpoch-attestationEpoch <= 1
 
-	if matchingSource && (inclusionDelay <= int(math.Sqrt(spec.SlotsPerEpoch))) {
+	if matchingSource && (inclusionDelay <= int(math.Sqrt(float64(p.baseMetrics.Spec.SlotsPerEpoch)))) {
 		result[0] = true
 	}
 	if matchingTarget && targetInclusionOk {
 		result[1] = true
 	}
-	if matchingHead && (inclusionDelay <= spec.MinInclusionDelay) {
+	if matchingHead && (inclusionDelay <= int(p.baseMetrics.Spec.MinAttestationInclusionDelay)) {
 		result[2] = true
 	}
 
-	return result
+	return result, nil
 }
 
-func (p DenebMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int) bool {
+func (p DenebMetrics) isFlagPossible(valIdx phase0.ValidatorIndex, flagIndex int) (bool, error) {
 	attSlot := p.baseMetrics.PrevState.EpochStructs.ValidatorAttSlot[valIdx]
 	maxInclusionDelay := 0
 
 	switch flagIndex { // for every flag there is a max inclusion delay to obtain a reward
 
 	case spec.AttSourceFlagIndex: // 5
-		maxInclusionDelay = int(math.Sqrt(spec.SlotsPerEpoch))
+		maxInclusionDelay = int(math.Sqrt(float64(p.baseMetrics.Spec.SlotsPerEpoch)))
 
 	case spec.AttTargetFlagIndex: // until end of next epoch
-		remainingSlotsInEpoch := spec.SlotsPerEpoch - int(attSlot%spec.SlotsPerEpoch)
-		maxInclusionDelay = spec.SlotsPerEpoch + remainingSlotsInEpoch
+		remainingSlotsInEpoch := p.baseMetrics.Spec.SlotsPerEpoch - uint64(attSlot)%p.baseMetrics.Spec.SlotsPerEpoch
+		maxInclusionDelay = int(p.baseMetrics.Spec.SlotsPerEpoch + remainingSlotsInEpoch)
 
 	case spec.AttHeadFlagIndex: // 1
 		maxInclusionDelay = 1
 	default:
-		log.Fatalf("provided flag index %d is not known", flagIndex)
+		return false, fmt.Errorf("provided flag index %d is not known", flagIndex)
 	}
 
 	// look for any block proposed => the attester could have achieved it
 	for slot := attSlot + 1; slot <= (attSlot + phase0.Slot(maxInclusionDelay)); slot++ {
-		slotInEpoch := slot % spec.SlotsPerEpoch
+		slotInEpoch := uint64(slot) % p.baseMetrics.Spec.SlotsPerEpoch
 		block := p.baseMetrics.PrevState.Blocks[slotInEpoch]
-		if slot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch*spec.SlotsPerEpoch) {
+		if slot >= p.baseMetrics.Spec.EpochStartSlot(p.baseMetrics.CurrentState.Epoch) {
 			block = p.baseMetrics.CurrentState.Blocks[slotInEpoch]
 		}
 
 		if block.Proposed { // if there was a block proposed inside the inclusion window
-			return true
+			return true, nil
 		}
 	}
-	return false
+	return false, nil
 
 }
 
@@ -284,7 +313,7 @@ func (p DenebMetrics) maxInclusionDelay(valIdx phase0.ValidatorIndex) int {
 
 	slot := p.baseMetrics.PrevState.EpochStructs.ValidatorAttSlot[valIdx]
 
-	slotsUntilEpochEnd := spec.SlotsPerEpoch - (slot % spec.SlotsPerEpoch) - 1
+	slotsUntilEpochEnd := p.baseMetrics.Spec.SlotsPerEpoch - uint64(slot)%p.baseMetrics.Spec.SlotsPerEpoch - 1
 
-	return spec.SlotsPerEpoch + int(slotsUntilEpochEnd)
+	return int(p.baseMetrics.Spec.SlotsPerEpoch + slotsUntilEpochEnd)
 }
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/migalabs/goteth/pkg/spec"
+)
+
+// ElectraMetrics keeps the Deneb rewards, the changes are in the quotients of the chain spec,
+// the committee bits of the attestations and the balances moved by the pending queues
+type ElectraMetrics struct {
+	DenebMetrics
+}
+
+func NewElectraMetrics(
+	nextState *spec.AgnosticState,
+	currentState *spec.AgnosticState,
+	prevState *spec.AgnosticState) (ElectraMetrics, error) {
+
+	electraObj := ElectraMetrics{}
+
+	electraObj.InitBundle(nextState, currentState, prevState)
+	err := electraObj.PreProcessBundle()
+
+	return electraObj, err
+}
+
+func (p *ElectraMetrics) InitBundle(nextState *spec.AgnosticState,
+	currentState *spec.AgnosticState,
+	prevState *spec.AgnosticState) {
+	p.DenebMetrics.InitBundle(nextState, currentState, prevState)
+
+	// the applied deposits and consolidations are only known from the queues of currentState
+	if !currentState.EmptyStateRoot() {
+		nextState.CalculateElectraTransfers(currentState)
+	}
+}
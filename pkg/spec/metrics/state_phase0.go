@@ -2,25 +2,31 @@ package metrics
 
 import (
 	"bytes"
-
-	"math"
+	"fmt"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
 type Phase0Metrics struct {
-	baseMetrics StateMetricsBase
+	baseMetrics       StateMetricsBase
+	SlashingPenalties map[phase0.ValidatorIndex]phase0.Gwei           // initial penalty of the validators slashed in nextState
+	attestationDuties map[phase0.ValidatorIndex]*spec.AttestationDuty // one per attester of prevState's epoch
+
+	// totals of the epoch transition into nextState's epoch
+	unslashedParticipatingInc [3]phase0.Gwei // per flag
+	totalSlashings            phase0.Gwei
+	inactivityLeak            bool
 }
 
-func NewPhase0Metrics(nextState *spec.AgnosticState, currentState *spec.AgnosticState, prevState *spec.AgnosticState) Phase0Metrics {
+func NewPhase0Metrics(nextState *spec.AgnosticState, currentState *spec.AgnosticState, prevState *spec.AgnosticState) (Phase0Metrics, error) {
 
 	phase0Obj := Phase0Metrics{}
 
 	phase0Obj.InitBundle(nextState, currentState, prevState)
-	phase0Obj.PreProcessBundle()
+	err := phase0Obj.PreProcessBundle()
 
-	return phase0Obj
+	return phase0Obj, err
 
 }
 
@@ -30,6 +36,7 @@ func (p *Phase0Metrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.NextState = nextState
 	p.baseMetrics.CurrentState = currentState
 	p.baseMetrics.PrevState = prevState
+	p.baseMetrics.Spec = nextState.Spec
 	p.baseMetrics.MaxBlockRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.MaxSlashingRewards = make(map[phase0.ValidatorIndex]phase0.Gwei)
 	p.baseMetrics.InclusionDelays = make([]int, len(p.baseMetrics.NextState.Validators))
@@ -37,20 +44,41 @@ func (p *Phase0Metrics) InitBundle(nextState *spec.AgnosticState,
 	p.baseMetrics.CurrentNumAttestingVals = make([]bool, len(currentState.Validators))
 }
 
-func (p *Phase0Metrics) PreProcessBundle() {
+func (p *Phase0Metrics) PreProcessBundle() error {
 
 	if !p.baseMetrics.PrevState.EmptyStateRoot() && !p.baseMetrics.CurrentState.EmptyStateRoot() {
-		p.GetInclusionDelayDeltas()
+		err := p.GetInclusionDelayDeltas()
+		if err != nil {
+			return err
+		}
+		p.ProcessSlashings()
 		p.GetMaxAttComponentDeltas()
 	}
+	p.ProcessPenalties()
+	return nil
+}
+
+// The whistleblower, always the proposer of the block, gets the whole reward
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#slash_validator
+func (p *Phase0Metrics) ProcessSlashings() {
+	for _, block := range p.baseMetrics.NextState.Blocks {
+		whistleBlowerReward := phase0.Gwei(0)
+		for _, idx := range block.SlashedValidators() {
+			slashedEffBalance := p.baseMetrics.NextState.Validators[idx].EffectiveBalance
+			whistleBlowerReward += slashedEffBalance / phase0.Gwei(p.baseMetrics.Spec.WhistleblowerRewardQuotient(p.baseMetrics.NextState.Version))
+		}
+		p.baseMetrics.MaxSlashingRewards[block.ProposerIndex] += whistleBlowerReward
+		block.ManualReward += whistleBlowerReward
+	}
 }
 
 func (p Phase0Metrics) GetMetricsBase() StateMetricsBase {
 	return p.baseMetrics
 }
 
-// Processes attestations and fills several structs
-func (p *Phase0Metrics) GetInclusionDelayDeltas() {
+// Processes attestations and fills several structs, also the attestation duties of prevState's epoch
+func (p *Phase0Metrics) GetInclusionDelayDeltas() error {
+	p.initAttestationDuties()
 
 	prevAttestations := orderAttestationsBySlot(p.baseMetrics.CurrentState.PrevAttestations)
 
@@ -61,7 +89,7 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 		inclusionSlot := slot + attestation.InclusionDelay
 		inclusionBlock, err := p.baseMetrics.GetBlockFromSlot(inclusionSlot)
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("error processing attestations at slot %d: %s", slot, err)
 		}
 		proposerIndex := inclusionBlock.ProposerIndex
 
@@ -76,7 +104,10 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 			if p.baseMetrics.InclusionDelays[attestingValIdx] == 0 {
 				p.baseMetrics.InclusionDelays[attestingValIdx] = int(attestation.InclusionDelay)
 				inclusionBlock.NewVotesIncluded += 1
-				bestPossibleInclusionDelay := p.getMinInclusionDelayPossible(slot)
+				bestPossibleInclusionDelay, err := p.getMinInclusionDelayPossible(slot)
+				if err != nil {
+					return err
+				}
 
 				// add correct flags and balances
 				if p.IsCorrectSource() {
@@ -86,10 +117,12 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 					// configure attester participation
 					p.baseMetrics.CurrentNumAttestingVals[attestingValIdx] = true
 
-					// add proposer reward
+					// add proposer reward, slashed attesters do not reward it
 					proposerReward := p.GetProposerReward(attestingValIdx)
-					p.baseMetrics.MaxBlockRewards[proposerIndex] += proposerReward
-					inclusionBlock.ManualReward += proposerReward
+					if !p.baseMetrics.CurrentState.Validators[attestingValIdx].Slashed {
+						p.baseMetrics.MaxBlockRewards[proposerIndex] += proposerReward
+						inclusionBlock.ManualReward += proposerReward
+					}
 
 					// add attester rewards
 					maxAttesterReward := p.GetBaseReward(attestingValIdx) - proposerReward
@@ -106,15 +139,23 @@ func (p *Phase0Metrics) GetInclusionDelayDeltas() {
 					p.baseMetrics.CurrentState.PrevEpochCorrectFlags[spec.AttHeadFlagIndex][attestingValIdx] = true
 					p.baseMetrics.CurrentState.AttestingBalance[spec.AttHeadFlagIndex] += p.baseMetrics.CurrentState.Validators[attestingValIdx].EffectiveBalance
 				}
+
+				flags := p.baseMetrics.CurrentState.PrevEpochCorrectFlags
+				p.recordAttestationDuty(attestingValIdx, attestation.Data, inclusionBlock, [3]bool{
+					flags[spec.AttSourceFlagIndex][attestingValIdx],
+					flags[spec.AttTargetFlagIndex][attestingValIdx],
+					flags[spec.AttHeadFlagIndex][attestingValIdx],
+				})
 			}
 		}
 	}
 
 	for valIdx, inclusionDelay := range p.baseMetrics.InclusionDelays {
 		if inclusionDelay == 0 {
-			p.baseMetrics.InclusionDelays[valIdx] = spec.SlotsPerEpoch + 1
+			p.baseMetrics.InclusionDelays[valIdx] = int(p.baseMetrics.Spec.SlotsPerEpoch) + 1
 		}
 	}
+	return nil
 }
 
 func (p *Phase0Metrics) GetMaxAttComponentDeltas() {
@@ -135,10 +176,10 @@ func (p *Phase0Metrics) GetMaxAttComponentDeltas() {
 			previousAttestedBalance := p.baseMetrics.CurrentState.AttestingBalance[i]
 
 			// participationRate per flag ==> previousAttestBalance / TotalActiveBalance
-			singleReward := baseReward * (previousAttestedBalance / spec.EffectiveBalanceInc)
+			singleReward := baseReward * (previousAttestedBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement)
 
 			// for each flag, we add baseReward * participationRate
-			maxReward += singleReward / (p.baseMetrics.CurrentState.TotalActiveBalance / spec.EffectiveBalanceInc)
+			maxReward += singleReward / (p.baseMetrics.CurrentState.TotalActiveBalance / p.baseMetrics.Spec.EffectiveBalanceIncrement)
 		}
 		p.baseMetrics.MaxAttesterRewards[phase0.ValidatorIndex(valIdx)] += maxReward
 
@@ -152,10 +193,10 @@ func (p Phase0Metrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 
 	maxReward := phase0.Gwei(0)
 
-	proposerReward := p.baseMetrics.MaxBlockRewards[valIdx] // it is only the reward for the previous epoch participation
+	// the reward for the previous epoch participation and the whistleblower rewards of nextState
+	proposerReward := p.baseMetrics.MaxBlockRewards[valIdx] + p.baseMetrics.MaxSlashingRewards[valIdx]
 
 	maxReward += p.baseMetrics.MaxAttesterRewards[valIdx]
-	maxReward += p.baseMetrics.MaxSlashingRewards[valIdx]
 	maxReward += proposerReward
 
 	result := spec.ValidatorRewards{
@@ -177,12 +218,13 @@ func (p Phase0Metrics) GetMaxReward(valIdx phase0.ValidatorIndex) (spec.Validato
 		InSyncCommittee:      false,
 		InclusionDelay:       p.baseMetrics.InclusionDelays[valIdx],
 	}
+	p.addRewardsBreakdown(&result)
 	return result, nil
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#helper-functions-1
 func (p Phase0Metrics) IsCorrectSource() bool {
-	epoch := phase0.Epoch(p.baseMetrics.CurrentState.Slot / spec.SlotsPerEpoch)
+	epoch := p.baseMetrics.Spec.EpochAtSlot(p.baseMetrics.CurrentState.Slot)
 	if epoch == p.baseMetrics.CurrentState.Epoch || epoch == p.baseMetrics.PrevState.Epoch {
 		return true
 	}
@@ -193,9 +235,8 @@ func (p Phase0Metrics) IsCorrectSource() bool {
 func (p Phase0Metrics) IsCorrectTarget(attestation phase0.PendingAttestation) bool {
 	target := attestation.Data.Target.Root
 
-	slot := p.baseMetrics.PrevState.Slot / spec.SlotsPerEpoch
-	slot = slot * spec.SlotsPerEpoch
-	expected := p.baseMetrics.PrevState.BlockRoots[slot%spec.SlotsPerHistoricalRoot]
+	slot := p.baseMetrics.Spec.FirstSlotInEpoch(p.baseMetrics.PrevState.Slot)
+	expected := p.baseMetrics.PrevState.GetBlockRootAtSlot(slot)
 
 	res := bytes.Compare(target[:], expected[:])
 
@@ -206,44 +247,39 @@ func (p Phase0Metrics) IsCorrectTarget(attestation phase0.PendingAttestation) bo
 func (p Phase0Metrics) IsCorrectHead(attestation phase0.PendingAttestation) bool {
 	head := attestation.Data.BeaconBlockRoot
 
-	index := attestation.Data.Slot % spec.SlotsPerHistoricalRoot
-	expected := p.baseMetrics.CurrentState.BlockRoots[index]
+	expected := p.baseMetrics.CurrentState.GetBlockRootAtSlot(attestation.Data.Slot)
 
 	res := bytes.Compare(head[:], expected[:])
 	return res == 0 // if 0, then block roots are the same
 }
 
-// BaseReward = ( effectiveBalance * (BaseRewardFactor)/(BaseRewardsPerEpoch * sqrt(activeBalance)) )
+// BaseReward = effectiveBalance * BaseRewardFactor / integer_sqrt(activeBalance) / BaseRewardsPerEpoch
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#helpers
 func (p Phase0Metrics) GetBaseReward(valIdx phase0.ValidatorIndex) phase0.Gwei {
 
-	var baseReward phase0.Gwei
 	valEffectiveBalance := p.baseMetrics.CurrentState.Validators[valIdx].EffectiveBalance
 
-	sqrt := math.Sqrt(float64(p.baseMetrics.CurrentState.TotalActiveBalance))
-	denom := spec.BaseRewardPerEpoch * sqrt
-	num := (valEffectiveBalance * spec.BaseRewardFactor)
+	sqrt := integerSquareroot(uint64(p.baseMetrics.CurrentState.TotalActiveBalance))
+	num := valEffectiveBalance * phase0.Gwei(p.baseMetrics.Spec.BaseRewardFactor)
 
-	baseReward = phase0.Gwei(num) / phase0.Gwei(denom)
-
-	return baseReward
+	return num / phase0.Gwei(sqrt) / phase0.Gwei(p.baseMetrics.Spec.BaseRewardsPerEpoch)
 }
 
-func (p Phase0Metrics) getMinInclusionDelayPossible(slot phase0.Slot) int {
+func (p Phase0Metrics) getMinInclusionDelayPossible(slot phase0.Slot) (int, error) {
 
 	result := 1
-	for i := slot + 1; i <= (slot + phase0.Slot(spec.SlotsPerEpoch)); i++ {
+	for i := slot + 1; i <= (slot + phase0.Slot(p.baseMetrics.Spec.SlotsPerEpoch)); i++ {
 		block, err := p.baseMetrics.GetBlockFromSlot(i)
 		if err != nil {
-			log.Fatalf("could not find best inclusion delay: %s", err)
+			return 0, fmt.Errorf("could not find best inclusion delay: %s", err)
 		}
 
 		if block.Proposed { // if there was a block proposed inside the inclusion window
-			return result
+			return result, nil
 		}
 		result += 1
 	}
-	return result
+	return result, nil
 }
 
 func orderAttestationsBySlot(attestations []*phase0.PendingAttestation) []*phase0.PendingAttestation {
@@ -270,5 +306,5 @@ func orderAttestationsBySlot(attestations []*phase0.PendingAttestation) []*phase
 }
 
 func (p Phase0Metrics) GetProposerReward(attesterValIdx phase0.ValidatorIndex) phase0.Gwei {
-	return phase0.Gwei(p.GetBaseReward(attesterValIdx) / spec.ProposerRewardQuotient)
+	return phase0.Gwei(p.GetBaseReward(attesterValIdx) / phase0.Gwei(p.baseMetrics.Spec.ProposerRewardQuotient))
 }
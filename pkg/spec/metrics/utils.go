@@ -8,46 +8,61 @@ import (
 	"github.com/migalabs/goteth/pkg/spec"
 )
 
-func (p AltairMetrics) GetValidatorFromCommitteeIndex(slot phase0.Slot, committeeIndex phase0.CommitteeIndex, idx int) (phase0.ValidatorIndex, error) {
-	if slot >= phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+func (p AltairMetrics) GetEpochDutiesFromSlot(slot phase0.Slot) (spec.EpochDuties, error) {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.PrevState.Epoch) {
 		// slot in PrevEpoch
-		valList := p.baseMetrics.PrevState.EpochStructs.GetValList(slot, committeeIndex)
-		return valList[idx], nil
+		return p.baseMetrics.PrevState.EpochStructs, nil
 	}
 
-	if slot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 		// slot in CurrentEpoch
-		valList := p.baseMetrics.CurrentState.EpochStructs.GetValList(slot, committeeIndex)
-		return valList[idx], nil
+		return p.baseMetrics.CurrentState.EpochStructs, nil
 	}
 
-	if slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.NextState.Epoch+1)*spec.SlotsPerEpoch {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.NextState.Epoch) {
 		// slot in NextEpoch
-		valList := p.baseMetrics.NextState.EpochStructs.GetValList(slot, committeeIndex)
-		return valList[idx], nil
+		return p.baseMetrics.NextState.EpochStructs, nil
 	}
 
-	return 0, fmt.Errorf("could not get validator from any epoch: slot %d, committee %d, index %d", slot, committeeIndex, idx)
+	return spec.EpochDuties{}, fmt.Errorf("could not get duties from any epoch: slot %d", slot)
+}
+
+// GetAttestingIndices returns the validators that voted in the attestation, in the order of its aggregation bits.
+// From Electra, the aggregate spans every committee set in its committee bits
+func (p AltairMetrics) GetAttestingIndices(attestation spec.AgnosticAttestation) ([]phase0.ValidatorIndex, error) {
+	slot := attestation.Data.Slot
+	duties, err := p.GetEpochDutiesFromSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	if attestation.CommitteeBits != nil {
+		return duties.GetAttestingIndicesElectra(slot, attestation.CommitteeBits, attestation.AggregationBits)
+	}
+
+	committee := duties.GetValList(slot, attestation.Data.Index)
+	if uint64(len(committee)) != attestation.AggregationBits.Len() {
+		return nil, fmt.Errorf("aggregation bits of length %d do not match committee %d of %d members at slot %d",
+			attestation.AggregationBits.Len(), attestation.Data.Index, len(committee), slot)
+	}
+	attestingIndices := make([]phase0.ValidatorIndex, 0)
+	for _, idx := range attestation.AggregationBits.BitIndices() {
+		attestingIndices = append(attestingIndices, committee[idx])
+	}
+	return attestingIndices, nil
 }
 
 func (p AltairMetrics) GetJustifiedRootfromSlot(slot phase0.Slot) (phase0.Root, error) {
-	if slot >= phase0.Slot(p.baseMetrics.PrevState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.PrevState.Epoch) {
 		// slot in PrevEpoch
 		return p.baseMetrics.PrevState.CurrentJustifiedCheckpoint.Root, nil
 	}
 
-	if slot >= phase0.Slot(p.baseMetrics.CurrentState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.CurrentState.Epoch) {
 		// slot in CurrentEpochEpoch
 		return p.baseMetrics.CurrentState.CurrentJustifiedCheckpoint.Root, nil
 	}
 
-	if slot >= phase0.Slot(p.baseMetrics.NextState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(p.baseMetrics.NextState.Epoch+1)*spec.SlotsPerEpoch {
+	if slotInEpoch(p.baseMetrics.Spec, slot, p.baseMetrics.NextState.Epoch) {
 		// slot in NextEpoch
 		return p.baseMetrics.NextState.CurrentJustifiedCheckpoint.Root, nil
 	}
@@ -57,22 +72,20 @@ func (p AltairMetrics) GetJustifiedRootfromSlot(slot phase0.Slot) (phase0.Root,
 }
 
 func (s StateMetricsBase) GetBlockFromSlot(slot phase0.Slot) (*spec.AgnosticBlock, error) {
-	if slot >= phase0.Slot(s.PrevState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch {
+	slotIdx := uint64(slot) % s.Spec.SlotsPerEpoch
+	if slotInEpoch(s.Spec, slot, s.PrevState.Epoch) {
 		// slot in PrevEpoch
-		return s.PrevState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.PrevState.Blocks[slotIdx], nil
 	}
 
-	if slot >= phase0.Slot(s.CurrentState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch {
+	if slotInEpoch(s.Spec, slot, s.CurrentState.Epoch) {
 		// slot in CurrentEpochEpoch
-		return s.CurrentState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.CurrentState.Blocks[slotIdx], nil
 	}
 
-	if slot >= phase0.Slot(s.NextState.Epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(s.NextState.Epoch+1)*spec.SlotsPerEpoch {
+	if slotInEpoch(s.Spec, slot, s.NextState.Epoch) {
 		// slot in NextEpoch
-		return s.NextState.Blocks[slot%spec.SlotsPerEpoch], nil
+		return s.NextState.Blocks[slotIdx], nil
 	}
 
 	return &spec.AgnosticBlock{}, errors.New("could not get block from any epoch")
@@ -81,7 +94,7 @@ func (s StateMetricsBase) GetBlockFromSlot(slot phase0.Slot) (*spec.AgnosticBloc
 // Returns the closest proposed block backwards from the given slot
 func (s StateMetricsBase) GetBestInclusionDelay(slot phase0.Slot) (int, error) {
 
-	minSlot := phase0.Slot(s.PrevState.Epoch * spec.SlotsPerEpoch)
+	minSlot := s.Spec.EpochStartSlot(s.PrevState.Epoch)
 
 	for i := slot; i > minSlot; i-- {
 		block, err := s.GetBlockFromSlot(i)
@@ -108,10 +121,17 @@ func countTrue(arr []bool) int {
 	return result
 }
 
-func slotInEpoch(slot phase0.Slot, epoch phase0.Epoch) bool {
-	if slot >= phase0.Slot(epoch)*spec.SlotsPerEpoch &&
-		slot < phase0.Slot(epoch+1)*spec.SlotsPerEpoch {
-		return true
+func slotInEpoch(chainSpec *spec.ChainSpec, slot phase0.Slot, epoch phase0.Epoch) bool {
+	return chainSpec.EpochAtSlot(slot) == epoch
+}
+
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#integer_squareroot
+func integerSquareroot(n uint64) uint64 {
+	x := n
+	y := (x + 1) / 2
+	for y < x {
+		x = y
+		y = (x + n/x) / 2
 	}
-	return false
+	return x
 }
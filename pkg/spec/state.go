@@ -7,7 +7,9 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
 )
 
 // This Wrapper is meant to include all common objects across Ethereum Hard Fork Specs
@@ -15,45 +17,54 @@ type AgnosticState struct {
 	Version                    spec.DataVersion
 	GenesisTimestamp           uint64 // genesis timestamp
 	StateRoot                  phase0.Root
-	Epoch                      phase0.Epoch                 // Epoch of the state
-	Slot                       phase0.Slot                  // Slot of the state
-	Balances                   []phase0.Gwei                // balance of each validator
-	Validators                 []*phase0.Validator          // list of validators
-	TotalActiveBalance         phase0.Gwei                  // effective balance
-	TotalActiveRealBalance     phase0.Gwei                  // real balance
-	AttestingBalance           []phase0.Gwei                // one attesting balance per flag (of the previous epoch attestations)
-	EpochStructs               EpochDuties                  // structs about beacon committees, proposers and attestation
-	PrevEpochCorrectFlags      [][]bool                     // one aray per flag
-	PrevAttestations           []*phase0.PendingAttestation // array of attestations (currently only for Phase0)
-	NumActiveVals              uint                         // number of active validators in the epoch
-	NumExitedVals              uint                         // number of exited validators in the epoch
-	NumSlashedVals             uint                         // number of slashed validators in the epoch
-	NumQueuedVals              uint                         // number of validators in the queue
-	BlockRoots                 []phase0.Root                // array of block roots at this point (8192)
-	MissedBlocks               []phase0.Slot                // blocks missed in the epoch until this point
-	SyncCommittee              altair.SyncCommittee         // list of pubkeys in the current sync committe
-	Blocks                     []*AgnosticBlock             // list of blocks in the epoch
-	Withdrawals                []phase0.Gwei                // one position per validator
-	Deposits                   []phase0.Gwei                // one per validator index
-	CurrentJustifiedCheckpoint phase0.Checkpoint            // the latest justified checkpoint
+	Epoch                      phase0.Epoch                    // Epoch of the state
+	Slot                       phase0.Slot                     // Slot of the state
+	Balances                   []phase0.Gwei                   // balance of each validator
+	Validators                 []*phase0.Validator             // list of validators
+	TotalActiveBalance         phase0.Gwei                     // effective balance
+	TotalActiveRealBalance     phase0.Gwei                     // real balance
+	AttestingBalance           []phase0.Gwei                   // one attesting balance per flag (of the previous epoch attestations)
+	EpochStructs               EpochDuties                     // structs about beacon committees, proposers and attestation
+	PrevEpochCorrectFlags      [][]bool                        // one aray per flag
+	PrevAttestations           []*phase0.PendingAttestation    // array of attestations (currently only for Phase0)
+	NumActiveVals              uint                            // number of active validators in the epoch
+	NumExitedVals              uint                            // number of exited validators in the epoch
+	NumSlashedVals             uint                            // number of slashed validators in the epoch
+	NumQueuedVals              uint                            // number of validators in the queue
+	BlockRoots                 []phase0.Root                   // array of block roots at this point (8192)
+	MissedBlocks               []phase0.Slot                   // blocks missed in the epoch until this point
+	SyncCommittee              altair.SyncCommittee            // list of pubkeys in the current sync committe
+	Blocks                     []*AgnosticBlock                // list of blocks in the epoch
+	Withdrawals                []phase0.Gwei                   // one position per validator
+	Deposits                   []phase0.Gwei                   // one per validator index
+	JustificationBits          bitfield.Bitvector4             // justification of the last 4 epochs, bit 0 is the current one
+	CurrentJustifiedCheckpoint phase0.Checkpoint               // the latest justified checkpoint
+	FinalizedCheckpoint        phase0.Checkpoint               // the latest finalized checkpoint
+	InactivityScores           []uint64                        // one per validator, from Altair
+	Slashings                  []phase0.Gwei                   // slashed balance per epoch, circular over EPOCHS_PER_SLASHINGS_VECTOR
+	PendingDeposits            []*electra.PendingDeposit       // deposits waiting for the churn, from Electra
+	PendingConsolidations      []*electra.PendingConsolidation // consolidations waiting for the source to be withdrawable, from Electra
+	Spec                       *ChainSpec                      // parameters of the chain the state belongs to
 }
 
-func GetCustomState(bstate spec.VersionedBeaconState, duties EpochDuties) (AgnosticState, error) {
+func GetCustomState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) (AgnosticState, error) {
 	switch bstate.Version {
 
 	case spec.DataVersionPhase0:
-		return NewPhase0State(bstate, duties), nil
+		return NewPhase0State(bstate, duties, chainSpec), nil
 
 	case spec.DataVersionAltair:
-		return NewAltairState(bstate, duties), nil
+		return NewAltairState(bstate, duties, chainSpec), nil
 
 	case spec.DataVersionBellatrix:
-		return NewBellatrixState(bstate, duties), nil
+		return NewBellatrixState(bstate, duties, chainSpec), nil
 
 	case spec.DataVersionCapella:
-		return NewCapellaState(bstate, duties), nil
+		return NewCapellaState(bstate, duties, chainSpec), nil
 	case spec.DataVersionDeneb:
-		return NewDenebState(bstate, duties), nil
+		return NewDenebState(bstate, duties, chainSpec), nil
+	case spec.DataVersionElectra:
+		return NewElectraState(bstate, duties, chainSpec), nil
 	default:
 		return AgnosticState{}, fmt.Errorf("could not figure out the Beacon State Fork Version: %s", bstate.Version)
 	}
@@ -101,6 +112,9 @@ func (p *AgnosticState) CalculateWithdrawals() {
 func (p *AgnosticState) CalculateDeposits() {
 
 	p.Deposits = make([]phase0.Gwei, len(p.Validators))
+	if p.Version >= spec.DataVersionElectra {
+		return // deposits are queued, see CalculateElectraTransfers
+	}
 	for _, block := range p.Blocks {
 		for _, deposit := range block.Deposits {
 
@@ -114,6 +128,105 @@ func (p *AgnosticState) CalculateDeposits() {
 	}
 }
 
+// pendingDepositKey identifies a pending deposit, the same deposit can be queued more than once
+type pendingDepositKey struct {
+	pubkey      phase0.BLSPubKey
+	credentials [32]byte
+	amount      phase0.Gwei
+	signature   phase0.BLSSignature
+	slot        phase0.Slot
+}
+
+func newPendingDepositKey(deposit *electra.PendingDeposit) pendingDepositKey {
+	key := pendingDepositKey{
+		pubkey:    deposit.Pubkey,
+		amount:    deposit.Amount,
+		signature: deposit.Signature,
+		slot:      deposit.Slot,
+	}
+	copy(key.credentials[:], deposit.WithdrawalCredentials)
+	return key
+}
+
+// isExcessBalanceDeposit returns whether the deposit was queued by the chain itself, with the
+// G2 point at infinity as signature: the excess balance of a switch to compounding or of the fork upgrade
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md#new-queue_excess_active_balance
+func isExcessBalanceDeposit(deposit *electra.PendingDeposit) bool {
+	return deposit.Signature == phase0.BLSSignature{0xc0}
+}
+
+// CalculateElectraTransfers fills the balance movements of the transition from prevState that the blocks
+// do not show. From Electra, deposits and consolidations are queued in the state and applied during
+// the epoch processing, so both queues are diffed:
+//   - the deposits that left the queue were applied and are counted as deposits
+//   - the excess balance queued by the chain left the validator and is counted as a withdrawal
+//   - the processed consolidations moved the source balance to the target, capped at its effective balance
+//
+// https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md#epoch-processing
+func (p *AgnosticState) CalculateElectraTransfers(prevState *AgnosticState) {
+	p.CalculateWithdrawals()
+	p.CalculateDeposits()
+
+	valIndices := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(p.Validators))
+	for valIdx, validator := range p.Validators {
+		valIndices[validator.PublicKey] = phase0.ValidatorIndex(valIdx)
+	}
+
+	queued := make(map[pendingDepositKey]int)
+	for _, deposit := range p.PendingDeposits {
+		queued[newPendingDepositKey(deposit)] += 1
+	}
+	applied := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	for _, deposit := range prevState.PendingDeposits {
+		key := newPendingDepositKey(deposit)
+		if queued[key] > 0 {
+			queued[key] -= 1 // still waiting
+			continue
+		}
+		valIdx, ok := valIndices[deposit.Pubkey]
+		if !ok {
+			continue // invalid signature, no validator was created
+		}
+		p.Deposits[valIdx] += deposit.Amount
+		applied[valIdx] += deposit.Amount
+	}
+	// what is left in queued are the deposits added during the transition
+	for _, deposit := range p.PendingDeposits {
+		key := newPendingDepositKey(deposit)
+		if queued[key] == 0 || !isExcessBalanceDeposit(deposit) {
+			continue
+		}
+		queued[key] -= 1
+		if valIdx, ok := valIndices[deposit.Pubkey]; ok {
+			p.Withdrawals[valIdx] += deposit.Amount
+		}
+	}
+
+	// the queue is processed in order, the pending ones stay at its head
+	processed := len(prevState.PendingConsolidations)
+	if len(p.PendingConsolidations) > 0 {
+		for i, consolidation := range prevState.PendingConsolidations {
+			if *consolidation == *p.PendingConsolidations[0] {
+				processed = i
+				break
+			}
+		}
+	}
+	for _, consolidation := range prevState.PendingConsolidations[:processed] {
+		source := consolidation.SourceIndex
+		if int(source) >= len(prevState.Validators) || int(consolidation.TargetIndex) >= len(p.Validators) ||
+			prevState.Validators[source].Slashed {
+			continue // slashed sources are dropped from the queue
+		}
+		amount := prevState.Balances[source] + applied[source]
+		if amount > prevState.Validators[source].EffectiveBalance {
+			amount = prevState.Validators[source].EffectiveBalance
+		}
+		p.Withdrawals[source] += amount
+		p.Deposits[consolidation.TargetIndex] += amount
+	}
+}
+
 // the length of the valList = number of validators
 // each position represents a valIdx
 // if the item has a number > 0, count it
@@ -174,8 +287,9 @@ func IsActive(validator phase0.Validator, epoch phase0.Epoch) bool {
 // We use blockroots to track missed blocks. When there is a missed block, the block root is repeated
 func (p *AgnosticState) TrackMissingBlocks() {
 
-	firstIndex := phase0.Slot(p.Epoch*SlotsPerEpoch) % SlotsPerHistoricalRoot                // first slot of the epoch
-	lastIndex := phase0.Slot(p.Epoch*SlotsPerEpoch+SlotsPerEpoch-1) % SlotsPerHistoricalRoot // last slot of the epoch
+	historicalRoots := phase0.Slot(p.Spec.SlotsPerHistoricalRoot)
+	firstIndex := p.Spec.EpochStartSlot(p.Epoch) % historicalRoots // first slot of the epoch
+	lastIndex := p.Spec.EpochEndSlot(p.Epoch) % historicalRoots    // last slot of the epoch
 	p.MissedBlocks = make([]phase0.Slot, 0)
 
 	for i := firstIndex; i <= lastIndex; i++ {
@@ -188,7 +302,7 @@ func (p *AgnosticState) TrackMissingBlocks() {
 
 		if res == 0 {
 			// both consecutive roots were the same ==> missed block
-			slot := i - firstIndex + phase0.Slot(p.Epoch*SlotsPerEpoch) // delta + start of the epoch
+			slot := i - firstIndex + p.Spec.EpochStartSlot(p.Epoch) // delta + start of the epoch
 			p.MissedBlocks = append(p.MissedBlocks, slot)
 		}
 	}
@@ -263,7 +377,7 @@ func (p AgnosticState) GetValStatus(valIdx phase0.ValidatorIndex) ValidatorStatu
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root
 func (p AgnosticState) GetBlockRoot(epoch phase0.Epoch) phase0.Root {
 
-	firstSlotInEpoch := phase0.Slot(epoch * SlotsPerEpoch)
+	firstSlotInEpoch := p.Spec.EpochStartSlot(epoch)
 
 	return p.GetBlockRootAtSlot(firstSlotInEpoch)
 }
@@ -271,7 +385,7 @@ func (p AgnosticState) GetBlockRoot(epoch phase0.Epoch) phase0.Root {
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root_at_slot
 func (p AgnosticState) GetBlockRootAtSlot(slot phase0.Slot) phase0.Root {
 
-	return p.BlockRoots[slot%SlotsPerHistoricalRoot]
+	return p.BlockRoots[slot%phase0.Slot(p.Spec.SlotsPerHistoricalRoot)]
 }
 
 // https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md#get_block_root_at_slot
@@ -281,7 +395,7 @@ func (p AgnosticState) EmptyStateRoot() bool {
 }
 
 // This Wrapper is meant to include all necessary data from the Phase0 Fork
-func NewPhase0State(bstate spec.VersionedBeaconState, duties EpochDuties) AgnosticState {
+func NewPhase0State(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
 
 	balances := make([]phase0.Gwei, 0)
 
@@ -295,12 +409,16 @@ func NewPhase0State(bstate spec.VersionedBeaconState, duties EpochDuties) Agnost
 		Balances:                   balances,
 		Validators:                 bstate.Phase0.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Phase0.Slot / SlotsPerEpoch),
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Phase0.Slot),
 		Slot:                       phase0.Slot(bstate.Phase0.Slot),
 		BlockRoots:                 bstate.Phase0.BlockRoots,
 		PrevAttestations:           bstate.Phase0.PreviousEpochAttestations,
 		GenesisTimestamp:           bstate.Phase0.GenesisTime,
+		JustificationBits:          bstate.Phase0.JustificationBits,
 		CurrentJustifiedCheckpoint: *bstate.Phase0.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Phase0.FinalizedCheckpoint,
+		Slashings:                  bstate.Phase0.Slashings,
 	}
 
 	phase0Obj.Setup()
@@ -310,19 +428,24 @@ func NewPhase0State(bstate spec.VersionedBeaconState, duties EpochDuties) Agnost
 }
 
 // This Wrapper is meant to include all necessary data from the Altair Fork
-func NewAltairState(bstate spec.VersionedBeaconState, duties EpochDuties) AgnosticState {
+func NewAltairState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
 
 	altairObj := AgnosticState{
 		Version:                    bstate.Version,
 		Balances:                   bstate.Altair.Balances,
 		Validators:                 bstate.Altair.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Altair.Slot / SlotsPerEpoch),
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Altair.Slot),
 		Slot:                       bstate.Altair.Slot,
 		BlockRoots:                 bstate.Altair.BlockRoots,
 		SyncCommittee:              *bstate.Altair.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Altair.GenesisTime,
+		JustificationBits:          bstate.Altair.JustificationBits,
 		CurrentJustifiedCheckpoint: *bstate.Altair.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Altair.FinalizedCheckpoint,
+		InactivityScores:           bstate.Altair.InactivityScores,
+		Slashings:                  bstate.Altair.Slashings,
 	}
 
 	altairObj.Setup()
@@ -360,19 +483,24 @@ func ProcessAltairAttestations(customState *AgnosticState, prevEpochParticipatio
 }
 
 // This Wrapper is meant to include all necessary data from the Bellatrix Fork
-func NewBellatrixState(bstate spec.VersionedBeaconState, duties EpochDuties) AgnosticState {
+func NewBellatrixState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
 
 	bellatrixObj := AgnosticState{
 		Version:                    bstate.Version,
 		Balances:                   bstate.Bellatrix.Balances,
 		Validators:                 bstate.Bellatrix.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Bellatrix.Slot / SlotsPerEpoch),
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Bellatrix.Slot),
 		Slot:                       bstate.Bellatrix.Slot,
 		BlockRoots:                 bstate.Bellatrix.BlockRoots,
 		SyncCommittee:              *bstate.Bellatrix.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Bellatrix.GenesisTime,
+		JustificationBits:          bstate.Bellatrix.JustificationBits,
 		CurrentJustifiedCheckpoint: *bstate.Bellatrix.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Bellatrix.FinalizedCheckpoint,
+		InactivityScores:           bstate.Bellatrix.InactivityScores,
+		Slashings:                  bstate.Bellatrix.Slashings,
 	}
 
 	bellatrixObj.Setup()
@@ -383,19 +511,24 @@ func NewBellatrixState(bstate spec.VersionedBeaconState, duties EpochDuties) Agn
 }
 
 // This Wrapper is meant to include all necessary data from the Capella Fork
-func NewCapellaState(bstate spec.VersionedBeaconState, duties EpochDuties) AgnosticState {
+func NewCapellaState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
 
 	capellaObj := AgnosticState{
 		Version:                    bstate.Version,
 		Balances:                   bstate.Capella.Balances,
 		Validators:                 bstate.Capella.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Capella.Slot / SlotsPerEpoch),
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Capella.Slot),
 		Slot:                       bstate.Capella.Slot,
 		BlockRoots:                 bstate.Capella.BlockRoots,
 		SyncCommittee:              *bstate.Capella.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Capella.GenesisTime,
+		JustificationBits:          bstate.Capella.JustificationBits,
 		CurrentJustifiedCheckpoint: *bstate.Capella.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Capella.FinalizedCheckpoint,
+		InactivityScores:           bstate.Capella.InactivityScores,
+		Slashings:                  bstate.Capella.Slashings,
 	}
 
 	capellaObj.Setup()
@@ -406,19 +539,24 @@ func NewCapellaState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnos
 }
 
 // This Wrapper is meant to include all necessary data from the Capella Fork
-func NewDenebState(bstate spec.VersionedBeaconState, duties EpochDuties) AgnosticState {
+func NewDenebState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
 
 	denebObj := AgnosticState{
 		Version:                    bstate.Version,
 		Balances:                   bstate.Deneb.Balances,
 		Validators:                 bstate.Deneb.Validators,
 		EpochStructs:               duties,
-		Epoch:                      phase0.Epoch(bstate.Deneb.Slot / SlotsPerEpoch),
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Deneb.Slot),
 		Slot:                       bstate.Deneb.Slot,
 		BlockRoots:                 bstate.Deneb.BlockRoots,
 		SyncCommittee:              *bstate.Deneb.CurrentSyncCommittee,
 		GenesisTimestamp:           bstate.Deneb.GenesisTime,
+		JustificationBits:          bstate.Deneb.JustificationBits,
 		CurrentJustifiedCheckpoint: *bstate.Deneb.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Deneb.FinalizedCheckpoint,
+		InactivityScores:           bstate.Deneb.InactivityScores,
+		Slashings:                  bstate.Deneb.Slashings,
 	}
 
 	denebObj.Setup()
@@ -427,3 +565,33 @@ func NewDenebState(bstate spec.VersionedBeaconState, duties EpochDuties) Agnosti
 
 	return denebObj
 }
+
+// This Wrapper is meant to include all necessary data from the Electra Fork
+func NewElectraState(bstate spec.VersionedBeaconState, duties EpochDuties, chainSpec *ChainSpec) AgnosticState {
+
+	electraObj := AgnosticState{
+		Version:                    bstate.Version,
+		Balances:                   bstate.Electra.Balances,
+		Validators:                 bstate.Electra.Validators,
+		EpochStructs:               duties,
+		Spec:                       chainSpec,
+		Epoch:                      chainSpec.EpochAtSlot(bstate.Electra.Slot),
+		Slot:                       bstate.Electra.Slot,
+		BlockRoots:                 bstate.Electra.BlockRoots,
+		SyncCommittee:              *bstate.Electra.CurrentSyncCommittee,
+		GenesisTimestamp:           bstate.Electra.GenesisTime,
+		JustificationBits:          bstate.Electra.JustificationBits,
+		CurrentJustifiedCheckpoint: *bstate.Electra.CurrentJustifiedCheckpoint,
+		FinalizedCheckpoint:        *bstate.Electra.FinalizedCheckpoint,
+		InactivityScores:           bstate.Electra.InactivityScores,
+		Slashings:                  bstate.Electra.Slashings,
+		PendingDeposits:            bstate.Electra.PendingDeposits,
+		PendingConsolidations:      bstate.Electra.PendingConsolidations,
+	}
+
+	electraObj.Setup()
+
+	ProcessAltairAttestations(&electraObj, bstate.Electra.PreviousEpochParticipation)
+
+	return electraObj
+}
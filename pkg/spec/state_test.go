@@ -0,0 +1,64 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateElectraTransfers(t *testing.T) {
+	eth := phase0.Gwei(EffectiveBalanceInc)
+	validators := make([]*phase0.Validator, 4)
+	for i := range validators {
+		validators[i] = &phase0.Validator{PublicKey: phase0.BLSPubKey{byte(i + 1)}, EffectiveBalance: 32 * eth}
+	}
+	deposit := func(pubkey byte, amount phase0.Gwei, signature phase0.BLSSignature) *electra.PendingDeposit {
+		return &electra.PendingDeposit{
+			Pubkey:                phase0.BLSPubKey{pubkey},
+			WithdrawalCredentials: make([]byte, 32),
+			Amount:                amount,
+			Signature:             signature,
+		}
+	}
+
+	prevState := &AgnosticState{
+		Version:    spec.DataVersionElectra,
+		Validators: validators,
+		Balances:   []phase0.Gwei{31 * eth, 32 * eth, 32 * eth, 35 * eth},
+		PendingDeposits: []*electra.PendingDeposit{
+			deposit(1, 5*eth, phase0.BLSSignature{0x01}), // applied
+			deposit(2, 1*eth, phase0.BLSSignature{0x02}), // postponed
+		},
+		PendingConsolidations: []*electra.PendingConsolidation{
+			{SourceIndex: 0, TargetIndex: 1}, // processed
+			{SourceIndex: 2, TargetIndex: 1},
+		},
+	}
+	nextState := &AgnosticState{
+		Version:    spec.DataVersionElectra,
+		Validators: validators,
+		PendingDeposits: []*electra.PendingDeposit{
+			deposit(2, 1*eth, phase0.BLSSignature{0x02}),
+			deposit(3, 2*eth, phase0.BLSSignature{0x03}), // new request, credited once applied
+			deposit(4, 3*eth, phase0.BLSSignature{0xc0}), // excess balance of a switch to compounding
+		},
+		PendingConsolidations: []*electra.PendingConsolidation{
+			{SourceIndex: 2, TargetIndex: 1},
+		},
+	}
+	nextState.CalculateElectraTransfers(prevState)
+
+	// the source moves its effective balance, capped below its balance plus the applied deposit
+	assert.Equal(t, []phase0.Gwei{5 * eth, 32 * eth, 0, 0}, nextState.Deposits)
+	assert.Equal(t, []phase0.Gwei{32 * eth, 0, 0, 3 * eth}, nextState.Withdrawals)
+
+	// a slashed source leaves the queue without moving its balance
+	validators[2].Slashed = true
+	nextState.PendingConsolidations = nil
+	prevState.PendingConsolidations = prevState.PendingConsolidations[1:]
+	nextState.CalculateElectraTransfers(prevState)
+	assert.Equal(t, phase0.Gwei(0), nextState.Deposits[1])
+}
@@ -0,0 +1,19 @@
+package spec
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// SyncCommitteeParticipation is the duty of a sync committee member at a slot
+type SyncCommitteeParticipation struct {
+	Slot           phase0.Slot
+	ValidatorIndex phase0.ValidatorIndex
+	Signed         bool        // the bits of every seat of the validator were set
+	MissedBlock    bool        // no block was proposed, so there was nothing to sign
+	Reward         phase0.Gwei // one participant reward per seat signed
+	Penalty        phase0.Gwei // one participant reward per seat not signed
+}
+
+func (f SyncCommitteeParticipation) Type() ModelType {
+	return SyncCommitteeParticipationModel
+}
@@ -1,7 +1,6 @@
 package spec
 
 import (
-	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,7 +24,3 @@ type BlockRewardsContent struct {
 	ProposerSlashings uint64 `json:"proposer_slashings,string"`
 	AttesterSlashings uint64 `json:"attester_slashings,string"`
 }
-
-func FirstSlotInEpoch(slot phase0.Slot) phase0.Slot {
-	return slot / SlotsPerEpoch * SlotsPerEpoch
-}
@@ -0,0 +1,129 @@
+package spec
+
+import (
+	"bytes"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+type ValidatorEventType string
+
+const (
+	DepositEvent               ValidatorEventType = "deposit"                // new validator or top up
+	EligibleEvent              ValidatorEventType = "eligible"               // eligible for activation, effective epoch is the eligibility one
+	ActivationEvent            ValidatorEventType = "activation"             // the validator became active
+	ExitInitiatedEvent         ValidatorEventType = "exit_initiated"         // effective epoch is the exit one
+	SlashedEvent               ValidatorEventType = "slashed"                // effective epoch is the withdrawable one
+	WithdrawableEvent          ValidatorEventType = "withdrawable"           // the validator became withdrawable
+	WithdrawalCredentialsEvent ValidatorEventType = "withdrawal_credentials" // the withdrawal credentials changed
+	FullyWithdrawnEvent        ValidatorEventType = "fully_withdrawn"        // the whole balance was withdrawn
+)
+
+// ValidatorEvent is a change of the validator registry between two consecutive states
+type ValidatorEvent struct {
+	ValidatorIndex phase0.ValidatorIndex
+	Epoch          phase0.Epoch // epoch of the state where the change was seen
+	Slot           phase0.Slot  // block that included the operation, or the slot of the state
+	Event          ValidatorEventType
+	EffectiveEpoch phase0.Epoch // epoch the change applies from
+}
+
+func (f ValidatorEvent) Type() ModelType {
+	return ValidatorEventModel
+}
+
+// GetValidatorEvents compares the registry of both states, one epoch apart, and returns the changes
+// in validator index order. Operations are given the slot of the block that included them
+func GetValidatorEvents(currentState *AgnosticState, nextState *AgnosticState) []ValidatorEvent {
+	farFuture := phase0.Epoch(farFutureEpoch)
+	events := make([]ValidatorEvent, 0)
+
+	depositSlots := make(map[phase0.BLSPubKey]phase0.Slot)
+	exitSlots := make(map[phase0.ValidatorIndex]phase0.Slot)
+	slashingSlots := make(map[phase0.ValidatorIndex]phase0.Slot)
+	withdrawalSlots := make(map[phase0.ValidatorIndex]phase0.Slot)
+	credentialSlots := make(map[phase0.ValidatorIndex]phase0.Slot)
+	for _, block := range nextState.Blocks {
+		for _, deposit := range block.Deposits {
+			depositSlots[deposit.Data.PublicKey] = block.Slot
+		}
+		for _, exit := range block.VoluntaryExits {
+			exitSlots[exit.Message.ValidatorIndex] = block.Slot
+		}
+		for _, valIdx := range block.SlashedValidators() {
+			slashingSlots[valIdx] = block.Slot
+		}
+		for _, withdrawal := range block.ExecutionPayload.Withdrawals {
+			withdrawalSlots[withdrawal.ValidatorIndex] = block.Slot
+		}
+		for _, change := range block.BLSToExecutionChanges {
+			credentialSlots[change.Message.ValidatorIndex] = block.Slot
+		}
+	}
+	// the first map holding the validator gives the slot
+	slotOf := func(valIdx phase0.ValidatorIndex, slotMaps ...map[phase0.ValidatorIndex]phase0.Slot) phase0.Slot {
+		for _, slots := range slotMaps {
+			if slot, ok := slots[valIdx]; ok {
+				return slot
+			}
+		}
+		return nextState.Slot
+	}
+	newEvent := func(valIdx phase0.ValidatorIndex, event ValidatorEventType, slot phase0.Slot, effectiveEpoch phase0.Epoch) {
+		events = append(events, ValidatorEvent{
+			ValidatorIndex: valIdx,
+			Epoch:          nextState.Epoch,
+			Slot:           slot,
+			Event:          event,
+			EffectiveEpoch: effectiveEpoch,
+		})
+	}
+
+	for i, validator := range nextState.Validators {
+		valIdx := phase0.ValidatorIndex(i)
+
+		prevValidator := &phase0.Validator{
+			ActivationEligibilityEpoch: farFuture,
+			ActivationEpoch:            farFuture,
+			ExitEpoch:                  farFuture,
+			WithdrawableEpoch:          farFuture,
+			WithdrawalCredentials:      validator.WithdrawalCredentials,
+		}
+		topUp := i < len(nextState.Deposits) && nextState.Deposits[i] > 0
+		if i < len(currentState.Validators) {
+			prevValidator = currentState.Validators[i]
+		}
+		if i >= len(currentState.Validators) || topUp {
+			slot, ok := depositSlots[validator.PublicKey]
+			if !ok {
+				slot = nextState.Slot
+			}
+			newEvent(valIdx, DepositEvent, slot, nextState.Epoch)
+		}
+
+		if prevValidator.ActivationEligibilityEpoch == farFuture && validator.ActivationEligibilityEpoch != farFuture {
+			newEvent(valIdx, EligibleEvent, nextState.Slot, validator.ActivationEligibilityEpoch)
+		}
+		if currentState.Epoch < validator.ActivationEpoch && validator.ActivationEpoch <= nextState.Epoch {
+			newEvent(valIdx, ActivationEvent, nextState.Slot, validator.ActivationEpoch)
+		}
+		if prevValidator.ExitEpoch == farFuture && validator.ExitEpoch != farFuture {
+			// slashings initiate the exit too
+			newEvent(valIdx, ExitInitiatedEvent, slotOf(valIdx, exitSlots, slashingSlots), validator.ExitEpoch)
+		}
+		if !prevValidator.Slashed && validator.Slashed {
+			newEvent(valIdx, SlashedEvent, slotOf(valIdx, slashingSlots), validator.WithdrawableEpoch)
+		}
+		if currentState.Epoch < validator.WithdrawableEpoch && validator.WithdrawableEpoch <= nextState.Epoch {
+			newEvent(valIdx, WithdrawableEvent, nextState.Slot, validator.WithdrawableEpoch)
+		}
+		if !bytes.Equal(prevValidator.WithdrawalCredentials, validator.WithdrawalCredentials) {
+			newEvent(valIdx, WithdrawalCredentialsEvent, slotOf(valIdx, credentialSlots), nextState.Epoch)
+		}
+		withdrawn := i < len(nextState.Withdrawals) && nextState.Withdrawals[i] > 0
+		if withdrawn && i < len(currentState.Balances) && currentState.Balances[i] > 0 && nextState.Balances[i] == 0 {
+			newEvent(valIdx, FullyWithdrawnEvent, slotOf(valIdx, withdrawalSlots), nextState.Epoch)
+		}
+	}
+	return events
+}
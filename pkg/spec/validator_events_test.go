@@ -0,0 +1,79 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetValidatorEvents(t *testing.T) {
+	farFuture := phase0.Epoch(farFutureEpoch)
+	validator := func(pubkey byte, activation phase0.Epoch, exit phase0.Epoch, withdrawable phase0.Epoch) *phase0.Validator {
+		return &phase0.Validator{
+			PublicKey:                  phase0.BLSPubKey{pubkey},
+			WithdrawalCredentials:      []byte{0x00, pubkey},
+			ActivationEligibilityEpoch: 1,
+			ActivationEpoch:            activation,
+			ExitEpoch:                  exit,
+			WithdrawableEpoch:          withdrawable,
+		}
+	}
+
+	currentState := &AgnosticState{
+		Epoch: 10,
+		Validators: []*phase0.Validator{
+			validator(0, 2, farFuture, farFuture),
+			validator(1, 11, farFuture, farFuture),
+			validator(2, 2, farFuture, farFuture),
+			validator(3, 2, 3, 11),
+		},
+		Balances: []phase0.Gwei{32, 32, 32, 32},
+	}
+
+	slashed := validator(2, 2, 15, 8203)
+	slashed.Slashed = true
+	withdrawalCredentials := validator(1, 11, farFuture, farFuture)
+	withdrawalCredentials.WithdrawalCredentials = []byte{0x01, 1}
+	newValidator := validator(4, farFuture, farFuture, farFuture)
+	newValidator.ActivationEligibilityEpoch = farFuture
+
+	nextState := &AgnosticState{
+		Epoch: 11,
+		Slot:  383,
+		Validators: []*phase0.Validator{
+			validator(0, 2, 20, 276),
+			withdrawalCredentials,
+			slashed,
+			validator(3, 2, 3, 11),
+			newValidator,
+		},
+		Balances: []phase0.Gwei{32, 32, 31, 0, 32},
+		Blocks: []*AgnosticBlock{
+			{Slot: 360, VoluntaryExits: []*phase0.SignedVoluntaryExit{{Message: &phase0.VoluntaryExit{ValidatorIndex: 0}}}},
+			{Slot: 365, Deposits: []*phase0.Deposit{{Data: &phase0.DepositData{PublicKey: phase0.BLSPubKey{4}, Amount: 32}}}},
+			{Slot: 370, ProposerSlashings: []*phase0.ProposerSlashing{
+				{SignedHeader1: &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{ProposerIndex: 2}}},
+			}},
+			{Slot: 375, BLSToExecutionChanges: []*capella.SignedBLSToExecutionChange{
+				{Message: &capella.BLSToExecutionChange{ValidatorIndex: 1, FromBLSPubkey: phase0.BLSPubKey{1}}},
+			}},
+			{Slot: 380, ExecutionPayload: AgnosticExecutionPayload{Withdrawals: []*capella.Withdrawal{{ValidatorIndex: 3, Amount: 32}}}},
+		},
+	}
+	nextState.CalculateWithdrawals()
+	nextState.CalculateDeposits()
+
+	events := GetValidatorEvents(currentState, nextState)
+	assert.Equal(t, []ValidatorEvent{
+		{ValidatorIndex: 0, Epoch: 11, Slot: 360, Event: ExitInitiatedEvent, EffectiveEpoch: 20},
+		{ValidatorIndex: 1, Epoch: 11, Slot: 383, Event: ActivationEvent, EffectiveEpoch: 11},
+		{ValidatorIndex: 1, Epoch: 11, Slot: 375, Event: WithdrawalCredentialsEvent, EffectiveEpoch: 11},
+		{ValidatorIndex: 2, Epoch: 11, Slot: 370, Event: ExitInitiatedEvent, EffectiveEpoch: 15},
+		{ValidatorIndex: 2, Epoch: 11, Slot: 370, Event: SlashedEvent, EffectiveEpoch: 8203},
+		{ValidatorIndex: 3, Epoch: 11, Slot: 383, Event: WithdrawableEvent, EffectiveEpoch: 11},
+		{ValidatorIndex: 3, Epoch: 11, Slot: 380, Event: FullyWithdrawnEvent, EffectiveEpoch: 11},
+		{ValidatorIndex: 4, Epoch: 11, Slot: 365, Event: DepositEvent, EffectiveEpoch: 11},
+	}, events)
+}
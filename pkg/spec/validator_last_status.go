@@ -16,11 +16,11 @@ type ValidatorLastStatus struct {
 	PublicKey       phase0.BLSPubKey
 }
 
-func (f ValidatorLastStatus) ToArray() []interface{} {
+func (f ValidatorLastStatus) ToArray(chainSpec *ChainSpec) []interface{} {
 	resultArgs := make([]interface{}, 0)
 	resultArgs = append(resultArgs, f.ValIdx)
 	resultArgs = append(resultArgs, f.Epoch)
-	resultArgs = append(resultArgs, f.BalanceToEth())
+	resultArgs = append(resultArgs, f.BalanceToEth(chainSpec))
 	resultArgs = append(resultArgs, f.CurrentStatus)
 	resultArgs = append(resultArgs, f.Slashed)
 	resultArgs = append(resultArgs, f.ActivationEpoch)
@@ -34,6 +34,6 @@ func (f ValidatorLastStatus) Type() ModelType {
 	return ValidatorLastStatusModel
 }
 
-func (f ValidatorLastStatus) BalanceToEth() float32 {
-	return float32(f.CurrentBalance) / EffectiveBalanceInc
+func (f ValidatorLastStatus) BalanceToEth(chainSpec *ChainSpec) float32 {
+	return float32(f.CurrentBalance) / float32(chainSpec.EffectiveBalanceIncrement)
 }
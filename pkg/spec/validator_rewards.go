@@ -23,21 +23,30 @@ type ValidatorRewards struct {
 	MissingHead          bool
 	Status               ValidatorStatus
 	InclusionDelay       int
+
+	// breakdown of the reward: the components and the proposer reward add up to it
+	FlagsReward        phase0.Gwei // attestation flags of two epochs before
+	FlagsPenalty       phase0.Gwei // missed source and target flags of two epochs before
+	InactivityPenalty  phase0.Gwei // from the inactivity score, mostly during a leak
+	SlashingPenalty    phase0.Gwei // initial penalty when slashed in the epoch
+	CorrelationPenalty phase0.Gwei // halfway to withdrawable, proportional to the balance slashed around the validator
+	SyncReward         phase0.Gwei // signed sync committee slots
+	SyncPenalty        phase0.Gwei // missed sync committee slots
 }
 
 func (f ValidatorRewards) Type() ModelType {
 	return ValidatorRewardsModel
 }
 
-func (f ValidatorRewards) BalanceToEth() float32 {
-	return float32(f.ValidatorBalance) / EffectiveBalanceInc
+func (f ValidatorRewards) BalanceToEth(chainSpec *ChainSpec) float32 {
+	return float32(f.ValidatorBalance) / float32(chainSpec.EffectiveBalanceIncrement)
 }
 
-func (f ValidatorRewards) ToArray() []interface{} {
+func (f ValidatorRewards) ToArray(chainSpec *ChainSpec) []interface{} {
 	rows := []interface{}{
 		f.ValidatorIndex,
 		f.Epoch,
-		f.BalanceToEth(),
+		f.BalanceToEth(chainSpec),
 		f.Reward,
 		f.MaxReward,
 		f.AttestationReward,
@@ -50,6 +59,13 @@ func (f ValidatorRewards) ToArray() []interface{} {
 		f.MissingHead,
 		f.Status,
 		f.InclusionDelay,
+		f.FlagsReward,
+		f.FlagsPenalty,
+		f.InactivityPenalty,
+		f.SlashingPenalty,
+		f.CorrelationPenalty,
+		f.SyncReward,
+		f.SyncPenalty,
 	}
 	return rows
 }
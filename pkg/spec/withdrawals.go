@@ -17,3 +17,16 @@ type Withdrawal struct {
 func (f Withdrawal) Type() ModelType {
 	return WithdrawalModel
 }
+
+// BLSToExecutionChange moves the withdrawal credentials of a validator from a BLS key (0x00)
+// to an execution address (0x01), from Capella
+type BLSToExecutionChange struct {
+	Slot               phase0.Slot
+	ValidatorIndex     phase0.ValidatorIndex
+	FromBLSPubkey      phase0.BLSPubKey
+	ToExecutionAddress bellatrix.ExecutionAddress
+}
+
+func (f BLSToExecutionChange) Type() ModelType {
+	return BLSToExecutionChangeModel
+}